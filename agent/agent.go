@@ -0,0 +1,74 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package agent implements the slnc signing agent: a process holding an
+// opened vault which signs messages on behalf of other slnc processes over
+// a Unix socket, like `ssh-agent` does for SSH keys. The private keys never
+// leave the agent process.
+package agent
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/streamingfast/slnc/policy"
+	"github.com/streamingfast/slnc/vault"
+	"github.com/streamingfast/solana-go"
+)
+
+// maxRequestSize bounds the size of a request read by the agent, the
+// largest Solana transaction message is 1232 bytes.
+const maxRequestSize = 64 * 1024
+
+type request struct {
+	Op        string `json:"op"`
+	PublicKey string `json:"public_key,omitempty"`
+	Message   []byte `json:"message,omitempty"`
+}
+
+type response struct {
+	Keys      []*KeyInfo                `json:"keys,omitempty"`
+	WatchOnly []*vault.WatchOnlyAddress `json:"watch_only,omitempty"`
+	Signature string                    `json:"signature,omitempty"`
+	Error     string                    `json:"error,omitempty"`
+}
+
+// KeyInfo describes a key held by the agent.
+type KeyInfo struct {
+	PublicKey solana.PublicKey `json:"public_key"`
+	Label     string           `json:"label,omitempty"`
+	// Policy is the spending policy of the key, enforced by the agent when
+	// signing and by its clients to refuse early.
+	Policy *policy.KeyPolicy `json:"policy,omitempty"`
+}
+
+// DefaultSocketPath returns the socket path used when none is configured,
+// in `$XDG_RUNTIME_DIR` when set and in a per-user directory of the
+// temporary directory otherwise.
+func DefaultSocketPath() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "slnc", "agent.sock")
+	}
+
+	return filepath.Join(os.TempDir(), fmt.Sprintf("slnc-%d", os.Getuid()), "agent.sock")
+}
+
+func decodeSignature(in string) (solana.Signature, error) {
+	signature, err := solana.SignatureFromBase58(in)
+	if err != nil {
+		return solana.Signature{}, fmt.Errorf("invalid signature from agent: %w", err)
+	}
+	return signature, nil
+}
@@ -0,0 +1,95 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/streamingfast/slnc/vault"
+	"github.com/streamingfast/solana-go"
+)
+
+// Client talks to the agent listening on a Unix socket.
+type Client struct {
+	socketPath string
+}
+
+func NewClient(socketPath string) *Client {
+	return &Client{socketPath: socketPath}
+}
+
+// List returns the keys held by the agent and the watch-only addresses of
+// its vault.
+func (c *Client) List() ([]*KeyInfo, []*vault.WatchOnlyAddress, error) {
+	resp, err := c.call(&request{Op: "list"})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return resp.Keys, resp.WatchOnly, nil
+}
+
+// Sign has the agent sign `message` with the key `pub`.
+func (c *Client) Sign(pub solana.PublicKey, message []byte) (solana.Signature, error) {
+	resp, err := c.call(&request{Op: "sign", PublicKey: pub.String(), Message: message})
+	if err != nil {
+		return solana.Signature{}, err
+	}
+
+	signature, err := decodeSignature(resp.Signature)
+	if err != nil {
+		return solana.Signature{}, err
+	}
+
+	if !signature.Verify(pub, message) {
+		return solana.Signature{}, fmt.Errorf("agent returned an invalid signature for key %s", pub)
+	}
+
+	return signature, nil
+}
+
+// Stop has the agent forget its keys and exit.
+func (c *Client) Stop() error {
+	_, err := c.call(&request{Op: "stop"})
+	return err
+}
+
+func (c *Client) call(req *request) (*response, error) {
+	conn, err := net.DialTimeout("unix", c.socketPath, 5*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("connect to agent on %q (is `slnc agent start` running?): %w", c.socketPath, err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(30 * time.Second))
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return nil, fmt.Errorf("send request to agent: %w", err)
+	}
+
+	resp := &response{}
+	if err := json.NewDecoder(conn).Decode(resp); err != nil {
+		return nil, fmt.Errorf("read agent response: %w", err)
+	}
+
+	if resp.Error != "" {
+		return nil, errors.New("agent: " + resp.Error)
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,279 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/streamingfast/slnc/vault"
+	"github.com/streamingfast/solana-go"
+	"go.uber.org/zap"
+)
+
+// SignCheckFunc refuses, returning an error, `message` to be signed by
+// `pub`.
+type SignCheckFunc func(pub solana.PublicKey, message []byte) error
+
+// SignedFunc is called with every message signed by the agent, the
+// signature is not returned when it fails.
+type SignedFunc func(pub solana.PublicKey, message []byte, signature solana.Signature) error
+
+// Server serves the signing requests for the keys of an opened vault.
+type Server struct {
+	socketPath string
+	timeout    time.Duration
+	logger     *zap.Logger
+	check      SignCheckFunc
+	signed     SignedFunc
+
+	// lock is held for reading while a key signs, `Stop` wiping the keys
+	// waits for the signatures in flight.
+	lock      sync.RWMutex
+	keys      map[solana.PublicKey]solana.PrivateKey
+	infos     []*KeyInfo
+	watchOnly []*vault.WatchOnlyAddress
+	listener  net.Listener
+	done      chan struct{}
+	stopOnce  sync.Once
+}
+
+// NewServer returns a server for the keys of the opened vault `v`, it stops
+// and forgets the keys after `timeout`, zero meaning never. It fails when the
+// public key index of the vault does not vouch for its keys and watch-only
+// addresses, see `vault.CheckPublicKeyIndex`.
+func NewServer(v *vault.Vault, socketPath string, timeout time.Duration, logger *zap.Logger) (*Server, error) {
+	if err := v.CheckPublicKeyIndex(); err != nil {
+		return nil, fmt.Errorf("vault does not verify: %w", err)
+	}
+
+	s := &Server{
+		socketPath: socketPath,
+		timeout:    timeout,
+		logger:     logger,
+		keys:       map[solana.PublicKey]solana.PrivateKey{},
+		done:       make(chan struct{}),
+	}
+
+	for _, key := range v.KeyBag {
+		pub := key.PublicKey()
+		info := &KeyInfo{PublicKey: pub}
+		if metadata := v.Metadata[pub.String()]; metadata != nil {
+			info.Label = metadata.Label
+			info.Policy = metadata.Policy
+		}
+
+		s.keys[pub] = append(solana.PrivateKey{}, key...)
+		s.infos = append(s.infos, info)
+	}
+
+	for _, address := range v.WatchOnly {
+		copied := *address
+		s.watchOnly = append(s.watchOnly, &copied)
+	}
+
+	return s, nil
+}
+
+// SetSignCheck adds a check of the messages before signing them, it's what
+// enforces the spending policies of the keys: any process reaching the
+// socket can ask for signatures.
+func (s *Server) SetSignCheck(check SignCheckFunc) {
+	s.check = check
+}
+
+// SetSignedHook sets the function called with every signed message, it's
+// what records the signatures of the agent in the audit log.
+func (s *Server) SetSignedHook(signed SignedFunc) {
+	s.signed = signed
+}
+
+// Listen creates the socket, only accessible to the current user, in a
+// directory owned by the current user and only accessible to them. An
+// existing socket is replaced only when no agent answers on it anymore.
+func (s *Server) Listen() error {
+	dir := filepath.Dir(s.socketPath)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("create socket directory: %w", err)
+	}
+
+	if err := checkSocketDir(dir); err != nil {
+		return fmt.Errorf("unsafe socket directory: %w", err)
+	}
+
+	if _, err := os.Stat(s.socketPath); err == nil {
+		if conn, err := net.DialTimeout("unix", s.socketPath, time.Second); err == nil {
+			conn.Close()
+			return fmt.Errorf("an agent is already listening on %q", s.socketPath)
+		}
+
+		if err := os.Remove(s.socketPath); err != nil {
+			return fmt.Errorf("remove stale socket: %w", err)
+		}
+	}
+
+	listener, err := net.Listen("unix", s.socketPath)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Chmod(s.socketPath, 0600); err != nil {
+		listener.Close()
+		return err
+	}
+
+	s.listener = listener
+	return nil
+}
+
+// Serve accepts signing requests until the timeout expires or `Stop` is
+// called.
+func (s *Server) Serve() error {
+	if s.timeout > 0 {
+		timer := time.AfterFunc(s.timeout, func() {
+			s.logger.Info("agent timeout expired, stopping")
+			s.Stop()
+		})
+		defer timer.Stop()
+	}
+
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			select {
+			case <-s.done:
+				return nil
+			default:
+			}
+			return err
+		}
+
+		go s.handle(conn)
+	}
+}
+
+// Stop closes the socket and wipes the keys from memory.
+func (s *Server) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.done)
+
+		s.lock.Lock()
+		for pub, key := range s.keys {
+			for i := range key {
+				key[i] = 0
+			}
+			delete(s.keys, pub)
+		}
+		s.lock.Unlock()
+
+		if s.listener != nil {
+			s.listener.Close()
+		}
+	})
+}
+
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(30 * time.Second))
+
+	enc := json.NewEncoder(conn)
+
+	req := &request{}
+	if err := json.NewDecoder(io.LimitReader(conn, maxRequestSize)).Decode(req); err != nil {
+		enc.Encode(&response{Error: fmt.Sprintf("invalid request: %s", err)})
+		return
+	}
+
+	resp, err := s.process(req)
+	if err != nil {
+		s.logger.Info("agent request failed", zap.String("op", req.Op), zap.Error(err))
+		resp = &response{Error: err.Error()}
+	}
+
+	enc.Encode(resp)
+}
+
+func (s *Server) process(req *request) (*response, error) {
+	switch req.Op {
+	case "list":
+		return &response{Keys: s.infos, WatchOnly: s.watchOnly}, nil
+
+	case "sign":
+		pub, err := solana.PublicKeyFromBase58(req.PublicKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid public key: %w", err)
+		}
+
+		if !s.holds(pub) {
+			return nil, fmt.Errorf("key %s not held by the agent", pub)
+		}
+
+		if s.check != nil {
+			if err := s.check(pub, req.Message); err != nil {
+				return nil, err
+			}
+		}
+
+		signature, err := s.sign(pub, req.Message)
+		if err != nil {
+			return nil, err
+		}
+
+		if s.signed != nil {
+			if err := s.signed(pub, req.Message, signature); err != nil {
+				return nil, fmt.Errorf("unable to record signature: %w", err)
+			}
+		}
+
+		s.logger.Info("agent signed message", zap.Stringer("public_key", pub), zap.Int("message_size", len(req.Message)))
+		return &response{Signature: signature.String()}, nil
+
+	case "stop":
+		go s.Stop()
+		return &response{}, nil
+
+	default:
+		return nil, errors.New("unknown operation " + req.Op)
+	}
+}
+
+func (s *Server) holds(pub solana.PublicKey) bool {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	_, found := s.keys[pub]
+	return found
+}
+
+// sign signs `message` with the key `pub`, which is gone once the agent is
+// stopped.
+func (s *Server) sign(pub solana.PublicKey, message []byte) (solana.Signature, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	key, found := s.keys[pub]
+	if !found {
+		return solana.Signature{}, fmt.Errorf("key %s not held by the agent", pub)
+	}
+
+	return key.Sign(message)
+}
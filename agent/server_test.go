@@ -0,0 +1,134 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/streamingfast/slnc/vault"
+	"github.com/streamingfast/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newTestVault(t *testing.T) (*vault.Vault, solana.PrivateKey) {
+	_, privateKey, err := solana.NewRandomPrivateKey()
+	require.NoError(t, err)
+
+	v := vault.NewVault()
+	v.AddPrivateKey(privateKey)
+	require.NoError(t, v.SetLabel(privateKey.PublicKey(), "hot"))
+
+	return v, privateKey
+}
+
+func TestServer_RoundTrip(t *testing.T) {
+	v, privateKey := newTestVault(t)
+	pub := privateKey.PublicKey()
+
+	socket := filepath.Join(t.TempDir(), "slnc", "agent.sock")
+	server, err := NewServer(v, socket, 0, zap.NewNop())
+	require.NoError(t, err)
+	var recorded []solana.Signature
+	server.SetSignedHook(func(signer solana.PublicKey, message []byte, signature solana.Signature) error {
+		assert.Equal(t, pub, signer)
+		recorded = append(recorded, signature)
+		return nil
+	})
+	require.NoError(t, server.Listen())
+
+	served := make(chan error, 1)
+	go func() { served <- server.Serve() }()
+
+	client := NewClient(socket)
+	keys, _, err := client.List()
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.Equal(t, pub, keys[0].PublicKey)
+	assert.Equal(t, "hot", keys[0].Label)
+
+	message := []byte("slnc agent test message")
+	signature, err := client.Sign(pub, message)
+	require.NoError(t, err)
+	assert.True(t, signature.Verify(pub, message))
+	assert.Equal(t, []solana.Signature{signature}, recorded)
+
+	_, otherKey, err := solana.NewRandomPrivateKey()
+	require.NoError(t, err)
+	_, err = client.Sign(otherKey.PublicKey(), message)
+	assert.Error(t, err)
+
+	require.NoError(t, client.Stop())
+	select {
+	case err := <-served:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("agent did not stop")
+	}
+
+	server.lock.RLock()
+	assert.Empty(t, server.keys)
+	server.lock.RUnlock()
+
+	_, err = server.sign(pub, message)
+	assert.Error(t, err)
+}
+
+func TestServer_Listen_UnsafeSocketDir(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("directory ownership is not checked on Windows")
+	}
+
+	v, _ := newTestVault(t)
+
+	dir := filepath.Join(t.TempDir(), "shared")
+	require.NoError(t, os.Mkdir(dir, 0700))
+	require.NoError(t, os.Chmod(dir, 0777))
+
+	server, err := NewServer(v, filepath.Join(dir, "agent.sock"), 0, zap.NewNop())
+	require.NoError(t, err)
+	assert.Error(t, server.Listen())
+
+	link := filepath.Join(t.TempDir(), "link")
+	require.NoError(t, os.Symlink(t.TempDir(), link))
+
+	server, err = NewServer(v, filepath.Join(link, "agent.sock"), 0, zap.NewNop())
+	require.NoError(t, err)
+	assert.Error(t, server.Listen())
+}
+
+func TestNewServer_RejectsAlteredWatchOnly(t *testing.T) {
+	v, _ := newTestVault(t)
+	watched, _, err := solana.NewRandomPrivateKey()
+	require.NoError(t, err)
+	_, err = v.AddWatchOnly(watched, "exchange-deposit")
+	require.NoError(t, err)
+	require.NoError(t, v.Seal(vault.NewPassphraseBoxer("secret")))
+
+	_, err = NewServer(v, filepath.Join(t.TempDir(), "agent.sock"), 0, zap.NewNop())
+	require.NoError(t, err)
+
+	swapped, _, err := solana.NewRandomPrivateKey()
+	require.NoError(t, err)
+	v.WatchOnly[0].PublicKey = swapped
+
+	_, err = NewServer(v, filepath.Join(t.TempDir(), "agent.sock"), 0, zap.NewNop())
+	assert.Error(t, err)
+}
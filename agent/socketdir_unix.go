@@ -0,0 +1,53 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !windows
+// +build !windows
+
+package agent
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// checkSocketDir refuses a socket directory another user could have
+// created or could write to, like a `slnc-<uid>` directory planted in the
+// shared temporary directory.
+func checkSocketDir(dir string) error {
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return err
+	}
+
+	if !info.IsDir() {
+		return fmt.Errorf("%q is not a directory", dir)
+	}
+
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fmt.Errorf("unable to read the owner of %q", dir)
+	}
+
+	if int(stat.Uid) != os.Getuid() {
+		return fmt.Errorf("%q is owned by uid %d, not by the current user", dir, stat.Uid)
+	}
+
+	if info.Mode().Perm() != 0700 {
+		return fmt.Errorf("%q has mode %s, it must be only accessible to the current user (0700)", dir, info.Mode().Perm())
+	}
+
+	return nil
+}
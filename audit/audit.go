@@ -0,0 +1,265 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package audit implements an append-only, hash-chained log of the
+// signatures made with vault keys. Each entry holds the hash of the
+// previous one, so altering or removing an entry breaks the chain of every
+// entry after it.
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/streamingfast/slnc/internal/lockfile"
+)
+
+// Entry is a signature recorded in the log.
+type Entry struct {
+	Sequence  uint64    `json:"sequence"`
+	Timestamp time.Time `json:"timestamp"`
+	Command   string    `json:"command"`
+	VaultFile string    `json:"vault_file,omitempty"`
+
+	// TransactionSignature is the first signature of the transaction, its
+	// identifier, unknown when the fee payer is not a vault key and has not
+	// signed yet.
+	TransactionSignature string   `json:"transaction_signature,omitempty"`
+	Signers              []string `json:"signers"`
+	Instructions         []string `json:"instructions"`
+	RPCEndpoint          string   `json:"rpc_endpoint,omitempty"`
+
+	// MessageHash is the hex encoded SHA-256 of a message signed instead of
+	// a transaction, MessageSignature its signature.
+	MessageHash      string `json:"message_hash,omitempty"`
+	MessageSignature string `json:"message_signature,omitempty"`
+
+	PreviousHash string `json:"previous_hash"`
+	Hash         string `json:"hash"`
+}
+
+func (e *Entry) computeHash() (string, error) {
+	unhashed := *e
+	unhashed.Hash = ""
+
+	cnt, err := json.Marshal(&unhashed)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(cnt)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Log is an audit log file, one JSON entry per line.
+type Log struct {
+	filename string
+}
+
+// NewLog returns the log stored in `filename`, created on first append.
+func NewLog(filename string) *Log {
+	return &Log{filename: filename}
+}
+
+func (l *Log) Filename() string {
+	return l.filename
+}
+
+// Append chains `entry` to the last one of the log and writes it, setting
+// its sequence, timestamp when zero, and hashes.
+func (l *Log) Append(entry *Entry) error {
+	if err := os.MkdirAll(filepath.Dir(l.filename), 0700); err != nil {
+		return fmt.Errorf("create audit log directory: %w", err)
+	}
+
+	unlock, err := l.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	last, err := l.lastEntry()
+	if err != nil {
+		return err
+	}
+
+	entry.Sequence = 1
+	entry.PreviousHash = ""
+	if last != nil {
+		entry.Sequence = last.Sequence + 1
+		entry.PreviousHash = last.Hash
+	}
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now().UTC()
+	}
+
+	if entry.Hash, err = entry.computeHash(); err != nil {
+		return fmt.Errorf("hash audit entry: %w", err)
+	}
+
+	cnt, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("encode audit entry: %w", err)
+	}
+
+	file, err := os.OpenFile(l.filename, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return fmt.Errorf("open audit log: %w", err)
+	}
+
+	if _, err := file.Write(append(cnt, '\n')); err != nil {
+		file.Close()
+		return fmt.Errorf("write audit log: %w", err)
+	}
+
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return fmt.Errorf("write audit log: %w", err)
+	}
+
+	return file.Close()
+}
+
+// Entries returns the entries of the log, none when it does not exist.
+func (l *Log) Entries() ([]*Entry, error) {
+	file, err := os.Open(l.filename)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open audit log: %w", err)
+	}
+	defer file.Close()
+
+	var entries []*Entry
+	reader := bufio.NewReader(file)
+	for line := 1; ; line++ {
+		cnt, err := reader.ReadBytes('\n')
+		if err == io.EOF && len(cnt) == 0 {
+			return entries, nil
+		}
+		if err != nil && err != io.EOF {
+			return nil, fmt.Errorf("read audit log: %w", err)
+		}
+
+		entry := &Entry{}
+		if err := json.Unmarshal(cnt, entry); err != nil {
+			return nil, fmt.Errorf("line %d of audit log: %w", line, err)
+		}
+		entries = append(entries, entry)
+	}
+}
+
+// Verify checks the chain of the log: every entry must follow the previous
+// one, hash to its recorded hash and reference the hash of the previous
+// entry. It returns the last entry, nil for an empty log.
+func (l *Log) Verify() (*Entry, error) {
+	entries, err := l.Entries()
+	if err != nil {
+		return nil, err
+	}
+
+	var previous *Entry
+	for _, entry := range entries {
+		expectedSequence, expectedPrevious := uint64(1), ""
+		if previous != nil {
+			expectedSequence, expectedPrevious = previous.Sequence+1, previous.Hash
+		}
+
+		if entry.Sequence != expectedSequence {
+			return nil, fmt.Errorf("entry #%d: expected sequence %d, entries were removed or reordered", entry.Sequence, expectedSequence)
+		}
+
+		if entry.PreviousHash != expectedPrevious {
+			return nil, fmt.Errorf("entry #%d: previous hash does not match the hash of entry #%d", entry.Sequence, entry.Sequence-1)
+		}
+
+		hash, err := entry.computeHash()
+		if err != nil {
+			return nil, fmt.Errorf("entry #%d: %w", entry.Sequence, err)
+		}
+
+		if hash != entry.Hash {
+			return nil, fmt.Errorf("entry #%d: content does not match its hash, it was altered", entry.Sequence)
+		}
+
+		previous = entry
+	}
+
+	return previous, nil
+}
+
+// lastEntry reads the last line of the log, entries being at most a few
+// kilobytes it's found in the tail of the file.
+func (l *Log) lastEntry() (*Entry, error) {
+	file, err := os.Open(l.filename)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open audit log: %w", err)
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("stat audit log: %w", err)
+	}
+
+	if stat.Size() == 0 {
+		return nil, nil
+	}
+
+	tailSize := int64(256 * 1024)
+	if tailSize > stat.Size() {
+		tailSize = stat.Size()
+	}
+
+	tail := make([]byte, tailSize)
+	if _, err := file.ReadAt(tail, stat.Size()-tailSize); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("read audit log: %w", err)
+	}
+
+	tail = bytes.TrimRight(tail, "\n")
+	if idx := bytes.LastIndexByte(tail, '\n'); idx >= 0 {
+		tail = tail[idx+1:]
+	} else if tailSize != stat.Size() {
+		return nil, errors.New("last entry of audit log is too large")
+	}
+
+	entry := &Entry{}
+	if err := json.Unmarshal(tail, entry); err != nil {
+		return nil, fmt.Errorf("decode last entry of audit log: %w", err)
+	}
+	return entry, nil
+}
+
+// lock takes the lock file of the log so concurrent processes do not fork
+// the chain.
+func (l *Log) lock() (unlock func(), err error) {
+	unlock, err = lockfile.Lock(l.filename+".lock", 30*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("lock audit log: %w", err)
+	}
+	return unlock, nil
+}
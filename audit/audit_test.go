@@ -0,0 +1,196 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLog(t *testing.T, entries int) *Log {
+	log := NewLog(filepath.Join(t.TempDir(), "audit", "audit.log"))
+	for i := 0; i < entries; i++ {
+		require.NoError(t, log.Append(&Entry{Command: fmt.Sprintf("slnc test %d", i+1), Signers: []string{"signer"}}))
+	}
+	return log
+}
+
+func readLines(t *testing.T, log *Log) [][]byte {
+	cnt, err := ioutil.ReadFile(log.Filename())
+	require.NoError(t, err)
+	return bytes.SplitAfter(bytes.TrimRight(cnt, "\n"), []byte("\n"))
+}
+
+func writeLines(t *testing.T, log *Log, lines [][]byte) {
+	cnt := bytes.Join(lines, nil)
+	if !bytes.HasSuffix(cnt, []byte("\n")) {
+		cnt = append(cnt, '\n')
+	}
+	require.NoError(t, ioutil.WriteFile(log.Filename(), cnt, 0600))
+}
+
+func TestLog_AppendChains(t *testing.T) {
+	log := newTestLog(t, 3)
+
+	entries, err := log.Entries()
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+
+	for i, entry := range entries {
+		assert.Equal(t, uint64(i+1), entry.Sequence)
+		assert.False(t, entry.Timestamp.IsZero())
+
+		hash, err := entry.computeHash()
+		require.NoError(t, err)
+		assert.Equal(t, hash, entry.Hash)
+
+		if i == 0 {
+			assert.Empty(t, entry.PreviousHash)
+		} else {
+			assert.Equal(t, entries[i-1].Hash, entry.PreviousHash)
+		}
+	}
+
+	last, err := log.Verify()
+	require.NoError(t, err)
+	assert.Equal(t, entries[2], last)
+}
+
+func TestLog_VerifyEmpty(t *testing.T) {
+	last, err := newTestLog(t, 0).Verify()
+	require.NoError(t, err)
+	assert.Nil(t, last)
+}
+
+func TestLog_VerifyDetectsTampering(t *testing.T) {
+	tests := []struct {
+		name     string
+		tamper   func(lines [][]byte) [][]byte
+		expected string
+	}{
+		{
+			name: "edited",
+			tamper: func(lines [][]byte) [][]byte {
+				lines[1] = bytes.Replace(lines[1], []byte("slnc test 2"), []byte("slnc test X"), 1)
+				return lines
+			},
+			expected: "entry #2: content does not match its hash, it was altered",
+		},
+		{
+			name: "removed",
+			tamper: func(lines [][]byte) [][]byte {
+				return append(lines[:1], lines[2:]...)
+			},
+			expected: "entry #3: expected sequence 2, entries were removed or reordered",
+		},
+		{
+			name: "reordered",
+			tamper: func(lines [][]byte) [][]byte {
+				lines[1], lines[2] = lines[2], lines[1]
+				return lines
+			},
+			expected: "entry #3: expected sequence 2, entries were removed or reordered",
+		},
+		{
+			name: "rechained",
+			tamper: func(lines [][]byte) [][]byte {
+				lines[2] = bytes.Replace(lines[2], []byte(`"previous_hash":"`), []byte(`"previous_hash":"00`), 1)
+				return lines
+			},
+			expected: "entry #3: previous hash does not match the hash of entry #2",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			log := newTestLog(t, 4)
+			writeLines(t, log, test.tamper(readLines(t, log)))
+
+			_, err := log.Verify()
+			assert.EqualError(t, err, test.expected)
+		})
+	}
+}
+
+// Entries cut off the end leave a valid chain, only the head hash kept
+// elsewhere tells.
+func TestLog_VerifyTruncated(t *testing.T) {
+	log := newTestLog(t, 3)
+	head, err := log.Verify()
+	require.NoError(t, err)
+
+	writeLines(t, log, readLines(t, log)[:2])
+
+	last, err := log.Verify()
+	require.NoError(t, err)
+	assert.NotEqual(t, head.Hash, last.Hash)
+}
+
+func TestLog_LastEntryLargeTail(t *testing.T) {
+	log := newTestLog(t, 0)
+
+	// Entries spanning more than the tail read by `lastEntry`
+	instructions := []string{strings.Repeat("i", 40*1024)}
+	for i := 0; i < 10; i++ {
+		require.NoError(t, log.Append(&Entry{Command: "slnc test", Instructions: instructions}))
+	}
+
+	last, err := log.lastEntry()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(10), last.Sequence)
+
+	require.NoError(t, log.Append(&Entry{Command: "slnc test"}))
+	last, err = log.Verify()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(11), last.Sequence)
+
+	err = log.Append(&Entry{Command: "slnc test", Instructions: []string{strings.Repeat("i", 300*1024)}})
+	require.NoError(t, err)
+	_, err = log.lastEntry()
+	assert.EqualError(t, err, "last entry of audit log is too large")
+}
+
+func TestLog_ConcurrentAppends(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "audit.log")
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// A log per goroutine, like separate processes sharing the file
+			errs <- NewLog(filename).Append(&Entry{Command: fmt.Sprintf("slnc test %d", i)})
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	last, err := NewLog(filename).Verify()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(20), last.Sequence)
+}
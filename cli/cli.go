@@ -15,8 +15,11 @@
 package cli
 
 import (
+	"bufio"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 
 	"github.com/pkg/errors"
 	"golang.org/x/crypto/ssh/terminal"
@@ -48,6 +51,19 @@ func GetEncryptPassphrase() (string, error) {
 	return passphrase, nil
 }
 
+// GetInput reads a line from the terminal, it's shown while typed, use
+// `GetPassword` for secrets.
+func GetInput(input string) (string, error) {
+	fmt.Printf(input)
+	line, err := stdinReader.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+var stdinReader = bufio.NewReader(os.Stdin)
+
 func GetPassword(input string) (string, error) {
 	fd := os.Stdin.Fd()
 	fmt.Printf(input)
@@ -0,0 +1,103 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/streamingfast/slnc/agent"
+	"github.com/streamingfast/slnc/vault"
+	"github.com/streamingfast/solana-go"
+)
+
+var agentCmd = &cobra.Command{
+	Use:   "agent",
+	Short: "Signing agent holding the opened vault for other slnc commands",
+}
+
+func init() {
+	RootCmd.AddCommand(agentCmd)
+}
+
+func isAgentMode() bool {
+	return viper.GetBool("global-agent")
+}
+
+func getAgentSocket() string {
+	if socket := viper.GetString("global-agent-socket"); socket != "" {
+		return socket
+	}
+	return agent.DefaultSocketPath()
+}
+
+// setupAgentWallet returns a vault mirroring the keys held by the signing
+// agent, and its watch-only addresses. Its private keys are placeholders,
+// see `placeholderKey`, the signatures being made by the agent.
+func setupAgentWallet() (*vault.Vault, error) {
+	keys, watchOnly, err := agent.NewClient(getAgentSocket()).List()
+	if err != nil {
+		return nil, err
+	}
+
+	v := vault.NewVault()
+	for _, key := range keys {
+		pub := v.AddPrivateKey(placeholderKey(key.PublicKey))
+		v.KeyMetadata(pub).CreatedAt = time.Time{}
+		v.KeyMetadata(pub).Policy = key.Policy
+		if key.Label != "" {
+			if err := v.SetLabel(pub, key.Label); err != nil {
+				return nil, err
+			}
+		}
+	}
+	v.WatchOnly = watchOnly
+
+	return v, nil
+}
+
+// placeholderKey returns a private key whose `PublicKey()` is `pub` but
+// whose secret half is zeroed, it stands for a key held by the agent, or
+// left sealed under --dry-run, so the commands looking up their signers in
+// the vault work unchanged.
+func placeholderKey(pub solana.PublicKey) solana.PrivateKey {
+	key := make(solana.PrivateKey, 64)
+	copy(key[32:], pub[:])
+	return key
+}
+
+func isPlaceholderKey(key solana.PrivateKey) bool {
+	if len(key) != 64 {
+		return false
+	}
+
+	for _, b := range key[:32] {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// signWithKey signs `message` with `privateKey`, through the signing agent
+// when the key is held by it.
+func signWithKey(privateKey solana.PrivateKey, message []byte) (solana.Signature, error) {
+	if isAgentMode() && isPlaceholderKey(privateKey) {
+		return agent.NewClient(getAgentSocket()).Sign(privateKey.PublicKey(), message)
+	}
+
+	return privateKey.Sign(message)
+}
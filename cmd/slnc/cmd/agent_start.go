@@ -0,0 +1,100 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/streamingfast/slnc/agent"
+)
+
+var agentStartCmd = &cobra.Command{
+	Use:   "start",
+	Short: "Open the vault once and serve signing requests over a Unix socket",
+	Long: `Open the vault once and serve signing requests over a Unix socket.
+
+The agent runs in the foreground until --timeout expires, it's stopped by
+'slnc agent stop' or interrupted. Other slnc commands run with --agent (or
+SLNC_GLOBAL_AGENT=true in the environment) have their transactions signed by
+the agent, the private keys never leave the agent process:
+
+    slnc agent start --timeout 30m &
+    export SLNC_GLOBAL_AGENT=true
+    slnc token mint-to ...
+
+The socket is only accessible to the current user, see --agent-socket, and
+the agent refuses a socket directory not owned by the current user with
+mode 0700. The agent enforces the spending policies of the keys itself (see
+'slnc vault policy'): a key having one only signs transactions passing it,
+and off-chain messages. Each signature is recorded in the audit log by the
+agent (see 'slnc vault audit'), as the hash of the signed message.
+`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if isAgentMode() {
+			return fmt.Errorf("the agent opens the vault itself, run it without --agent")
+		}
+
+		wallet, err := getWallet()
+		if err != nil {
+			return err
+		}
+
+		socket := getAgentSocket()
+		timeout := viper.GetDuration("agent-start-cmd-timeout")
+		server, err := agent.NewServer(wallet, socket, timeout, zlog)
+		if err != nil {
+			return err
+		}
+		server.SetSignCheck(enforceMessagePolicy)
+		server.SetSignedHook(recordMessageSignature)
+		if err := server.Listen(); err != nil {
+			return fmt.Errorf("unable to listen on %q: %w", socket, err)
+		}
+		defer os.Remove(socket)
+
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			<-signals
+			server.Stop()
+		}()
+
+		expiry := "until stopped"
+		if timeout > 0 {
+			expiry = fmt.Sprintf("for %s", timeout)
+		}
+		fmt.Fprintf(os.Stderr, "Agent serving %d keys on %q %s.\n", len(wallet.KeyBag), socket, expiry)
+
+		if err := server.Serve(); err != nil {
+			return fmt.Errorf("agent: %w", err)
+		}
+
+		fmt.Fprintln(os.Stderr, "Agent stopped, keys forgotten.")
+		return nil
+	},
+}
+
+func init() {
+	agentCmd.AddCommand(agentStartCmd)
+
+	agentStartCmd.Flags().Duration("timeout", time.Hour, "Time after which the agent forgets the keys and exits (0 means never)")
+}
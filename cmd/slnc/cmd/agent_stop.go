@@ -0,0 +1,50 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	"github.com/streamingfast/slnc/agent"
+)
+
+var agentStopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Have the running signing agent forget its keys and exit",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		socket := getAgentSocket()
+		if err := agent.NewClient(socket).Stop(); err != nil {
+			return err
+		}
+
+		return printOutput(&agentStopOutput{Socket: socket})
+	},
+}
+
+type agentStopOutput struct {
+	Socket string `json:"socket"`
+}
+
+func (o *agentStopOutput) Text(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "Agent on %q stopped.\n", o.Socket)
+	return err
+}
+
+func init() {
+	agentCmd.AddCommand(agentStopCmd)
+}
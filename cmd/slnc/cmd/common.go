@@ -18,15 +18,19 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"os"
 	"strings"
+	"sync"
+	"sync/atomic"
 
 	"github.com/spf13/viper"
 	"github.com/streamingfast/cli"
 	"github.com/streamingfast/dhttp"
 	"github.com/streamingfast/slnc/vault"
+	"github.com/streamingfast/solana-go"
 	"github.com/streamingfast/solana-go/rpc"
 	"github.com/streamingfast/solana-go/rpc/ws"
 	"go.uber.org/zap"
@@ -34,9 +38,11 @@ import (
 
 func getClient(opt ...rpc.ClientOption) *rpc.Client {
 	httpHeaders := viper.GetStringSlice("global-http-header")
-	rpcURL := sanitizeAPIURL(viper.GetString("global-rpc-url"))
-	zlog.Debug("sanitized RPC URL", zap.String("rpc_url", rpcURL))
-	api := rpc.NewClient(rpcURL, opt...)
+	endpoints := getRPCURLs()
+	zlog.Debug("sanitized RPC URLs", zap.Strings("rpc_urls", endpoints))
+	routeRPCRequests(endpoints[0], getRPCHTTPClient(endpoints))
+
+	api := rpc.NewClient(endpoints[0], opt...)
 
 	for i := 0; i < 25; i++ {
 		if val := os.Getenv(fmt.Sprintf("SLNC_GLOBAL_HTTP_HEADER_%d", i)); val != "" {
@@ -54,36 +60,88 @@ func getClient(opt ...rpc.ClientOption) *rpc.Client {
 	return api
 }
 
+// getRPCURLs returns the JSON-RPC endpoints, in order of preference, the
+// first one being the one other clients should be pointed to.
+func getRPCURLs() []string {
+	endpoints := splitEndpoints(viper.GetString("global-rpc-url"))
+	if len(endpoints) == 0 {
+		return []string{defaultRPCURL}
+	}
+	return endpoints
+}
+
 func getRPCURL() string {
-	return sanitizeAPIURL(viper.GetString("global-rpc-url"))
+	return getRPCURLs()[0]
 }
 
+// wsDialCount rotates the first websocket endpoint dialed so that
+// successive clients are spread across endpoints.
+var wsDialCount uint32
+
 func getWsClient(ctx context.Context) (*ws.Client, error) {
-	wsURL := sanitizeAPIURL(viper.GetString("global-ws-url"))
-	if wsURL == "" {
+	endpoints := splitWSEndpoints(viper.GetString("global-ws-url"))
+	if len(endpoints) == 0 {
 		return nil, fmt.Errorf("ws-url not defined")
 	}
 
-	cli := ws.NewClient(wsURL, false)
-	err := cli.Dial(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("unable to dial ws: %w", err)
+	start := int(atomic.AddUint32(&wsDialCount, 1) - 1)
+
+	var errs []string
+	for i := 0; i < len(endpoints); i++ {
+		wsURL := endpoints[(start+i)%len(endpoints)]
+
+		cli := ws.NewClient(wsURL, false)
+		if err := cli.Dial(ctx); err != nil {
+			zlog.Info("unable to dial ws endpoint, trying next one", zap.String("ws_url", wsURL), zap.Error(err))
+			errs = append(errs, fmt.Sprintf("%s: %s", wsURL, err))
+			continue
+		}
+		return cli, nil
 	}
-	return cli, nil
+
+	return nil, fmt.Errorf("unable to dial ws: %s", strings.Join(errs, ", "))
+}
+
+// knownClusters are the cluster monikers accepted in place of an RPC or
+// websocket endpoint.
+var knownClusters = map[string]struct{ rpcURL, wsURL string }{
+	"mainnet":  {defaultRPCURL, defaultWSURL},
+	"devnet":   {"https://api.devnet.solana.com", "wss://api.devnet.solana.com"},
+	"testnet":  {"https://api.testnet.solana.com", "wss://api.testnet.solana.com"},
+	"localnet": {"http://127.0.0.1:8899", "ws://127.0.0.1:8900"},
 }
 
 func sanitizeAPIURL(input string) string {
-	switch input {
-	case "devnet":
-		return "https://devnet.solana.com"
-	case "testnet":
-		return "https://testnet.solana.com"
-	case "mainnet":
-		return "https://api.mainnet-beta.solana.com"
+	if cluster, found := knownClusters[input]; found {
+		return cluster.rpcURL
+	}
+	return strings.TrimRight(input, "/")
+}
+
+func sanitizeWSURL(input string) string {
+	if cluster, found := knownClusters[input]; found {
+		return cluster.wsURL
 	}
 	return strings.TrimRight(input, "/")
 }
 
+// getCommitment returns the commitment configured with `--commitment`, or
+// `fallback` when none is.
+func getCommitment(fallback rpc.CommitmentType) rpc.CommitmentType {
+	if commitment := viper.GetString("global-commitment"); commitment != "" {
+		return rpc.CommitmentType(commitment)
+	}
+	return fallback
+}
+
+func validateCommitment() error {
+	switch rpc.CommitmentType(viper.GetString("global-commitment")) {
+	case "", rpc.CommitmentProcessed, rpc.CommitmentConfirmed, rpc.CommitmentFinalized:
+		return nil
+	}
+	return fmt.Errorf("invalid commitment %q, valid commitments are %s, %s, %s", viper.GetString("global-commitment"), rpc.CommitmentProcessed, rpc.CommitmentConfirmed, rpc.CommitmentFinalized)
+}
+
 func errorCheck(prefix string, err error) {
 	if err != nil {
 		fmt.Printf("ERROR: %s: %s\n", prefix, err)
@@ -94,33 +152,194 @@ func errorCheck(prefix string, err error) {
 	}
 }
 
+// openedWallet keeps the vault once opened so that the passphrase is asked
+// (or KMS called) only once per invocation.
+var openedWallet *vault.Vault
+var openedWalletBoxer vault.SecretBoxer
+var openedWalletLock sync.Mutex
+
 func mustGetWallet() *vault.Vault {
-	vault, err := setupWallet()
+	vault, err := getWallet()
+	errorCheck("wallet setup", err)
+
+	return vault
+}
+
+func getWallet() (*vault.Vault, error) {
+	openedWalletLock.Lock()
+	defer openedWalletLock.Unlock()
+
+	if openedWallet != nil {
+		return openedWallet, nil
+	}
+
+	if isAgentMode() {
+		vault, err := setupAgentWallet()
+		if err != nil {
+			return nil, err
+		}
+
+		openedWallet = vault
+		return vault, nil
+	}
+
+	vault, boxer, err := setupWallet()
+	if err != nil {
+		return nil, err
+	}
+
+	openedWallet = vault
+	openedWalletBoxer = boxer
+	return vault, nil
+}
+
+func mustGetSignerWallet() *vault.Vault {
+	vault, err := getSignerWallet()
 	errorCheck("wallet setup", err)
+
 	return vault
 }
 
-func setupWallet() (*vault.Vault, error) {
+// getSignerWallet returns the vault the signers of a transaction are looked
+// up in. Under --dry-run and --dump-unsigned the transaction is not signed,
+// so the vault is not opened: its keys, and its watch-only addresses whose
+// keys may be held elsewhere, are read from the public key index as
+// placeholders, see `placeholderKey`. Vaults without an index are opened.
+func getSignerWallet() (*vault.Vault, error) {
+	if !isUnsignedTransactionMode() || isAgentMode() {
+		return getWallet()
+	}
+
+	walletFile := viper.GetString("global-vault-file")
+	index, err := vault.LoadVault(context.Background(), walletFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load vault file: %w", err)
+	}
+
+	if !index.HasPublicKeyIndex() {
+		return getWallet()
+	}
+
+	if err := index.VerifyPublicKeyIndex(); err != nil {
+		return nil, fmt.Errorf("vault file %q public key index: %w", walletFile, err)
+	}
+
+	v := vault.NewVault()
+	addPlaceholder := func(pub solana.PublicKey, label string) error {
+		v.AddPrivateKey(placeholderKey(pub))
+		if label == "" {
+			return nil
+		}
+		return v.SetLabel(pub, label)
+	}
+
+	for _, entry := range index.PublicKeys {
+		if err := addPlaceholder(entry.PublicKey, entry.Label); err != nil {
+			return nil, err
+		}
+	}
+	for _, address := range index.WatchOnly {
+		if err := addPlaceholder(address.PublicKey, address.Label); err != nil {
+			return nil, err
+		}
+	}
+
+	return v, nil
+}
+
+// writeOpenedWallet seals the opened vault with the boxer that opened it
+// and atomically writes it back, `backup` keeps a copy of the previous
+// vault file.
+func writeOpenedWallet(backup bool) error {
+	if isAgentMode() {
+		return fmt.Errorf("the vault can't be modified through the signing agent, run the command without --agent")
+	}
+
+	wallet, err := getWallet()
+	if err != nil {
+		return err
+	}
+
+	if err := wallet.Seal(openedWalletBoxer); err != nil {
+		return fmt.Errorf("failed to seal vault: %w", err)
+	}
+
+	walletFile := viper.GetString("global-vault-file")
+	if !backup {
+		if err := wallet.Save(context.Background(), walletFile); err != nil {
+			return fmt.Errorf("failed to write vault file: %w", err)
+		}
+		return nil
+	}
+
+	backupFile, err := wallet.Replace(context.Background(), walletFile)
+	if err != nil {
+		return fmt.Errorf("failed to replace vault file: %w", err)
+	}
+
+	printInfo("Previous vault file saved to %q.\n", backupFile)
+	return nil
+}
+
+// resolveAddress parses a base58 address, `@label` being the address of the
+// vault key, or watch-only address, with that label.
+func resolveAddress(in string) (solana.PublicKey, error) {
+	if !strings.HasPrefix(in, "@") {
+		return solana.PublicKeyFromBase58(in)
+	}
+
+	label := strings.TrimPrefix(in, "@")
+	if err := vault.ValidateLabel(label); err != nil {
+		return solana.PublicKey{}, err
+	}
+
+	wallet, err := getSignerWallet()
+	if err != nil {
+		return solana.PublicKey{}, fmt.Errorf("resolving %q: %w", in, err)
+	}
+
+	if key, found := wallet.FindByLabel(label); found {
+		return key.PublicKey(), nil
+	}
+
+	if address, found := wallet.FindWatchOnlyByLabel(label); found {
+		return address.PublicKey, nil
+	}
+
+	return solana.PublicKey{}, fmt.Errorf("no key labeled %q in vault", label)
+}
+
+func setupWallet() (*vault.Vault, vault.SecretBoxer, error) {
 	walletFile := viper.GetString("global-vault-file")
-	if _, err := os.Stat(walletFile); err != nil {
-		return nil, fmt.Errorf("wallet file %q missing: %w", walletFile, err)
+	exists, err := vault.VaultExists(context.Background(), walletFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("wallet file %q: %w", walletFile, err)
+	}
+	if !exists {
+		return nil, nil, fmt.Errorf("wallet file %q missing", walletFile)
 	}
 
-	v, err := vault.NewVaultFromWalletFile(walletFile)
+	v, err := vault.LoadVault(context.Background(), walletFile)
 	if err != nil {
-		return nil, fmt.Errorf("loading vault: %w", err)
+		return nil, nil, fmt.Errorf("loading vault: %w", err)
 	}
 
-	boxer, err := vault.SecretBoxerForType(v.SecretBoxWrap, viper.GetString("global-kms-gcp-keypath"))
+	boxer, err := secretBoxerForType(v.SecretBoxWrap)
 	if err != nil {
-		return nil, fmt.Errorf("secret boxer: %w", err)
+		return nil, nil, fmt.Errorf("secret boxer: %w", err)
 	}
 
 	if err := v.Open(boxer); err != nil {
-		return nil, fmt.Errorf("opening: %w", err)
+		return nil, nil, fmt.Errorf("opening: %w", err)
 	}
 
-	return v, nil
+	// The watch-only addresses are in plaintext, only the public key index
+	// vouches for them
+	if err := v.CheckPublicKeyIndex(); err != nil {
+		return nil, nil, fmt.Errorf("vault file %q does not verify: %w", walletFile, err)
+	}
+
+	return v, boxer, nil
 }
 
 var httpClient = &http.Client{
@@ -129,51 +348,82 @@ var httpClient = &http.Client{
 
 // fetchAndPrintJSONFromURL fetches a JSON document from `jsonDataURL` (handles
 // `ipfs` scheme via an IPFS gateway, Pinata Cloud by default). It then decodes the fetched
-// document and print it to standard output in a used friendly way.
-func fetchAndPrintJSONFromURL(label string, jsonDataURL string) {
-	lowercasedLabel := strings.ToLower(label)
+// document and print it to `w` in a used friendly way.
+func fetchAndPrintJSONFromURL(w io.Writer, label string, jsonDataURL string) {
+	document, err := fetchJSONFromURL(jsonDataURL)
+	if err != nil {
+		fmt.Fprintf(w, "%s: %s\n", label, err)
+		return
+	}
+
+	if document == nil {
+		fmt.Fprintf(w, "%s: <No Data Returned>\n", label)
+		return
+	}
+
+	fmt.Fprintln(w, label)
+	out, err := json.MarshalIndent(document, "", "  ")
+	cli.NoError(err, "unable to prettify json data")
+
+	fmt.Fprintln(w, string(out))
+}
 
-	logger := zlog.With(zap.String("label", lowercasedLabel))
+// fetchJSONFromURL fetches and decodes the JSON document at `jsonDataURL`,
+// a `nil` document is returned when the server has no content for it.
+func fetchJSONFromURL(jsonDataURL string) (interface{}, error) {
 	url, err := url.Parse(jsonDataURL)
 	if err != nil {
-		logger.Debug("invalid url", zap.String("label", "label"), zap.String("url", jsonDataURL), zap.Error(err))
-		return
+		return nil, fmt.Errorf("invalid URL %q: %w", jsonDataURL, err)
 	}
 
 	if url.Scheme == "ipfs" {
-		fmt.Printf("IPFS scheme is not supported for now (URI %q)\n", url)
-		return
+		return nil, fmt.Errorf("IPFS scheme is not supported for now (URI %q)", url)
 	}
 
 	resp, err := httpClient.Get(jsonDataURL)
 	if err != nil {
-		logger.Debug("unable to fetch url", zap.String("url", jsonDataURL), zap.Error(err))
-		return
+		return nil, fmt.Errorf("unable to fetch %q: %w", jsonDataURL, err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode == 204 {
-		fmt.Printf("%s: <No Data Returned>\n", label)
-		return
+		return nil, nil
 	}
 
-	if resp.StatusCode == 200 {
-		decoder := json.NewDecoder(resp.Body)
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("Failed %d", resp.StatusCode)
+	}
 
-		var metadata interface{}
-		err := decoder.Decode(&metadata)
-		if err != nil {
-			fmt.Printf("%s: Unable to decode response from %q (Error is %q)\n", label, jsonDataURL, err)
-			return
+	var document interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&document); err != nil {
+		return nil, fmt.Errorf("Unable to decode response from %q (Error is %q)", jsonDataURL, err)
+	}
+
+	return document, nil
+}
+
+// secretBoxerForType returns the boxer to open a vault sealed by
+// `wrapType`, configured from the global flags.
+func secretBoxerForType(wrapType string) (vault.SecretBoxer, error) {
+	if wrapType == "shamir" {
+		if files := viper.GetStringSlice("global-shamir-share-files"); len(files) > 0 {
+			return vault.NewShamirOpener(vault.ShamirSharesFromFiles(files)), nil
 		}
+	}
 
-		fmt.Println(label)
-		out, err := json.MarshalIndent(metadata, "", "  ")
-		cli.NoError(err, "unable to prettify json data")
+	return vault.SecretBoxerForType(wrapType, getKMSConfig())
+}
 
-		fmt.Println(string(out))
-		return
+// getKMSConfig returns the configuration of the KMS backed vault types, the
+// deprecated --kms-gcp-keypath is still honored when --kms-key is not set.
+func getKMSConfig() vault.KMSConfig {
+	key := viper.GetString("global-kms-key")
+	if key == "" {
+		key = viper.GetString("global-kms-gcp-keypath")
 	}
 
-	fmt.Printf("%s: Failed %d\n", label, resp.StatusCode)
+	return vault.KMSConfig{
+		Key:      key,
+		Endpoint: viper.GetString("global-kms-endpoint"),
+	}
 }
@@ -0,0 +1,88 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/streamingfast/slnc/vault"
+	"github.com/streamingfast/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestVaultFile writes a vault holding a key labeled "payroll" and
+// watching an address labeled "cold-storage", and points the commands at it.
+func newTestVaultFile(t *testing.T) (key, watched solana.PublicKey) {
+	_, priv, err := solana.NewRandomPrivateKey()
+	require.NoError(t, err)
+	watched, _, err = solana.NewRandomPrivateKey()
+	require.NoError(t, err)
+
+	v := vault.NewVault()
+	key = v.AddPrivateKey(priv)
+	require.NoError(t, v.SetLabel(key, "payroll"))
+	_, err = v.AddWatchOnly(watched, "cold-storage")
+	require.NoError(t, err)
+	require.NoError(t, v.Seal(vault.NewPassphraseBoxer("secret")))
+
+	walletFile := filepath.Join(t.TempDir(), "slnc-vault.json")
+	require.NoError(t, v.Save(context.Background(), walletFile))
+
+	viper.Set("global-vault-file", walletFile)
+	t.Cleanup(func() { viper.Set("global-vault-file", "") })
+
+	return key, watched
+}
+
+func TestGetSignerWallet_DryRun(t *testing.T) {
+	pub, _ := newTestVaultFile(t)
+	viper.Set("global-dry-run", true)
+	t.Cleanup(func() { viper.Set("global-dry-run", false) })
+
+	// The vault is not opened, which would prompt for its passphrase
+	wallet, err := getSignerWallet()
+	require.NoError(t, err)
+	require.Len(t, wallet.KeyBag, 2)
+	assert.Equal(t, pub, wallet.KeyBag[0].PublicKey())
+	assert.True(t, isPlaceholderKey(wallet.KeyBag[0]))
+	assert.Nil(t, openedWallet)
+
+	resolved, err := resolveAddress("@payroll")
+	require.NoError(t, err)
+	assert.Equal(t, pub, resolved)
+}
+
+func TestGetSignerWallet_DumpUnsigned(t *testing.T) {
+	_, watched := newTestVaultFile(t)
+	viper.Set("global-dump-unsigned", true)
+	t.Cleanup(func() { viper.Set("global-dump-unsigned", false) })
+
+	// The key of a watch-only address is held elsewhere, it signs the
+	// envelope later on
+	wallet, err := getSignerWallet()
+	require.NoError(t, err)
+	signer, found := wallet.PrivateKey(watched)
+	require.True(t, found)
+	assert.True(t, isPlaceholderKey(signer))
+	assert.Nil(t, openedWallet)
+
+	resolved, err := resolveAddress("@cold-storage")
+	require.NoError(t, err)
+	assert.Equal(t, watched, resolved)
+}
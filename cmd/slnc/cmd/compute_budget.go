@@ -0,0 +1,205 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/spf13/viper"
+	"github.com/streamingfast/solana-go"
+	"github.com/streamingfast/solana-go/rpc"
+	"go.uber.org/zap"
+)
+
+var computeBudgetProgramID = solana.MustPublicKeyFromBase58("ComputeBudget111111111111111111111111111111")
+
+// maxComputeUnitLimit is the maximum amount of compute units a single
+// transaction can request
+const maxComputeUnitLimit = 1_400_000
+
+// computeBudgetInstruction is an instruction of the Compute Budget program,
+// those instructions do not take any account.
+type computeBudgetInstruction struct {
+	data []byte
+}
+
+func (i *computeBudgetInstruction) Accounts() []*solana.AccountMeta { return nil }
+func (i *computeBudgetInstruction) ProgramID() solana.PublicKey     { return computeBudgetProgramID }
+func (i *computeBudgetInstruction) Data() ([]byte, error)           { return i.data, nil }
+
+func newSetComputeUnitLimitInstruction(units uint32) *computeBudgetInstruction {
+	data := make([]byte, 5)
+	data[0] = 2
+	binary.LittleEndian.PutUint32(data[1:], units)
+
+	return &computeBudgetInstruction{data: data}
+}
+
+// newSetComputeUnitPriceInstruction sets the priority fee of the transaction,
+// expressed in micro-lamports per compute unit.
+func newSetComputeUnitPriceInstruction(microLamports uint64) *computeBudgetInstruction {
+	data := make([]byte, 9)
+	data[0] = 3
+	binary.LittleEndian.PutUint64(data[1:], microLamports)
+
+	return &computeBudgetInstruction{data: data}
+}
+
+// computeBudgetInstructions returns the Compute Budget instructions to add
+// in front of `instructions` according to `--compute-unit-limit`,
+// `--priority-fee` and `--auto-priority-fee`.
+func computeBudgetInstructions(rpcClient *rpc.Client, instructions []solana.Instruction) ([]solana.Instruction, error) {
+	var out []solana.Instruction
+
+	if limit := viper.GetUint64("global-compute-unit-limit"); limit != 0 {
+		if limit > maxComputeUnitLimit {
+			return nil, fmt.Errorf("compute unit limit %d is over the maximum of %d", limit, maxComputeUnitLimit)
+		}
+		out = append(out, newSetComputeUnitLimitInstruction(uint32(limit)))
+	}
+
+	priorityFee := viper.GetUint64("global-priority-fee")
+	if viper.GetBool("global-auto-priority-fee") {
+		if priorityFee != 0 {
+			return nil, fmt.Errorf("flags --priority-fee and --auto-priority-fee are mutually exclusive")
+		}
+
+		fee, err := estimatePriorityFee(rpcClient, instructions)
+		if err != nil {
+			return nil, err
+		}
+		priorityFee = fee
+	}
+
+	if priorityFee != 0 {
+		out = append(out, newSetComputeUnitPriceInstruction(priorityFee))
+	}
+
+	return out, nil
+}
+
+type prioritizationFee struct {
+	Slot              uint64 `json:"slot"`
+	PrioritizationFee uint64 `json:"prioritizationFee"`
+}
+
+// estimatePriorityFee derives a priority fee from the fees paid recently by
+// transactions that wrote to the same accounts as `instructions`.
+func estimatePriorityFee(rpcClient *rpc.Client, instructions []solana.Instruction) (uint64, error) {
+	seen := map[solana.PublicKey]bool{}
+	var writables []string
+	for _, instruction := range instructions {
+		for _, account := range instruction.Accounts() {
+			if account.IsWritable && !seen[account.PublicKey] {
+				seen[account.PublicKey] = true
+				writables = append(writables, account.PublicKey.String())
+			}
+		}
+	}
+
+	// The RPC node accepts at most 128 accounts
+	if len(writables) > 128 {
+		writables = writables[:128]
+	}
+
+	var fees []prioritizationFee
+	if err := rpcClient.DoRequest(&fees, "getRecentPrioritizationFees", writables); err != nil {
+		return 0, fmt.Errorf("unable to retrieve recent prioritization fees: %w", err)
+	}
+
+	if len(fees) == 0 {
+		return 0, nil
+	}
+
+	values := make([]uint64, len(fees))
+	for i, fee := range fees {
+		values[i] = fee.PrioritizationFee
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+
+	percentile := viper.GetUint64("global-auto-priority-fee-percentile")
+	if percentile > 100 {
+		return 0, fmt.Errorf("auto priority fee percentile %d must be between 0 and 100", percentile)
+	}
+
+	fee := values[(len(values)-1)*int(percentile)/100]
+	if max := viper.GetUint64("global-max-priority-fee"); max != 0 && fee > max {
+		fee = max
+	}
+
+	zlog.Debug("estimated priority fee",
+		zap.Int("writable_accounts", len(writables)),
+		zap.Int("slot_count", len(values)),
+		zap.Uint64("percentile", percentile),
+		zap.Uint64("micro_lamports", fee),
+	)
+	return fee, nil
+}
+
+// defaultInstructionComputeUnitLimit is the compute units granted to each
+// instruction of a transaction not setting its compute unit limit.
+const defaultInstructionComputeUnitLimit = 200_000
+
+// estimateTransactionFee returns the fee `trx` is charged, in lamports: the
+// fee of each of its signatures plus its priority fee, the compute unit
+// price times the compute unit limit it requests.
+func estimateTransactionFee(trx *solana.Transaction) (uint64, error) {
+	var computeUnitLimit, computeUnitPrice uint64
+	var hasComputeUnitLimit bool
+	var instructionCount uint64
+
+	for _, instruction := range trx.Message.Instructions {
+		programID, err := trx.Message.ResolveProgramIDIndex(instruction.ProgramIDIndex)
+		if err != nil {
+			return 0, fmt.Errorf("unable to resolve program of instruction: %w", err)
+		}
+
+		if programID != computeBudgetProgramID {
+			instructionCount++
+			continue
+		}
+
+		data := []byte(instruction.Data)
+		switch {
+		case len(data) == 5 && data[0] == 2:
+			computeUnitLimit = uint64(binary.LittleEndian.Uint32(data[1:]))
+			hasComputeUnitLimit = true
+		case len(data) == 9 && data[0] == 3:
+			computeUnitPrice = binary.LittleEndian.Uint64(data[1:])
+		}
+	}
+
+	if !hasComputeUnitLimit {
+		computeUnitLimit = instructionCount * defaultInstructionComputeUnitLimit
+		if computeUnitLimit > maxComputeUnitLimit {
+			computeUnitLimit = maxComputeUnitLimit
+		}
+	}
+
+	// The price is in micro-lamports per compute unit, rounded up
+	priorityFee := new(big.Int).Mul(new(big.Int).SetUint64(computeUnitPrice), new(big.Int).SetUint64(computeUnitLimit))
+	priorityFee.Add(priorityFee, big.NewInt(999_999))
+	priorityFee.Div(priorityFee, big.NewInt(1_000_000))
+
+	fee := priorityFee.Add(priorityFee, new(big.Int).SetUint64(uint64(trx.Message.Header.NumRequiredSignatures)*lamportsPerSignature))
+	if !fee.IsUint64() {
+		return 0, fmt.Errorf("transaction fee overflows, check --priority-fee")
+	}
+
+	return fee.Uint64(), nil
+}
@@ -0,0 +1,82 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/streamingfast/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEstimateTransactionFee(t *testing.T) {
+	from, _, err := solana.NewRandomPrivateKey()
+	require.NoError(t, err)
+	to, _, err := solana.NewRandomPrivateKey()
+	require.NoError(t, err)
+	nonceAccount, _, err := solana.NewRandomPrivateKey()
+	require.NoError(t, err)
+	nonceAuthority, _, err := solana.NewRandomPrivateKey()
+	require.NoError(t, err)
+
+	transfer := newSystemTransferInstruction(lamportsPerSOL, from, to)
+
+	tests := []struct {
+		name         string
+		instructions []solana.Instruction
+		expected     uint64
+	}{
+		{
+			name:         "single signature",
+			instructions: []solana.Instruction{transfer},
+			expected:     lamportsPerSignature,
+		},
+		{
+			name:         "nonce authority signature",
+			instructions: []solana.Instruction{newAdvanceNonceAccountInstruction(nonceAccount, nonceAuthority), transfer},
+			expected:     2 * lamportsPerSignature,
+		},
+		{
+			name:         "priority fee with default compute unit limit",
+			instructions: []solana.Instruction{newSetComputeUnitPriceInstruction(1_000), transfer},
+			expected:     lamportsPerSignature + 200,
+		},
+		{
+			name:         "priority fee rounded up",
+			instructions: []solana.Instruction{newSetComputeUnitLimitInstruction(450), newSetComputeUnitPriceInstruction(1_000), transfer},
+			expected:     lamportsPerSignature + 1,
+		},
+		{
+			name: "priority fee capped compute unit limit",
+			instructions: []solana.Instruction{
+				newSetComputeUnitPriceInstruction(1_000_000),
+				transfer, transfer, transfer, transfer, transfer, transfer, transfer, transfer,
+			},
+			expected: lamportsPerSignature + maxComputeUnitLimit,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			trx, err := solana.NewTransaction(test.instructions, solana.PublicKey{1}, solana.TransactionPayer(from))
+			require.NoError(t, err)
+
+			fee, err := estimateTransactionFee(trx)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, fee)
+		})
+	}
+}
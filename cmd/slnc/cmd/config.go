@@ -0,0 +1,220 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v2"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manage the slnc configuration file",
+	Long: `Manage the slnc configuration file.
+
+The configuration file (see --config-file) holds named profiles. A profile
+sets the RPC and websocket endpoints, HTTP headers, vault file, default
+vault key, program IDs and commitment level to use, for example one profile
+per cluster.
+
+The profile used is the one passed with --profile, or the one selected
+with 'slnc config profile use'. Flags and environment variables explicitly
+set always take precedence over the profile values.
+`,
+}
+
+func init() {
+	RootCmd.AddCommand(configCmd)
+}
+
+func isConfigCommand(cmd *cobra.Command) bool {
+	for ; cmd != nil; cmd = cmd.Parent() {
+		if cmd == configCmd {
+			return true
+		}
+	}
+	return false
+}
+
+type slncConfig struct {
+	CurrentProfile string                    `yaml:"current_profile,omitempty" json:"current_profile,omitempty"`
+	Profiles       map[string]*configProfile `yaml:"profiles,omitempty" json:"profiles,omitempty"`
+}
+
+type configProfile struct {
+	RPCURLs         []string         `yaml:"rpc_urls,omitempty" json:"rpc_urls,omitempty"`
+	WSURLs          []string         `yaml:"ws_urls,omitempty" json:"ws_urls,omitempty"`
+	HTTPHeaders     []string         `yaml:"http_headers,omitempty" json:"http_headers,omitempty"`
+	VaultFile       string           `yaml:"vault_file,omitempty" json:"vault_file,omitempty"`
+	DefaultVaultKey string           `yaml:"default_vault_key,omitempty" json:"default_vault_key,omitempty"`
+	ProgramIDs      configProgramIDs `yaml:"program_ids,omitempty" json:"program_ids,omitempty"`
+	Commitment      string           `yaml:"commitment,omitempty" json:"commitment,omitempty"`
+}
+
+type configProgramIDs struct {
+	MetaplexMeta string `yaml:"metaplex_meta,omitempty" json:"metaplex_meta,omitempty"`
+	CandyMachine string `yaml:"candy_machine,omitempty" json:"candy_machine,omitempty"`
+}
+
+// viperValues maps the profile values to the viper keys of the flags they
+// configure.
+func (p *configProfile) viperValues() map[string]interface{} {
+	out := map[string]interface{}{}
+	if len(p.RPCURLs) > 0 {
+		out["global-rpc-url"] = strings.Join(p.RPCURLs, ",")
+	}
+	if len(p.WSURLs) > 0 {
+		out["global-ws-url"] = strings.Join(p.WSURLs, ",")
+	}
+	if len(p.HTTPHeaders) > 0 {
+		out["global-http-header"] = p.HTTPHeaders
+	}
+	if p.VaultFile != "" {
+		out["global-vault-file"] = p.VaultFile
+	}
+	if p.DefaultVaultKey != "" {
+		out["global-default-vault-key"] = p.DefaultVaultKey
+	}
+	if p.ProgramIDs.MetaplexMeta != "" {
+		out["metaplex-global-meta-program-id"] = p.ProgramIDs.MetaplexMeta
+	}
+	if p.ProgramIDs.CandyMachine != "" {
+		out["metaplex-global-candy-machine-program-id"] = p.ProgramIDs.CandyMachine
+	}
+	if p.Commitment != "" {
+		out["global-commitment"] = p.Commitment
+	}
+	return out
+}
+
+func defaultConfigFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return filepath.Join(".config", "slnc", "config.yaml")
+	}
+	return filepath.Join(dir, "slnc", "config.yaml")
+}
+
+func getConfigFile() string {
+	if file := viper.GetString("global-config-file"); file != "" {
+		return file
+	}
+	return defaultConfigFile()
+}
+
+// readConfig reads the configuration file, a missing file being an empty
+// configuration.
+func readConfig() (*slncConfig, error) {
+	filename := getConfigFile()
+	cnt, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return &slncConfig{Profiles: map[string]*configProfile{}}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read config file %q: %w", filename, err)
+	}
+
+	config := &slncConfig{}
+	if err := yaml.Unmarshal(cnt, config); err != nil {
+		return nil, fmt.Errorf("unable to decode config file %q: %w", filename, err)
+	}
+
+	if config.Profiles == nil {
+		config.Profiles = map[string]*configProfile{}
+	}
+	return config, nil
+}
+
+func writeConfig(config *slncConfig) error {
+	filename := getConfigFile()
+	cnt, err := yaml.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("unable to encode config: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filename), 0700); err != nil {
+		return fmt.Errorf("unable to create config directory: %w", err)
+	}
+
+	// Written to a temporary file first so a partially written config never
+	// replaces a good one.
+	tmpFile, err := ioutil.TempFile(filepath.Dir(filename), ".slnc-config-*")
+	if err != nil {
+		return fmt.Errorf("unable to create temporary file: %w", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.Write(cnt); err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("unable to write config: %w", err)
+	}
+
+	if err := tmpFile.Close(); err != nil {
+		return fmt.Errorf("unable to write config: %w", err)
+	}
+
+	if err := os.Rename(tmpFile.Name(), filename); err != nil {
+		return fmt.Errorf("unable to write config file %q: %w", filename, err)
+	}
+	return nil
+}
+
+// activeProfileName is the profile selected by `--profile`, or else the
+// current profile of the configuration file.
+func activeProfileName(config *slncConfig) string {
+	if name := viper.GetString("global-profile"); name != "" {
+		return name
+	}
+	return config.CurrentProfile
+}
+
+// applyProfile loads the active profile values in viper, at the config
+// level so that flags and environment variables explicitly set win.
+func applyProfile() error {
+	config, err := readConfig()
+	if err != nil {
+		return err
+	}
+
+	name := activeProfileName(config)
+	if name == "" {
+		return nil
+	}
+
+	profile, found := config.Profiles[name]
+	if !found {
+		return fmt.Errorf("profile %q not found in config file %q, available profiles are: %s", name, getConfigFile(), strings.Join(config.profileNames(), ", "))
+	}
+
+	zlog.Debug("applying profile", zap.String("profile", name))
+	return viper.MergeConfigMap(profile.viperValues())
+}
+
+func (c *slncConfig) profileNames() (out []string) {
+	for name := range c.Profiles {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return
+}
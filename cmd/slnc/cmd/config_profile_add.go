@@ -0,0 +1,94 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var configProfileAddCmd = &cobra.Command{
+	Use:   "add {name}",
+	Short: "Add a profile to the configuration file",
+	Long: `Add a profile to the configuration file.
+
+The profile records the global flags explicitly passed to this command,
+for example:
+
+    slnc config profile add devnet -u devnet --ws-url devnet --vault-file ./devnet-vault.json --commitment confirmed
+`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+
+		config, err := readConfig()
+		if err != nil {
+			return err
+		}
+
+		if _, found := config.Profiles[name]; found && !viper.GetBool("config-profile-add-cmd-overwrite") {
+			return fmt.Errorf("profile %q already exists, use --overwrite to replace it", name)
+		}
+
+		flags := cmd.Flags()
+		profile := &configProfile{}
+		if flags.Changed("rpc-url") {
+			profile.RPCURLs = splitEndpoints(viper.GetString("global-rpc-url"))
+		}
+		if flags.Changed("ws-url") {
+			profile.WSURLs = splitWSEndpoints(viper.GetString("global-ws-url"))
+		}
+		if flags.Changed("http-header") {
+			profile.HTTPHeaders = viper.GetStringSlice("global-http-header")
+		}
+		if flags.Changed("vault-file") {
+			profile.VaultFile = viper.GetString("global-vault-file")
+		}
+		if flags.Changed("default-vault-key") {
+			profile.DefaultVaultKey = viper.GetString("global-default-vault-key")
+		}
+		if flags.Changed("commitment") {
+			profile.Commitment = viper.GetString("global-commitment")
+		}
+		profile.ProgramIDs.MetaplexMeta = viper.GetString("config-profile-add-cmd-metaplex-meta-program-id")
+		profile.ProgramIDs.CandyMachine = viper.GetString("config-profile-add-cmd-candy-machine-program-id")
+
+		config.Profiles[name] = profile
+		if config.CurrentProfile == "" || viper.GetBool("config-profile-add-cmd-use") {
+			config.CurrentProfile = name
+		}
+
+		if err := writeConfig(config); err != nil {
+			return err
+		}
+
+		printInfo("Profile %q written to %q\n", name, getConfigFile())
+		if config.CurrentProfile == name {
+			printInfo("Profile %q is now the current profile\n", name)
+		}
+		return nil
+	},
+}
+
+func init() {
+	configProfileCmd.AddCommand(configProfileAddCmd)
+
+	configProfileAddCmd.Flags().Bool("overwrite", false, "Replace the profile if it already exists")
+	configProfileAddCmd.Flags().Bool("use", false, "Make the profile the current one (the first profile added always is)")
+	configProfileAddCmd.Flags().String("metaplex-meta-program-id", "", "Metaplex program id of the profile")
+	configProfileAddCmd.Flags().String("candy-machine-program-id", "", "Metaplex Candy Machine program id of the profile")
+}
@@ -0,0 +1,69 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var configProfileListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the profiles of the configuration file",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config, err := readConfig()
+		if err != nil {
+			return err
+		}
+
+		active := activeProfileName(config)
+		out := profilesOutput{}
+		for _, name := range config.profileNames() {
+			out = append(out, &profileSummaryOutput{
+				Name:    name,
+				Active:  name == active,
+				RPCURLs: config.Profiles[name].RPCURLs,
+			})
+		}
+
+		return printOutput(out)
+	},
+}
+
+func init() {
+	configProfileCmd.AddCommand(configProfileListCmd)
+}
+
+type profileSummaryOutput struct {
+	Name    string   `json:"name"`
+	Active  bool     `json:"active"`
+	RPCURLs []string `json:"rpc_urls"`
+}
+
+type profilesOutput []*profileSummaryOutput
+
+func (o profilesOutput) Columns() []string { return []string{"Name", "Active", "RPC URLs"} }
+func (o profilesOutput) Rows() (out [][]string) {
+	for _, profile := range o {
+		active := ""
+		if profile.Active {
+			active = "*"
+		}
+		out = append(out, []string{profile.Name, active, strings.Join(profile.RPCURLs, ",")})
+	}
+	return
+}
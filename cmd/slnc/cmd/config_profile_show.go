@@ -0,0 +1,70 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+var configProfileShowCmd = &cobra.Command{
+	Use:   "show [name]",
+	Short: "Show a profile, the active one by default",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config, err := readConfig()
+		if err != nil {
+			return err
+		}
+
+		name := activeProfileName(config)
+		if len(args) == 1 {
+			name = args[0]
+		}
+
+		if name == "" {
+			return fmt.Errorf("no active profile, pass a profile name or select one with 'slnc config profile use'")
+		}
+
+		profile, found := config.Profiles[name]
+		if !found {
+			return fmt.Errorf("profile %q not found in config file %q", name, getConfigFile())
+		}
+
+		return printOutput(&profileOutput{Name: name, Profile: profile})
+	},
+}
+
+func init() {
+	configProfileCmd.AddCommand(configProfileShowCmd)
+}
+
+type profileOutput struct {
+	Name    string         `json:"name"`
+	Profile *configProfile `json:"profile"`
+}
+
+func (o *profileOutput) Text(w io.Writer) error {
+	cnt, err := yaml.Marshal(map[string]*configProfile{o.Name: o.Profile})
+	if err != nil {
+		return fmt.Errorf("unable to encode profile: %w", err)
+	}
+
+	_, err = w.Write(cnt)
+	return err
+}
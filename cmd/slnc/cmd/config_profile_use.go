@@ -0,0 +1,52 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var configProfileUseCmd = &cobra.Command{
+	Use:   "use {name}",
+	Short: "Make a profile the current one",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+
+		config, err := readConfig()
+		if err != nil {
+			return err
+		}
+
+		if _, found := config.Profiles[name]; !found {
+			return fmt.Errorf("profile %q not found, available profiles are: %s", name, strings.Join(config.profileNames(), ", "))
+		}
+
+		config.CurrentProfile = name
+		if err := writeConfig(config); err != nil {
+			return err
+		}
+
+		printInfo("Profile %q is now the current profile\n", name)
+		return nil
+	},
+}
+
+func init() {
+	configProfileCmd.AddCommand(configProfileUseCmd)
+}
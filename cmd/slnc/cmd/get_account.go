@@ -17,9 +17,11 @@ package cmd
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 
 	"github.com/spf13/cobra"
 	"github.com/streamingfast/solana-go"
+	"github.com/streamingfast/solana-go/rpc"
 )
 
 var getAccountCmd = &cobra.Command{
@@ -28,36 +30,74 @@ var getAccountCmd = &cobra.Command{
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		client := getClient()
-		resp, err := client.GetAccountInfo(solana.MustPublicKeyFromBase58(args[0]))
-		if err != nil {
-			return err
-		}
 
-		acct := resp.Value
-		var data []byte
-		if data, err = json.MarshalIndent(acct, "", "  "); err != nil {
-			return fmt.Errorf("unable to marshall account information: %w", err)
+		address, err := resolveAddress(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid account address %q: %w", args[0], err)
 		}
 
-		fmt.Println(string(data))
-
-		obj, err := decode(acct.Owner, acct.Data)
+		resp, err := client.GetAccountInfo(address)
 		if err != nil {
 			return err
 		}
 
-		if obj != nil {
-			cnt, err := json.MarshalIndent(obj, "", "  ")
-			if err != nil {
-				return err
-			}
-			fmt.Printf("Data %T: %s\n", obj, string(cnt))
+		out, err := newAccountOutput(address, resp.Value)
+		if err != nil {
+			return err
 		}
 
-		return nil
+		return printOutput(out)
 	},
 }
 
 func init() {
 	getCmd.AddCommand(getAccountCmd)
 }
+
+type accountOutput struct {
+	Address    string      `json:"address"`
+	Lamports   uint64      `json:"lamports"`
+	Owner      string      `json:"owner"`
+	Executable bool        `json:"executable"`
+	RentEpoch  uint64      `json:"rent_epoch"`
+	Data       []byte      `json:"data"`
+	Decoded    interface{} `json:"decoded,omitempty"`
+
+	account *rpc.Account
+}
+
+func newAccountOutput(address solana.PublicKey, acct *rpc.Account) (*accountOutput, error) {
+	obj, err := decode(acct.Owner, acct.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &accountOutput{
+		Address:    address.String(),
+		Lamports:   uint64(acct.Lamports),
+		Owner:      acct.Owner.String(),
+		Executable: acct.Executable,
+		RentEpoch:  uint64(acct.RentEpoch),
+		Data:       acct.Data,
+		Decoded:    obj,
+		account:    acct,
+	}, nil
+}
+
+func (o *accountOutput) Text(w io.Writer) error {
+	data, err := json.MarshalIndent(o.account, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to marshall account information: %w", err)
+	}
+	fmt.Fprintln(w, string(data))
+
+	if o.Decoded != nil {
+		cnt, err := json.MarshalIndent(o.Decoded, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "Data %T: %s\n", o.Decoded, string(cnt))
+	}
+
+	return nil
+}
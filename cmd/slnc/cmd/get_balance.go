@@ -16,7 +16,7 @@ package cmd
 
 import (
 	"fmt"
-	"github.com/streamingfast/solana-go"
+	"io"
 
 	"github.com/spf13/cobra"
 )
@@ -28,7 +28,7 @@ var getBalanceCmd = &cobra.Command{
 	RunE: func(cmd *cobra.Command, args []string) error {
 		client := getClient()
 
-		key, err := solana.PublicKeyFromBase58(args[0])
+		key, err := resolveAddress(args[0])
 		if err != nil {
 			return err
 		}
@@ -41,12 +41,25 @@ var getBalanceCmd = &cobra.Command{
 			return fmt.Errorf("account not found")
 		}
 
-		fmt.Println(resp.Value, "lamports")
-
-		return nil
+		return printOutput(&balanceOutput{Address: key.String(), Lamports: uint64(resp.Value)})
 	},
 }
 
 func init() {
 	getCmd.AddCommand(getBalanceCmd)
 }
+
+type balanceOutput struct {
+	Address  string `json:"address"`
+	Lamports uint64 `json:"lamports"`
+}
+
+func (o *balanceOutput) Columns() []string { return []string{"Address", "Lamports"} }
+func (o *balanceOutput) Rows() [][]string {
+	return [][]string{{o.Address, fmt.Sprintf("%d", o.Lamports)}}
+}
+
+func (o *balanceOutput) Text(w io.Writer) error {
+	_, err := fmt.Fprintln(w, o.Lamports, "lamports")
+	return err
+}
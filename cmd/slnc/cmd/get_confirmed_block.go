@@ -15,7 +15,6 @@
 package cmd
 
 import (
-	"encoding/json"
 	"fmt"
 	"strconv"
 
@@ -39,10 +38,7 @@ var getConfirmedBlockCmd = &cobra.Command{
 			return err
 		}
 
-		cnt, _ := json.MarshalIndent(resp, "", "  ")
-		fmt.Println(string(cnt))
-
-		return nil
+		return printOutput(resp)
 	},
 }
 
@@ -15,13 +15,10 @@
 package cmd
 
 import (
-	"encoding/json"
 	"fmt"
-	"os"
+	"io"
 
 	"github.com/spf13/cobra"
-	"github.com/streamingfast/solana-go"
-	"github.com/streamingfast/solana-go/text"
 )
 
 var getProgramAccountsCmd = &cobra.Command{
@@ -31,7 +28,12 @@ var getProgramAccountsCmd = &cobra.Command{
 	RunE: func(cmd *cobra.Command, args []string) error {
 		client := getClient()
 
-		resp, err := client.GetProgramAccounts(solana.MustPublicKeyFromBase58(args[0]), nil)
+		programID, err := resolveAddress(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid program address %q: %w", args[0], err)
+		}
+
+		resp, err := client.GetProgramAccounts(programID, nil)
 		if err != nil {
 			return err
 		}
@@ -40,32 +42,43 @@ var getProgramAccountsCmd = &cobra.Command{
 			return fmt.Errorf("program account not found")
 		}
 
+		var out accountsOutput
 		for _, keyedAcct := range resp {
-			acct := keyedAcct.Account
-
-			obj, err := decode(acct.Owner, acct.Data)
+			acct, err := newAccountOutput(keyedAcct.Pubkey, keyedAcct.Account)
 			if err != nil {
 				return err
 			}
-
-			if obj != nil {
-				cnt, err := json.MarshalIndent(obj, "", "  ")
-				if err != nil {
-					return err
-				}
-				fmt.Printf("Data %T: %s\n", obj, string(cnt))
-				return nil
-			}
-
-			if err := text.NewEncoder(os.Stdout).Encode(acct, nil); err != nil {
-				return fmt.Errorf("unable to text encode account: %w", err)
-			}
+			out = append(out, acct)
 		}
 
-		return nil
+		return printOutput(out)
 	},
 }
 
 func init() {
 	getCmd.AddCommand(getProgramAccountsCmd)
 }
+
+type accountsOutput []*accountOutput
+
+func (o accountsOutput) Columns() []string {
+	return []string{"Address", "Owner", "Lamports", "Executable", "Data Length"}
+}
+
+func (o accountsOutput) Rows() (out [][]string) {
+	for _, a := range o {
+		out = append(out, []string{a.Address, a.Owner, fmt.Sprintf("%d", a.Lamports), fmt.Sprintf("%t", a.Executable), fmt.Sprintf("%d", len(a.Data))})
+	}
+	return
+}
+
+func (o accountsOutput) Text(w io.Writer) error {
+	for _, a := range o {
+		fmt.Fprintf(w, "Address: %s\n", a.Address)
+		if err := a.Text(w); err != nil {
+			return err
+		}
+		fmt.Fprintln(w, "")
+	}
+	return nil
+}
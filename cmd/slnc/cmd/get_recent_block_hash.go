@@ -15,7 +15,6 @@
 package cmd
 
 import (
-	"encoding/json"
 	"fmt"
 
 	"github.com/spf13/cobra"
@@ -32,13 +31,23 @@ var GetLatestBlockhashCmd = &cobra.Command{
 			return err
 		}
 
-		cnt, _ := json.MarshalIndent(resp.Value, "", "  ")
-		fmt.Println(string(cnt))
-
-		return nil
+		return printOutput(&blockhashOutput{
+			Blockhash:            resp.Value.Blockhash.String(),
+			LastValidBlockHeight: uint64(resp.Value.LastValidBlockHeight),
+		})
 	},
 }
 
 func init() {
 	getCmd.AddCommand(GetLatestBlockhashCmd)
 }
+
+type blockhashOutput struct {
+	Blockhash            string `json:"blockhash"`
+	LastValidBlockHeight uint64 `json:"last_valid_block_height"`
+}
+
+func (o *blockhashOutput) Columns() []string { return []string{"Blockhash", "Last Valid Block Height"} }
+func (o *blockhashOutput) Rows() [][]string {
+	return [][]string{{o.Blockhash, fmt.Sprintf("%d", o.LastValidBlockHeight)}}
+}
@@ -16,6 +16,7 @@ package cmd
 
 import (
 	"fmt"
+	"io"
 
 	"github.com/spf13/cobra"
 )
@@ -32,12 +33,22 @@ var getSlotCmd = &cobra.Command{
 			return err
 		}
 
-		fmt.Println(resp)
-
-		return nil
+		return printOutput(&slotOutput{Slot: uint64(resp)})
 	},
 }
 
 func init() {
 	getCmd.AddCommand(getSlotCmd)
 }
+
+type slotOutput struct {
+	Slot uint64 `json:"slot"`
+}
+
+func (o *slotOutput) Columns() []string { return []string{"Slot"} }
+func (o *slotOutput) Rows() [][]string  { return [][]string{{fmt.Sprintf("%d", o.Slot)}} }
+
+func (o *slotOutput) Text(w io.Writer) error {
+	_, err := fmt.Fprintln(w, o.Slot)
+	return err
+}
@@ -15,16 +15,7 @@
 package cmd
 
 import (
-	"fmt"
-	"log"
-	"os"
-
 	"github.com/spf13/cobra"
-	bin "github.com/streamingfast/binary"
-	"github.com/streamingfast/solana-go"
-	"github.com/streamingfast/solana-go/programs/token"
-	"github.com/streamingfast/solana-go/rpc"
-	"github.com/streamingfast/solana-go/text"
 )
 
 var getSPLTokenCmd = &cobra.Command{
@@ -34,50 +25,12 @@ var getSPLTokenCmd = &cobra.Command{
 	RunE: func(cmd *cobra.Command, args []string) error {
 		client := getClient()
 
-		resp, err := client.GetProgramAccounts(
-			solana.MustPublicKeyFromBase58("TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA"),
-			&rpc.GetProgramAccountsOpts{
-				Filters: []rpc.RPCFilter{
-					{
-						DataSize: 82,
-					},
-				},
-			},
-		)
+		mints, err := fetchMints(client)
 		if err != nil {
 			return err
 		}
 
-		if resp == nil {
-			return fmt.Errorf("program account not found")
-		}
-
-		for _, keyedAcct := range resp {
-			acct := keyedAcct.Account
-			//fmt.Println("Data len:", len(acct.Data), keyedAcct.Pubkey)
-			var mint *token.Mint
-			if err := bin.NewDecoder(acct.Data).Decode(&mint); err != nil {
-				log.Fatalln("failed unpack", err)
-			}
-
-			text.EncoderColorCyan.Print("Address: ")
-			fmt.Println(keyedAcct.Pubkey.String())
-
-			text.EncoderColorCyan.Print("OpenOrders: ")
-			fmt.Println(keyedAcct.Account.Owner.String())
-
-			text.EncoderColorCyan.Print("Lamports: ")
-			fmt.Println(keyedAcct.Account.Lamports)
-
-			if err := text.NewEncoder(os.Stdout).Encode(mint, nil); err != nil {
-				log.Fatalln("failed string encode", err)
-			}
-			fmt.Println("-------------------------------")
-			fmt.Println("")
-		}
-		fmt.Println("\nTotal result:", len(resp))
-
-		return nil
+		return printOutput(mints)
 	},
 }
 
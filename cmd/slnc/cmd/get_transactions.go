@@ -17,93 +17,443 @@ package cmd
 import (
 	"encoding/json"
 	"fmt"
+	"io"
+
+	"github.com/ryanuber/columnize"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 	"github.com/streamingfast/solana-go"
-	_ "github.com/streamingfast/solana-go/programs/serum"
-	_ "github.com/streamingfast/solana-go/programs/system"
-	_ "github.com/streamingfast/solana-go/programs/token"
-	_ "github.com/streamingfast/solana-go/programs/tokenregistry"
 	"github.com/streamingfast/solana-go/rpc"
 	"github.com/streamingfast/solana-go/text"
 )
 
 var getTransactionsCmd = &cobra.Command{
 	Use:   "transactions {account}",
-	Short: "Retrieve transaction for a specific account",
-	Args:  cobra.ExactArgs(1),
+	Short: "Retrieve transactions for a specific account",
+	Long: `Retrieve transactions for a specific account.
+
+Transactions are listed from the most recent to the oldest. Instructions
+of the System, SPL Token, Serum, Token Registry and Metaplex programs are
+decoded, the others are printed raw.
+
+Use --before with the last signature printed to retrieve the next page.
+`,
+	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		client := getClient()
 
 		address := args[0]
-		pubKey, err := solana.PublicKeyFromBase58(address)
+		pubKey, err := resolveAddress(address)
 		if err != nil {
 			return fmt.Errorf("invalid account address %q: %w", address, err)
 		}
 
-		csList, err := client.GetSignaturesForAddress(pubKey, &rpc.GetSignaturesForAddressOpts{
-			Limit:  1,
-			Before: "",
-			Until:  "",
+		limit := viper.GetUint64("get-transactions-cmd-limit")
+		if limit == 0 || limit > 1000 {
+			return fmt.Errorf("limit must be between 1 and 1000, got %d", limit)
+		}
+
+		csList, err := getSignaturesForAddress(client, pubKey, &rpc.GetSignaturesForAddressOpts{
+			Limit:  limit,
+			Before: viper.GetString("get-transactions-cmd-before"),
+			Until:  viper.GetString("get-transactions-cmd-until"),
 		})
 		if err != nil {
 			return fmt.Errorf("unable to retrieve confirmed transaction signatures for account: %w", err)
 		}
 
+		out := &transactionsOutput{Transactions: []*transactionView{}}
 		for _, cs := range csList {
-			fmt.Println("-----------------------------------------------------------------------------------------------")
-			text.EncoderColorCyan.Print("Transaction: ")
-			fmt.Println(cs.Signature)
-
-			text.EncoderColorGreen.Print("Slot: ")
-			fmt.Println(cs.Slot)
-			text.EncoderColorGreen.Print("Memo: ")
-			fmt.Println(cs.Memo)
-
-			ct, err := client.GetConfirmedTransaction(cs.Signature)
+			ct, err := getConfirmedTransaction(client, cs.Signature)
 			if err != nil {
 				return fmt.Errorf("unable to get confirmed transaction with signature %q: %w", cs.Signature, err)
 			}
 
-			if ct.Meta.Err != nil {
-				cnt, _ := json.Marshal(ct.Meta.Err.Raw)
-				return fmt.Errorf("unable to get confirmed transaction with signature %q: %s", cs.Signature, string(cnt))
+			if ct == nil {
+				return fmt.Errorf("transaction with signature %q not found", cs.Signature)
 			}
 
-			fmt.Print("\nInstructions:\n-------------\n\n")
-			for _, i := range ct.Transaction.Message.Instructions {
-
-				id := ct.Transaction.Message.AccountKeys[i.ProgramIdIndex]
-				decoder := solana.InstructionDecoderRegistry[id.String()]
-				if decoder == nil {
-					fmt.Println("raw instruction:")
-					fmt.Printf("Program: %s Data: %s\n", id.String(), i.Data)
-					fmt.Println("Accounts:")
-					for _, accIndex := range i.Accounts {
-						key := ct.Transaction.Message.AccountKeys[accIndex]
-						fmt.Printf("%s\n", key.String())
-						//fmt.Printf("%s Is Writable: %t Is Signer: %t\n", key.String(), ct.Transaction.IsWritable(key), ct.Transaction.IsSigner(key))
-					}
-					fmt.Printf("\n\n")
-					continue
-				}
-
-				//decoded, err := decoder(ct.Transaction.AccountMetaList(), i.Data)
-				//if err != nil {
-				//	return fmt.Errorf("unable to decode instruction: %w", err)
-				//}
-				//
-				//err = text.NewEncoder(os.Stdout).Encode(decoded, nil)
-				//if err != nil {
-				//	return fmt.Errorf("unable to text encoder instruction: %w", err)
-				//}
+			view, err := newTransactionView(cs, ct)
+			if err != nil {
+				return fmt.Errorf("unable to read transaction with signature %q: %w", cs.Signature, err)
 			}
-			text.EncoderColorCyan.Print("\n\nEnd of transaction\n\n")
+
+			out.Transactions = append(out.Transactions, view)
 		}
 
-		return nil
+		if uint64(len(csList)) == limit {
+			out.NextBefore = csList[len(csList)-1].Signature
+		}
+
+		return printOutput(out)
 	},
 }
 
 func init() {
 	getCmd.AddCommand(getTransactionsCmd)
+
+	getTransactionsCmd.Flags().Uint64("limit", 10, "Maximum number of transactions to retrieve, at most 1000")
+	getTransactionsCmd.Flags().String("before", "", "Retrieve transactions older than this transaction signature")
+	getTransactionsCmd.Flags().String("until", "", "Retrieve transactions newer than this transaction signature")
+}
+
+// transactionSignature is an entry of `getSignaturesForAddress`, the error
+// is kept raw since `rpc.TransactionError` cannot decode all of them.
+type transactionSignature struct {
+	Signature string          `json:"signature"`
+	Slot      uint64          `json:"slot"`
+	BlockTime *int64          `json:"blockTime"`
+	Err       json.RawMessage `json:"err"`
+	Memo      *string         `json:"memo"`
+}
+
+func getSignaturesForAddress(client *rpc.Client, address solana.PublicKey, opts *rpc.GetSignaturesForAddressOpts) (out []*transactionSignature, err error) {
+	err = client.DoRequest(&out, "getSignaturesForAddress", address.String(), opts)
+	return
+}
+
+type confirmedTransaction struct {
+	Slot        uint64 `json:"slot"`
+	BlockTime   *int64 `json:"blockTime"`
+	Transaction *struct {
+		Signatures []string          `json:"signatures"`
+		Message    *confirmedMessage `json:"message"`
+	} `json:"transaction"`
+	Meta *confirmedTransactionMeta `json:"meta"`
+}
+
+type confirmedTransactionMeta struct {
+	Err               json.RawMessage              `json:"err"`
+	Fee               uint64                       `json:"fee"`
+	PreBalances       []uint64                     `json:"preBalances"`
+	PostBalances      []uint64                     `json:"postBalances"`
+	InnerInstructions []*confirmedInnerInstruction `json:"innerInstructions"`
+	LogMessages       []string                     `json:"logMessages"`
+	LoadedAddresses   *struct {
+		Writable []solana.PublicKey `json:"writable"`
+		Readonly []solana.PublicKey `json:"readonly"`
+	} `json:"loadedAddresses"`
+}
+
+type confirmedMessage struct {
+	AccountKeys  []solana.PublicKey      `json:"accountKeys"`
+	Header       rpc.MessageHeader       `json:"header"`
+	Instructions []*confirmedInstruction `json:"instructions"`
+}
+
+type confirmedInnerInstruction struct {
+	Index        int                     `json:"index"`
+	Instructions []*confirmedInstruction `json:"instructions"`
+}
+
+type confirmedInstruction struct {
+	ProgramIDIndex int           `json:"programIdIndex"`
+	Accounts       []int         `json:"accounts"`
+	Data           solana.Base58 `json:"data"`
+}
+
+// getConfirmedTransaction retrieves a transaction along with its metadata,
+// it returns `nil` when the transaction is not found.
+func getConfirmedTransaction(client *rpc.Client, signature string) (out *confirmedTransaction, err error) {
+	err = client.DoRequest(&out, "getTransaction", signature, map[string]interface{}{
+		"encoding":                       "json",
+		"commitment":                     getCommitment(rpc.CommitmentConfirmed),
+		"maxSupportedTransactionVersion": 0,
+	})
+	return
+}
+
+// transactionsOutput is a page of transactions, `next_before` is set when
+// more transactions may be retrieved by passing it to `--before`.
+type transactionsOutput struct {
+	Transactions []*transactionView `json:"transactions"`
+	NextBefore   string             `json:"next_before,omitempty"`
+}
+
+func (o *transactionsOutput) Text(w io.Writer) error {
+	for _, view := range o.Transactions {
+		if err := printTransactionView(w, view); err != nil {
+			return err
+		}
+	}
+
+	if o.NextBefore != "" {
+		fmt.Fprintf(w, "More transactions may be available, continue with --before %s\n", o.NextBefore)
+	}
+	return nil
+}
+
+type transactionView struct {
+	Signature      string               `json:"signature"`
+	Slot           uint64               `json:"slot"`
+	BlockTime      *int64               `json:"block_time"`
+	Memo           *string              `json:"memo"`
+	Error          string               `json:"error,omitempty"`
+	Fee            uint64               `json:"fee"`
+	Instructions   []*instructionView   `json:"instructions"`
+	BalanceChanges []*balanceChangeView `json:"balance_changes"`
+	Logs           []string             `json:"logs"`
+}
+
+type instructionView struct {
+	ProgramID         solana.PublicKey      `json:"program_id"`
+	Accounts          []*solana.AccountMeta `json:"-"`
+	Data              solana.Base58         `json:"data"`
+	Decoded           interface{}           `json:"-"`
+	DecodeError       string                `json:"decode_error,omitempty"`
+	InnerInstructions []*instructionView    `json:"inner_instructions,omitempty"`
+}
+
+type instructionAccountView struct {
+	Address  solana.PublicKey `json:"address"`
+	Signer   bool             `json:"signer"`
+	Writable bool             `json:"writable"`
+}
+
+type decodedInstructionView struct {
+	Name   string      `json:"name"`
+	Params interface{} `json:"params"`
+}
+
+// MarshalJSON gives the accounts and the decoded instruction a schema of
+// their own, neither `solana.AccountMeta` nor the program instructions have
+// `json` tags.
+func (v *instructionView) MarshalJSON() ([]byte, error) {
+	type alias instructionView
+	out := struct {
+		*alias
+		Accounts []*instructionAccountView `json:"accounts"`
+		Decoded  *decodedInstructionView   `json:"decoded,omitempty"`
+	}{alias: (*alias)(v), Accounts: []*instructionAccountView{}}
+
+	for _, account := range v.Accounts {
+		out.Accounts = append(out.Accounts, &instructionAccountView{
+			Address:  account.PublicKey,
+			Signer:   account.IsSigner,
+			Writable: account.IsWritable,
+		})
+	}
+
+	if v.Decoded != nil {
+		name, params := decodedInstructionName(v.Decoded)
+		out.Decoded = &decodedInstructionView{Name: name, Params: params}
+	}
+
+	return json.Marshal(out)
+}
+
+type balanceChangeView struct {
+	Address solana.PublicKey `json:"address"`
+	Pre     uint64           `json:"pre"`
+	Post    uint64           `json:"post"`
+}
+
+func newTransactionView(cs *transactionSignature, ct *confirmedTransaction) (*transactionView, error) {
+	if ct.Transaction == nil || ct.Transaction.Message == nil || ct.Meta == nil {
+		return nil, fmt.Errorf("transaction or its metadata is missing from the response")
+	}
+
+	accounts := transactionAccountMetas(ct.Transaction.Message, ct.Meta)
+	newInstruction := func(programIDIndex int, accountIndexes []int, data solana.Base58) (*instructionView, error) {
+		if programIDIndex >= len(accounts) {
+			return nil, fmt.Errorf("program index %d out of range", programIDIndex)
+		}
+
+		view := &instructionView{
+			ProgramID: accounts[programIDIndex].PublicKey,
+			Data:      data,
+		}
+		for _, index := range accountIndexes {
+			if index >= len(accounts) {
+				return nil, fmt.Errorf("account index %d out of range", index)
+			}
+			view.Accounts = append(view.Accounts, accounts[index])
+		}
+
+		decoded, err := decodeInstruction(view.ProgramID, view.Accounts, data)
+		if err != nil {
+			view.DecodeError = err.Error()
+		}
+		view.Decoded = decoded
+
+		return view, nil
+	}
+
+	view := &transactionView{
+		Signature: cs.Signature,
+		Slot:      ct.Slot,
+		BlockTime: ct.BlockTime,
+		Memo:      cs.Memo,
+		Fee:       ct.Meta.Fee,
+		Logs:      ct.Meta.LogMessages,
+	}
+
+	var programIDs []solana.PublicKey
+	for _, inst := range ct.Transaction.Message.Instructions {
+		instruction, err := newInstruction(inst.ProgramIDIndex, inst.Accounts, inst.Data)
+		if err != nil {
+			return nil, err
+		}
+
+		view.Instructions = append(view.Instructions, instruction)
+		programIDs = append(programIDs, instruction.ProgramID)
+	}
+
+	for _, inner := range ct.Meta.InnerInstructions {
+		if inner.Index >= len(view.Instructions) {
+			return nil, fmt.Errorf("inner instructions index %d out of range", inner.Index)
+		}
+
+		parent := view.Instructions[inner.Index]
+		for _, inst := range inner.Instructions {
+			instruction, err := newInstruction(inst.ProgramIDIndex, inst.Accounts, inst.Data)
+			if err != nil {
+				return nil, err
+			}
+			parent.InnerInstructions = append(parent.InnerInstructions, instruction)
+		}
+	}
+
+	if len(ct.Meta.Err) != 0 && string(ct.Meta.Err) != "null" {
+		view.Error = describeTransactionError(ct.Meta.Err, programIDs)
+	}
+
+	for i, account := range accounts {
+		if i >= len(ct.Meta.PreBalances) || i >= len(ct.Meta.PostBalances) {
+			break
+		}
+
+		if ct.Meta.PreBalances[i] != ct.Meta.PostBalances[i] {
+			view.BalanceChanges = append(view.BalanceChanges, &balanceChangeView{
+				Address: account.PublicKey,
+				Pre:     ct.Meta.PreBalances[i],
+				Post:    ct.Meta.PostBalances[i],
+			})
+		}
+	}
+
+	return view, nil
+}
+
+// transactionAccountMetas resolves the signer and writable roles of every
+// account of the message, the ones loaded from address lookup tables
+// coming after the static ones.
+func transactionAccountMetas(message *confirmedMessage, meta *confirmedTransactionMeta) []*solana.AccountMeta {
+	numRequiredSignatures := int(message.Header.NumRequiredSignatures)
+	numWritableSigned := numRequiredSignatures - int(message.Header.NumReadonlySignedAccounts)
+	numWritableUnsigned := len(message.AccountKeys) - int(message.Header.NumReadonlyUnsignedAccounts)
+
+	var out []*solana.AccountMeta
+	for i, key := range message.AccountKeys {
+		out = append(out, &solana.AccountMeta{
+			PublicKey:  key,
+			IsSigner:   i < numRequiredSignatures,
+			IsWritable: i < numWritableSigned || (i >= numRequiredSignatures && i < numWritableUnsigned),
+		})
+	}
+
+	if meta.LoadedAddresses != nil {
+		for _, key := range meta.LoadedAddresses.Writable {
+			out = append(out, &solana.AccountMeta{PublicKey: key, IsWritable: true})
+		}
+		for _, key := range meta.LoadedAddresses.Readonly {
+			out = append(out, &solana.AccountMeta{PublicKey: key})
+		}
+	}
+
+	return out
+}
+
+func printTransactionView(w io.Writer, view *transactionView) error {
+	fmt.Fprintln(w, "-----------------------------------------------------------------------------------------------")
+	text.EncoderColorCyan.Fprint(w, "Transaction: ")
+	fmt.Fprintln(w, view.Signature)
+
+	text.EncoderColorGreen.Fprint(w, "Slot: ")
+	fmt.Fprintln(w, view.Slot)
+	if view.Memo != nil {
+		text.EncoderColorGreen.Fprint(w, "Memo: ")
+		fmt.Fprintln(w, *view.Memo)
+	}
+	text.EncoderColorGreen.Fprint(w, "Fee: ")
+	fmt.Fprintln(w, formatLamports(view.Fee))
+	if view.Error != "" {
+		text.EncoderColorYellow.Fprint(w, "Error: ")
+		fmt.Fprintln(w, view.Error)
+	}
+
+	fmt.Fprint(w, "\nInstructions:\n-------------\n\n")
+	for i, instruction := range view.Instructions {
+		if err := printInstructionView(w, fmt.Sprintf("#%d", i), instruction); err != nil {
+			return err
+		}
+
+		for j, inner := range instruction.InnerInstructions {
+			if err := printInstructionView(w, fmt.Sprintf("#%d.%d (inner)", i, j), inner); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(view.BalanceChanges) > 0 {
+		fmt.Fprint(w, "Balance changes:\n----------------\n\n")
+		out := []string{"Account | Pre | Post | Delta"}
+		for _, change := range view.BalanceChanges {
+			delta := "+" + formatLamports(change.Post-change.Pre)
+			if change.Post < change.Pre {
+				delta = "-" + formatLamports(change.Pre-change.Post)
+			}
+			out = append(out, fmt.Sprintf("%s | %s | %s | %s", change.Address, formatLamports(change.Pre), formatLamports(change.Post), delta))
+		}
+		fmt.Fprintln(w, columnize.Format(out, nil))
+		fmt.Fprintln(w, "")
+	}
+
+	if len(view.Logs) > 0 {
+		fmt.Fprint(w, "Logs:\n-----\n\n")
+		for _, line := range view.Logs {
+			fmt.Fprintln(w, line)
+		}
+	}
+
+	text.EncoderColorCyan.Fprint(w, "\n\nEnd of transaction\n\n")
+	return nil
+}
+
+func printInstructionView(w io.Writer, label string, instruction *instructionView) error {
+	text.EncoderColorGreen.Fprintf(w, "Instruction %s ", label)
+	fmt.Fprintf(w, "program %s\n", instruction.ProgramID.String())
+
+	fmt.Fprintln(w, "Accounts:")
+	for _, account := range instruction.Accounts {
+		fmt.Fprintf(w, "  %s %s\n", accountRoles(account), account.PublicKey.String())
+	}
+
+	if instruction.Decoded == nil {
+		if instruction.DecodeError != "" {
+			fmt.Fprintf(w, "Unable to decode: %s\n", instruction.DecodeError)
+		}
+		fmt.Fprintf(w, "Data: %s\n\n", instruction.Data.String())
+		return nil
+	}
+
+	name, content := decodedInstructionName(instruction.Decoded)
+	cnt, err := json.MarshalIndent(content, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to encode instruction: %w", err)
+	}
+
+	fmt.Fprintf(w, "%s: %s\n\n", name, string(cnt))
+	return nil
+}
+
+func accountRoles(account *solana.AccountMeta) string {
+	roles := []byte("--")
+	if account.IsSigner {
+		roles[0] = 's'
+	}
+	if account.IsWritable {
+		roles[1] = 'w'
+	}
+	return string(roles)
 }
@@ -0,0 +1,220 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/binary"
+	"fmt"
+	"reflect"
+
+	bin "github.com/streamingfast/binary"
+	"github.com/streamingfast/solana-go"
+	"github.com/streamingfast/solana-go/programs/metaplex"
+	_ "github.com/streamingfast/solana-go/programs/serum"
+	_ "github.com/streamingfast/solana-go/programs/system"
+	_ "github.com/streamingfast/solana-go/programs/token"
+	_ "github.com/streamingfast/solana-go/programs/tokenregistry"
+)
+
+func init() {
+	// The metaplex package does not register itself in the instruction
+	// decoder registry like the other programs do
+	solana.RegisterInstructionDecoder(metaplex.PROGRAM_ID, decodeMetaplexInstruction)
+}
+
+// decodeInstruction decodes an instruction through the decoder registered
+// for `programID`. It returns `nil` without error when no decoder is known
+// for the program.
+func decodeInstruction(programID solana.PublicKey, accounts []*solana.AccountMeta, data []byte) (out interface{}, err error) {
+	decoder := solana.InstructionDecoderRegistry[programID.String()]
+	if decoder == nil {
+		return nil, nil
+	}
+
+	// Decoders index accounts and data without bound checks, a malformed
+	// instruction must not bring the whole command down
+	defer func() {
+		if r := recover(); r != nil {
+			out, err = nil, fmt.Errorf("unable to decode instruction: %v", r)
+		}
+	}()
+
+	return decoder(accounts, data)
+}
+
+func decodeMetaplexInstruction(accounts []*solana.AccountMeta, data []byte) (interface{}, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("empty metaplex instruction")
+	}
+
+	var inst interface{}
+	switch metaplex.InstType(data[0]) {
+	case metaplex.CreateMetadataAccountV1Inst:
+		inst = &metaplex.CreateMetadataAccount{}
+	case metaplex.UpdateMetadataAccountV1Inst:
+		inst = &metaplex.UpdateMetadataV1Account{}
+	case metaplex.MintNewEditionFromMasterEditionViaTokenInst:
+		inst = &metaplex.MintNewEditionFromMasterEditionViaToken{}
+	case metaplex.UpdateMetadataAccountV2Inst:
+		inst = &metaplex.UpdateMetadataV2Account{}
+	case metaplex.CreateMetadataAccountV2Inst:
+		inst = &metaplex.CreateMetadataV2Account{}
+	case metaplex.CreateMasterEditionV3Inst:
+		inst = &metaplex.CreateMasterEditionV3Account{}
+	default:
+		return nil, fmt.Errorf("unsupported metaplex instruction %d", data[0])
+	}
+
+	decoder := &borshDecoder{data: data}
+	if err := decoder.decode(reflect.ValueOf(inst).Elem()); err != nil {
+		return nil, fmt.Errorf("unable to decode metaplex instruction %d: %w", data[0], err)
+	}
+
+	return inst, nil
+}
+
+// borshDecoder decodes the Borsh encoded metaplex instructions. It's used
+// instead of `borsh.Deserialize` which turns absent optional values into
+// zero values instead of leaving them `nil`.
+type borshDecoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *borshDecoder) read(n int) ([]byte, error) {
+	if d.pos+n > len(d.data) {
+		return nil, fmt.Errorf("unexpected end of data at offset %d, %d bytes required", d.pos, n)
+	}
+
+	out := d.data[d.pos : d.pos+n]
+	d.pos += n
+	return out, nil
+}
+
+func (d *borshDecoder) decode(v reflect.Value) error {
+	if v.Type() == reflect.TypeOf(solana.PublicKey{}) {
+		b, err := d.read(32)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(solana.PublicKeyFromBytes(b)))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		b, err := d.read(1)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b[0] != 0)
+	case reflect.Uint8, reflect.Int8:
+		b, err := d.read(1)
+		if err != nil {
+			return err
+		}
+		d.setInt(v, uint64(b[0]))
+	case reflect.Uint16, reflect.Int16:
+		b, err := d.read(2)
+		if err != nil {
+			return err
+		}
+		d.setInt(v, uint64(binary.LittleEndian.Uint16(b)))
+	case reflect.Uint32, reflect.Int32:
+		b, err := d.read(4)
+		if err != nil {
+			return err
+		}
+		d.setInt(v, uint64(binary.LittleEndian.Uint32(b)))
+	case reflect.Uint64, reflect.Int64:
+		b, err := d.read(8)
+		if err != nil {
+			return err
+		}
+		d.setInt(v, binary.LittleEndian.Uint64(b))
+	case reflect.String:
+		b, err := d.read(4)
+		if err != nil {
+			return err
+		}
+		s, err := d.read(int(binary.LittleEndian.Uint32(b)))
+		if err != nil {
+			return err
+		}
+		v.SetString(string(s))
+	case reflect.Slice:
+		b, err := d.read(4)
+		if err != nil {
+			return err
+		}
+		length := int(binary.LittleEndian.Uint32(b))
+		if length > len(d.data)-d.pos {
+			return fmt.Errorf("invalid slice length %d at offset %d", length, d.pos)
+		}
+		v.Set(reflect.MakeSlice(v.Type(), length, length))
+		for i := 0; i < length; i++ {
+			if err := d.decode(v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Ptr:
+		b, err := d.read(1)
+		if err != nil {
+			return err
+		}
+		if b[0] == 0 {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		v.Set(reflect.New(v.Type().Elem()))
+		return d.decode(v.Elem())
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).Tag.Get("borsh_skip") == "true" {
+				continue
+			}
+			if err := d.decode(v.Field(i)); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+func (d *borshDecoder) setInt(v reflect.Value, value uint64) {
+	switch v.Kind() {
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(int64(value))
+	default:
+		v.SetUint(value)
+	}
+}
+
+// decodedInstructionName returns the name of a decoded instruction along
+// with its content, unwrapping the variant used by most program packages.
+func decodedInstructionName(decoded interface{}) (string, interface{}) {
+	rv := reflect.Indirect(reflect.ValueOf(decoded))
+	if rv.Kind() == reflect.Struct {
+		if field := rv.FieldByName("BaseVariant"); field.IsValid() {
+			if variant, ok := field.Interface().(bin.BaseVariant); ok && variant.Impl != nil {
+				return reflect.Indirect(reflect.ValueOf(variant.Impl)).Type().Name(), variant.Impl
+			}
+		}
+	}
+
+	return rv.Type().Name(), decoded
+}
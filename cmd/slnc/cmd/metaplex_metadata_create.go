@@ -25,8 +25,6 @@ import (
 	"github.com/streamingfast/solana-go/programs/metaplex"
 	"github.com/streamingfast/solana-go/programs/system"
 	"github.com/streamingfast/solana-go/programs/token"
-	"github.com/streamingfast/solana-go/rpc"
-	"github.com/streamingfast/solana-go/rpc/confirm"
 	"go.uber.org/zap"
 	"os"
 )
@@ -37,7 +35,11 @@ var metaplexMedatadaCreateCmd = &cobra.Command{
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		ctx := cmd.Context()
-		vault := mustGetWallet()
+		if viper.GetBool("global-dump-unsigned") {
+			return fmt.Errorf("creating a mint requires its new keypair to sign, it cannot be combined with --dump-unsigned")
+		}
+
+		vault := mustGetSignerWallet()
 		rpcClient := getClient()
 		wsClient, err := getWsClient(ctx)
 		if err != nil {
@@ -113,18 +115,13 @@ var metaplexMedatadaCreateCmd = &cobra.Command{
 			),
 		}
 
-		blockHashResult, err := rpcClient.GetLatestBlockhash(rpc.CommitmentFinalized)
-		if err != nil {
-			return fmt.Errorf("unable retrieve recent block hash: %w", err)
-		}
-
-		trx, err := solana.NewTransaction(instructions, blockHashResult.Value.Blockhash)
+		trx, err := newTransaction(rpcClient, instructions)
 		if err != nil {
-			return fmt.Errorf("unable to create transaction: %w", err)
+			return err
 		}
 
-		zlog.Info("signing transactions")
-		_, err = trx.Sign(func(key solana.PublicKey) *solana.PrivateKey {
+		zlog.Info("signing and sending transaction")
+		trxHash, err := sendTransaction(ctx, rpcClient, wsClient, trx, func(key solana.PublicKey) *solana.PrivateKey {
 			// create account need to be signed by the private key of the new account
 			// that is not in the vault and will be lost after the execution.
 			if adminKey.PublicKey() == key {
@@ -137,20 +134,16 @@ var metaplexMedatadaCreateCmd = &cobra.Command{
 			return nil
 		})
 		if err != nil {
-			return fmt.Errorf("unable to sign transaction: %w", err)
+			return fmt.Errorf("unable to send transaction: %w", err)
 		}
 
-		zlog.Info("sending transaction")
-		trxHash, err := confirm.SendAndConfirmTransaction(ctx, rpcClient, wsClient, trx)
-		if err != nil {
-			return fmt.Errorf("unable to send transaction: %w", err)
+		if trxHash == "" {
+			return nil
 		}
 
-		fmt.Printf("Creating whitelist token, with transaction hash: %s\n", trxHash)
-		fmt.Printf("  Mint Address: %s\n", mintPublicKey.String())
-		fmt.Printf("  Owner Address: %s\n", adminKey.PublicKey().String())
-		fmt.Printf("Run `slnc metaplex metadata get %s -u %q` to view metadataAddress", mintPublicKey.String(), getRPCURL())
-		return nil
+		return printOutput(newTransactionOutput(trxHash, "Creating whitelist token, with transaction hash: %s\n  Mint Address: %s\n  Owner Address: %s\nRun `slnc metaplex metadata get %s -u %q` to view metadataAddress", trxHash, mintPublicKey.String(), adminKey.PublicKey().String(), mintPublicKey.String(), getRPCURL()).
+			withAccount("mint", mintPublicKey).
+			withAccount("owner", adminKey.PublicKey()))
 	},
 }
 
@@ -163,7 +156,7 @@ func selectAccountFromVault(vault *vault.Vault) (solana.PrivateKey, error) {
 	var defaultKey *solana.PublicKey
 	var err error
 	if defaultKeyStr != "" {
-		v, err := solana.PublicKeyFromBase58(defaultKeyStr)
+		v, err := resolveAddress(defaultKeyStr)
 		if err != nil {
 			return nil, fmt.Errorf("invalid default key")
 		}
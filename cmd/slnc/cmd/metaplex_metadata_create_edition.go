@@ -22,7 +22,6 @@ import (
 	"github.com/streamingfast/solana-go/programs/metaplex"
 	"github.com/streamingfast/solana-go/programs/token"
 	"github.com/streamingfast/solana-go/rpc"
-	"github.com/streamingfast/solana-go/rpc/confirm"
 	"go.uber.org/zap"
 	"strconv"
 )
@@ -33,7 +32,7 @@ var metaplexMedatadaCreateEditionCmd = &cobra.Command{
 	Args:  cobra.ExactArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		ctx := cmd.Context()
-		vault := mustGetWallet()
+		vault := mustGetSignerWallet()
 		rpcClient := getClient()
 		wsClient, err := getWsClient(ctx)
 		if err != nil {
@@ -46,7 +45,7 @@ var metaplexMedatadaCreateEditionCmd = &cobra.Command{
 			return fmt.Errorf("unable to decode metaplex metadata programId %q: %w", metaplexMetaProgramId, err)
 		}
 
-		mintAddr, err := solana.PublicKeyFromBase58(args[0])
+		mintAddr, err := resolveAddress(args[0])
 		if err != nil {
 			return fmt.Errorf("unable to decode mint addr: %w", err)
 		}
@@ -137,18 +136,13 @@ var metaplexMedatadaCreateEditionCmd = &cobra.Command{
 			),
 		}
 
-		blockHashResult, err := rpcClient.GetLatestBlockhash(rpc.CommitmentFinalized)
+		trx, err := newTransaction(rpcClient, instructions)
 		if err != nil {
-			return fmt.Errorf("unable retrieve recent block hash: %w", err)
+			return err
 		}
 
-		trx, err := solana.NewTransaction(instructions, blockHashResult.Value.Blockhash)
-		if err != nil {
-			return fmt.Errorf("unable to create transaction: %w", err)
-		}
-
-		zlog.Info("signing transactions")
-		_, err = trx.Sign(func(key solana.PublicKey) *solana.PrivateKey {
+		zlog.Info("signing and sending transaction")
+		trxHash, err := sendTransaction(ctx, rpcClient, wsClient, trx, func(key solana.PublicKey) *solana.PrivateKey {
 			// create account need to be signed by the private key of the new account
 			// that is not in the vault and will be lost after the execution.
 			if payerKey.PublicKey() == key {
@@ -164,20 +158,17 @@ var metaplexMedatadaCreateEditionCmd = &cobra.Command{
 			return nil
 		})
 		if err != nil {
-			return fmt.Errorf("unable to sign transaction: %w", err)
+			return fmt.Errorf("unable to send transaction: %w", err)
 		}
 
-		zlog.Info("sending transaction")
-		trxHash, err := confirm.SendAndConfirmTransaction(ctx, rpcClient, wsClient, trx)
-		if err != nil {
-			return fmt.Errorf("unable to send transaction: %w", err)
+		if trxHash == "" {
+			return nil
 		}
 
-		fmt.Printf("Creating whitelist token, with transaction hash: %s\n", trxHash)
-		fmt.Printf("  Mint Address: %s\n", mintAddr.String())
-		fmt.Printf("  Metada Address: %s\n", metadataAddr.String())
-		fmt.Printf("  Edition Address: %s\n", editionAccount.String())
-		return nil
+		return printOutput(newTransactionOutput(trxHash, "Creating whitelist token, with transaction hash: %s\n  Mint Address: %s\n  Metada Address: %s\n  Edition Address: %s\n", trxHash, mintAddr.String(), metadataAddr.String(), editionAccount.String()).
+			withAccount("mint", mintAddr).
+			withAccount("metadata", metadataAddr).
+			withAccount("edition", editionAccount))
 	},
 }
 
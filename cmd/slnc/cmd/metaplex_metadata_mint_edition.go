@@ -16,6 +16,7 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -25,7 +26,6 @@ import (
 	"github.com/streamingfast/solana-go/programs/system"
 	"github.com/streamingfast/solana-go/programs/token"
 	"github.com/streamingfast/solana-go/rpc"
-	"github.com/streamingfast/solana-go/rpc/confirm"
 	"github.com/streamingfast/solana-go/rpc/ws"
 	"go.uber.org/zap"
 	"strconv"
@@ -39,7 +39,11 @@ var metaplexMedatadaMintEditionCmd = &cobra.Command{
 	Args:  cobra.ExactArgs(3),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		ctx := cmd.Context()
-		vault := mustGetWallet()
+		if viper.GetBool("global-dump-unsigned") {
+			return fmt.Errorf("minting an edition requires the keypair of its new mint to sign, it cannot be combined with --dump-unsigned")
+		}
+
+		vault := mustGetSignerWallet()
 		rpcClient := getClient()
 		wsClient, err := getWsClient(ctx)
 		if err != nil {
@@ -56,12 +60,12 @@ var metaplexMedatadaMintEditionCmd = &cobra.Command{
 			return fmt.Errorf("unable to select admin key: %w", err)
 		}
 
-		recipientAddr, err := solana.PublicKeyFromBase58(args[0])
+		recipientAddr, err := resolveAddress(args[0])
 		if err != nil {
 			return fmt.Errorf("unable to decode mint addr: %w", err)
 		}
 
-		masterMintAddr, err := solana.PublicKeyFromBase58(args[1])
+		masterMintAddr, err := resolveAddress(args[1])
 		if err != nil {
 			return fmt.Errorf("unable to decode master mint addr: %w", err)
 		}
@@ -85,8 +89,12 @@ var metaplexMedatadaMintEditionCmd = &cobra.Command{
 			return err
 		}
 
-		fmt.Printf("Minted: %s Recipient: %s, Edition: %d\n", trxHash, recipientAddr.String(), editionNum)
-		return nil
+		if trxHash == "" {
+			return nil
+		}
+
+		return printOutput(newTransactionOutput(trxHash, "Minted: %s Recipient: %s, Edition: %d\n", trxHash, recipientAddr.String(), editionNum).
+			withAccount("recipient", recipientAddr))
 	},
 }
 
@@ -224,6 +232,9 @@ func mintEdition(
 			}
 			return nil
 		})
+		if errors.Is(err, errSimulationFailed) {
+			return "", err
+		}
 		if err != nil {
 			zlog.Info("error minting will retry", zap.Error(err))
 			time.Sleep(50 * time.Millisecond)
@@ -231,7 +242,7 @@ func mintEdition(
 		}
 		break
 	}
-	if trxHash == "" {
+	if err != nil {
 		return "", fmt.Errorf("exceed retry count could not resolve: %w", err)
 	}
 
@@ -240,28 +251,23 @@ func mintEdition(
 
 var RETRY_COUNT = 5
 
-type getterFunc = func(key solana.PublicKey) *solana.PrivateKey
+// mintEditionInstructionIndex is the position of the Metaplex instruction
+// among the instructions built by `mintEdition`.
+const mintEditionInstructionIndex = 4
 
 func sendMintEditionTrx(ctx context.Context, rpcClient *rpc.Client, wsClient *ws.Client, instructions []solana.Instruction, getter getterFunc) (string, error) {
-	blockHashResult, err := rpcClient.GetLatestBlockhash(rpc.CommitmentFinalized)
-	if err != nil {
-		return "", fmt.Errorf("unable retrieve recent block hash: %w", err)
-	}
-
-	trx, err := solana.NewTransaction(instructions, blockHashResult.Value.Blockhash)
+	trx, err := newTransaction(rpcClient, instructions)
 	if err != nil {
-		return "", fmt.Errorf("unable to create transaction: %w", err)
+		return "", err
 	}
 
-	_, err = trx.Sign(getter)
-	if err != nil {
-		return "", fmt.Errorf("unable to sign transaction: %w", err)
-	}
+	// `newTransaction` prepends the durable nonce and Compute Budget
+	// instructions, shifting the Metaplex one reported in errors.
+	index := len(trx.Message.Instructions) - len(instructions) + mintEditionInstructionIndex
 
-	zlog.Info("transction signed, sending to chain", zap.String("trx_sign", trx.Signatures[0].String()))
-	trxHash, err := confirm.SendAndConfirmTransaction(ctx, rpcClient, wsClient, trx)
+	trxHash, err := sendTransaction(ctx, rpcClient, wsClient, trx, getter)
 	if err != nil {
-		if strings.Contains(err.Error(), "Instruction 4: custom program error: 0x3") {
+		if strings.Contains(err.Error(), fmt.Sprintf("Instruction %d: custom program error: 0x3", index)) {
 			zlog.Info("mint edition failed, most likely mint exisit, skipping")
 			return "lost", nil
 		}
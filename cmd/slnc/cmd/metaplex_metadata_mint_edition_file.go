@@ -32,15 +32,17 @@ import (
 	"strings"
 )
 
-const PARALLE_MINT_EDITION = 10
-
 var metaplexMedatadaMintEditionFromFileCmd = &cobra.Command{
 	Use:   "mint-edition-from-file {file-path} {outfile-path}",
 	Short: "Mint an edition",
 	Args:  cobra.ExactArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		ctx := cmd.Context()
-		vault := mustGetWallet()
+		if isOfflineTransactionMode() {
+			return fmt.Errorf("minting editions from a file cannot be combined with --sign-only or --dump-unsigned")
+		}
+
+		vault := mustGetSignerWallet()
 		rpcClient := getClient()
 		wsClient, err := getWsClient(ctx)
 		if err != nil {
@@ -110,7 +112,7 @@ var metaplexMedatadaMintEditionFromFileCmd = &cobra.Command{
 			mintEditionToProcess = append(mintEditionToProcess, me)
 		}
 
-		queue := dhammer.NewNailer(PARALLE_MINT_EDITION, mintEditionJob(rpcClient, wsClient, programID, adminKey, rentLamports))
+		queue := dhammer.NewNailer(getMaxConcurrency(), mintEditionJob(rpcClient, wsClient, programID, adminKey, rentLamports))
 		queue.Start(ctx)
 		zlog.Info("found mint edition to process", zap.Int("count", len(mintEditionToProcess)))
 		// producer async
@@ -175,12 +177,12 @@ type MintEdition struct {
 }
 
 func fromRecord(rec []string) (*MintEdition, error) {
-	recipientAddr, err := solana.PublicKeyFromBase58(rec[0])
+	recipientAddr, err := resolveAddress(rec[0])
 	if err != nil {
 		return nil, fmt.Errorf("unable to decode mint addr: %w", err)
 	}
 
-	masterMintAddr, err := solana.PublicKeyFromBase58(rec[1])
+	masterMintAddr, err := resolveAddress(rec[1])
 	if err != nil {
 		return nil, fmt.Errorf("unable to decode master mint addr: %w", err)
 	}
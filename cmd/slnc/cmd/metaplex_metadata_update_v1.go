@@ -18,9 +18,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/streamingfast/solana-go"
-	"github.com/streamingfast/solana-go/rpc"
-	"github.com/streamingfast/solana-go/rpc/confirm"
-	"go.uber.org/zap"
 	"os"
 
 	"github.com/spf13/cobra"
@@ -34,7 +31,7 @@ var metaplexMedatadaUpdateV1Cmd = &cobra.Command{
 	Args:  cobra.ExactArgs(3),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		ctx := cmd.Context()
-		vault := mustGetWallet()
+		vault := mustGetSignerWallet()
 		rpcClient := getClient()
 		wsClient, err := getWsClient(ctx)
 
@@ -48,7 +45,7 @@ var metaplexMedatadaUpdateV1Cmd = &cobra.Command{
 			return fmt.Errorf("unable to decode metaplex metadata programId %q: %w", metaplexMetaProgramId, err)
 		}
 
-		address, err := solana.PublicKeyFromBase58(args[0])
+		address, err := resolveAddress(args[0])
 		if err != nil {
 			return fmt.Errorf("unable to decode mint addr: %w", err)
 		}
@@ -69,7 +66,7 @@ var metaplexMedatadaUpdateV1Cmd = &cobra.Command{
 			return fmt.Errorf("unable to decode data: %w", err)
 		}
 
-		updateAuthority, err := solana.PublicKeyFromBase58(args[2])
+		updateAuthority, err := resolveAddress(args[2])
 		if err != nil {
 			return fmt.Errorf("unable to decode mint addr: %w", err)
 		}
@@ -82,12 +79,12 @@ var metaplexMedatadaUpdateV1Cmd = &cobra.Command{
 			return fmt.Errorf("current update authority in metatadata %q does not match update authority passed via command line %q", metadata.UpdateAuthority.String(), updateAuthority.String())
 		}
 
-		fmt.Printf("Updating Metadata @ %q from: \n", metadataAddr.String())
+		printInfo("Updating Metadata @ %q from: \n", metadataAddr.String())
 		cnt, _ := json.MarshalIndent(metadata, "", " ")
-		fmt.Println(string(cnt))
-		fmt.Println("to:")
+		printInfo("%s\n", cnt)
+		printInfo("to:\n")
 		cnt, _ = json.MarshalIndent(newMetadata, "", " ")
-		fmt.Println(string(cnt))
+		printInfo("%s\n", cnt)
 
 		if newMetadata.Creators != nil {
 			for idx, creator := range *newMetadata.Creators {
@@ -107,23 +104,15 @@ var metaplexMedatadaUpdateV1Cmd = &cobra.Command{
 			updateAuthority,
 		)
 
-		zlog.Debug("retrieving block hash")
-		blockHashResult, err := rpcClient.GetLatestBlockhash(rpc.CommitmentFinalized)
-		if err != nil {
-			return fmt.Errorf("unable retrieve recent block hash: %w", err)
-		}
-
-		zlog.Debug("found block hash", zap.String("block_hash", blockHashResult.Value.Blockhash.String()))
-
-		trx, err := solana.NewTransaction([]solana.Instruction{
+		trx, err := newTransaction(rpcClient, []solana.Instruction{
 			updateMetadataInstruction,
-		}, blockHashResult.Value.Blockhash)
+		})
 		if err != nil {
-			return fmt.Errorf("unable to craft transaction: %w", err)
+			return err
 		}
 
-		zlog.Debug("signing metaplex transaction")
-		_, err = trx.Sign(func(key solana.PublicKey) *solana.PrivateKey {
+		zlog.Debug("signing and sending metaplex transaction")
+		trxHash, err := sendTransaction(ctx, rpcClient, wsClient, trx, func(key solana.PublicKey) *solana.PrivateKey {
 			// create account need to be signed by the private key of the new account
 			// that is not in the vault and will be lost after the execution.
 			for _, k := range vault.KeyBag {
@@ -134,20 +123,15 @@ var metaplexMedatadaUpdateV1Cmd = &cobra.Command{
 			return nil
 		})
 		if err != nil {
-			return fmt.Errorf("unable to sign transaction: %w", err)
+			return fmt.Errorf("unable to send transaction: %w", err)
 		}
 
-		zlog.Debug("sending transaction")
-
-		trxHash, err := confirm.SendAndConfirmTransaction(ctx, rpcClient, wsClient, trx)
-		if err != nil {
-			return fmt.Errorf("unable to send transaction: %w", err)
+		if trxHash == "" {
+			return nil
 		}
 
-		fmt.Printf("Metaplex Metadata Updated, with transaction hash: %s\n", trxHash)
-		fmt.Printf("  Metadata Address: %s\n", metadataAddr.String())
-		fmt.Printf("Run `slnc metaplex get %s` to view metadata", metadataAddr.String())
-		return nil
+		return printOutput(newTransactionOutput(trxHash, "Metaplex Metadata Updated, with transaction hash: %s\n  Metadata Address: %s\nRun `slnc metaplex get %s` to view metadata", trxHash, metadataAddr.String(), metadataAddr.String()).
+			withAccount("metadata", metadataAddr))
 	},
 }
 
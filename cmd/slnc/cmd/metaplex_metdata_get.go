@@ -17,7 +17,10 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"io"
+
 	"github.com/streamingfast/solana-go/rpc"
+	"go.uber.org/zap"
 
 	"github.com/spf13/viper"
 
@@ -40,7 +43,7 @@ var metaplexmetadataGetMintCmd = &cobra.Command{
 			return fmt.Errorf("unable to decode metaplex metadata programId %q: %w", metaplexMetaProgramId, err)
 		}
 
-		address, err := solana.PublicKeyFromBase58(args[0])
+		address, err := resolveAddress(args[0])
 		if err != nil {
 			return fmt.Errorf("unable to decode mint addr: %w", err)
 		}
@@ -90,52 +93,113 @@ func getMetaplexMetadata(ctx context.Context, rpcClient *rpc.Client, metaplexMet
 }
 
 func getAndDisplayMetadata(metadataAddr solana.PublicKey, metadata *metaplex.Metadata) error {
-	fmt.Println()
-	fmt.Printf("Metadata Addr: %s\n", metadataAddr.String())
-	fmt.Printf("Mint: %s\n", metadata.Mint.String())
-	fmt.Printf("Udpate Authority: %s\n", metadata.UpdateAuthority.String())
-	fmt.Printf("Primary Sale Happened: %t\n", metadata.PrimarySaleHappened)
-	fmt.Printf("Is Mutable: %t\n", metadata.IsMutable)
-	if metadata.EditionNonce != nil {
-		fmt.Printf("Edition Nonce: %d\n", *metadata.EditionNonce)
+	out := &metadataOutput{
+		Address:             metadataAddr.String(),
+		Mint:                metadata.Mint.String(),
+		UpdateAuthority:     metadata.UpdateAuthority.String(),
+		PrimarySaleHappened: metadata.PrimarySaleHappened,
+		IsMutable:           metadata.IsMutable,
+		EditionNonce:        metadata.EditionNonce,
+		Collection:          metadata.Collection,
+		Data: metadataDataOutput{
+			Name:                 metadata.Data.Name,
+			Symbol:               metadata.Data.Symbol,
+			URI:                  metadata.Data.URI,
+			SellerFeeBasisPoints: metadata.Data.SellerFeeBasisPoints,
+			Creators:             []metaplex.Creator{},
+		},
+	}
+
+	if metadata.TokenStandard != nil {
+		tokenStandard := uint8(*metadata.TokenStandard)
+		out.TokenStandard = &tokenStandard
+	}
+
+	if metadata.Data.Creators != nil {
+		out.Data.Creators = *metadata.Data.Creators
+	}
+
+	// The off-chain document is only embedded in the machine readable output,
+	// the human one prints it as it goes along with fetch errors if any.
+	if isMachineOutput() && metadata.Data.URI != "" {
+		offChain, err := fetchJSONFromURL(metadata.Data.URI)
+		if err != nil {
+			zlog.Info("unable to fetch off-chain metadata", zap.String("uri", metadata.Data.URI), zap.Error(err))
+		}
+		out.OffChain = offChain
+	}
+
+	return printOutput(out)
+}
+
+type metadataOutput struct {
+	Address             string               `json:"address"`
+	Mint                string               `json:"mint"`
+	UpdateAuthority     string               `json:"update_authority"`
+	PrimarySaleHappened bool                 `json:"primary_sale_happened"`
+	IsMutable           bool                 `json:"is_mutable"`
+	EditionNonce        *uint8               `json:"edition_nonce"`
+	TokenStandard       *uint8               `json:"token_standard"`
+	Collection          *metaplex.Collection `json:"collection"`
+	Data                metadataDataOutput   `json:"data"`
+	OffChain            interface{}          `json:"off_chain,omitempty"`
+}
+
+type metadataDataOutput struct {
+	Name                 string             `json:"name"`
+	Symbol               string             `json:"symbol"`
+	URI                  string             `json:"uri"`
+	SellerFeeBasisPoints uint16             `json:"seller_fee_basis_points"`
+	Creators             []metaplex.Creator `json:"creators"`
+}
+
+func (o *metadataOutput) Text(w io.Writer) error {
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "Metadata Addr: %s\n", o.Address)
+	fmt.Fprintf(w, "Mint: %s\n", o.Mint)
+	fmt.Fprintf(w, "Udpate Authority: %s\n", o.UpdateAuthority)
+	fmt.Fprintf(w, "Primary Sale Happened: %t\n", o.PrimarySaleHappened)
+	fmt.Fprintf(w, "Is Mutable: %t\n", o.IsMutable)
+	if o.EditionNonce != nil {
+		fmt.Fprintf(w, "Edition Nonce: %d\n", *o.EditionNonce)
 	} else {
-		fmt.Printf("No Edition Nonce\n")
+		fmt.Fprintf(w, "No Edition Nonce\n")
 	}
-	if metadata.Collection != nil {
-		fmt.Printf("Collection Key: %s\n", (*metadata.Collection).Key.String())
-		fmt.Printf("Collection verified: %t\n", (*metadata.Collection).Verified)
+	if o.Collection != nil {
+		fmt.Fprintf(w, "Collection Key: %s\n", o.Collection.Key.String())
+		fmt.Fprintf(w, "Collection verified: %t\n", o.Collection.Verified)
 	} else {
-		fmt.Printf("No Collection\n")
+		fmt.Fprintf(w, "No Collection\n")
 	}
 
-	if metadata.TokenStandard != nil {
-		fmt.Printf("Token Standard: %d\n", (*metadata.TokenStandard))
+	if o.TokenStandard != nil {
+		fmt.Fprintf(w, "Token Standard: %d\n", *o.TokenStandard)
 	} else {
-		fmt.Printf("No Token Standard\n")
+		fmt.Fprintf(w, "No Token Standard\n")
 	}
 
-	fmt.Println("Data")
-	fmt.Printf("> Name:%s \n", metadata.Data.Name)
-	fmt.Printf("> Symbol: %s\n", metadata.Data.Symbol)
-	fmt.Printf("> URI: %s\n", metadata.Data.URI)
-	fmt.Printf("> Seller Basis Points: %d\n", metadata.Data.SellerFeeBasisPoints)
-	if metadata.Data.Creators != nil && len(*metadata.Data.Creators) > 0 {
-		fmt.Printf("> %d creators\n", len(*metadata.Data.Creators))
-		for _, creator := range *metadata.Data.Creators {
+	fmt.Fprintln(w, "Data")
+	fmt.Fprintf(w, "> Name:%s \n", o.Data.Name)
+	fmt.Fprintf(w, "> Symbol: %s\n", o.Data.Symbol)
+	fmt.Fprintf(w, "> URI: %s\n", o.Data.URI)
+	fmt.Fprintf(w, "> Seller Basis Points: %d\n", o.Data.SellerFeeBasisPoints)
+	if len(o.Data.Creators) > 0 {
+		fmt.Fprintf(w, "> %d creators\n", len(o.Data.Creators))
+		for _, creator := range o.Data.Creators {
 			verified := "[ ]"
 			if creator.Verified {
 				verified = "[✅]"
 			}
 
-			fmt.Printf("> %s %d %s\n", creator.Address.String(), creator.Share, verified)
+			fmt.Fprintf(w, "> %s %d %s\n", creator.Address.String(), creator.Share, verified)
 		}
 	} else {
-		fmt.Println("> No creators found")
+		fmt.Fprintln(w, "> No creators found")
 	}
 
-	if metadata.Data.URI != "" {
-		fmt.Println()
-		fetchAndPrintJSONFromURL("Metadata", metadata.Data.URI)
+	if o.Data.URI != "" {
+		fmt.Fprintln(w)
+		fetchAndPrintJSONFromURL(w, "Metadata", o.Data.URI)
 	}
 
 	return nil
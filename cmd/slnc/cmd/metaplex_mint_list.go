@@ -2,9 +2,11 @@ package cmd
 
 import (
 	"fmt"
+	"io"
+	"strings"
+
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
-	"github.com/streamingfast/solana-go"
 	"github.com/streamingfast/solana-go/rpc"
 	"go.uber.org/zap"
 )
@@ -16,7 +18,7 @@ var metaplexMintListEditionCmd = &cobra.Command{
 	RunE: func(cmd *cobra.Command, args []string) error {
 		rpcClient := getClient(rpc.WithDebug())
 
-		masterEditionAccount, err := solana.PublicKeyFromBase58(args[0])
+		masterEditionAccount, err := resolveAddress(args[0])
 		if err != nil {
 			return fmt.Errorf("unable to decode mint addr: %w", err)
 		}
@@ -24,9 +26,7 @@ var metaplexMintListEditionCmd = &cobra.Command{
 		validAccountLenght := viper.GetUint64("metaplex-mint-list-edition-cmd-valid-account-length")
 		onlyHashes := viper.GetBool("metaplex-mint-list-edition-cmd-only-hashes")
 
-		fmt.Println("")
-		fmt.Println("Retrieving Transactions:")
-		fmt.Println("")
+		printInfo("\nRetrieving Transactions:\n\n")
 
 		limit := uint64(1000)
 		lastSignature := ""
@@ -35,6 +35,7 @@ var metaplexMintListEditionCmd = &cobra.Command{
 		failedTrxCount := 0
 		invalidTrxCount := 0
 		processedTrxCount := 0
+		out := &editionMintsOutput{onlyHashes: onlyHashes}
 
 		for hasMore {
 			signatures, err := rpcClient.GetSignaturesForAddress(masterEditionAccount, &rpc.GetSignaturesForAddressOpts{
@@ -69,25 +70,63 @@ var metaplexMintListEditionCmd = &cobra.Command{
 					continue
 				}
 				processedTrxCount++
-				if onlyHashes {
-					fmt.Printf("%s\n", transaction.Transaction.Message.AccountKeys[1].String())
-				} else {
-					fmt.Printf("%s,%s\n", trxSignature.Signature, transaction.Transaction.Message.AccountKeys[1].String())
-				}
+				out.Mints = append(out.Mints, &editionMintOutput{
+					Signature: trxSignature.Signature,
+					Mint:      transaction.Transaction.Message.AccountKeys[1].String(),
+				})
 			}
 		}
 
-		fmt.Println("")
-		fmt.Println("Summary")
-		fmt.Println("Master Edition > ", masterEditionAccount.String())
-		fmt.Println("Total TRX > ", totalCount)
-		fmt.Println("Failed TRX > ", failedTrxCount)
-		fmt.Println("Invalid TRX > ", invalidTrxCount)
-		fmt.Println("Processed TRX > ", processedTrxCount)
+		if err := printOutput(out); err != nil {
+			return err
+		}
+
+		printInfo("\nSummary\n")
+		printInfo("Master Edition >  %s\n", masterEditionAccount.String())
+		printInfo("Total TRX >  %d\n", totalCount)
+		printInfo("Failed TRX >  %d\n", failedTrxCount)
+		printInfo("Invalid TRX >  %d\n", invalidTrxCount)
+		printInfo("Processed TRX >  %d\n", processedTrxCount)
 		return nil
 	},
 }
 
+type editionMintOutput struct {
+	Signature string `json:"signature"`
+	Mint      string `json:"mint"`
+}
+
+type editionMintsOutput struct {
+	Mints []*editionMintOutput `json:"mints"`
+
+	onlyHashes bool
+}
+
+func (o *editionMintsOutput) Columns() []string {
+	if o.onlyHashes {
+		return []string{"Mint"}
+	}
+	return []string{"Signature", "Mint"}
+}
+
+func (o *editionMintsOutput) Rows() (out [][]string) {
+	for _, mint := range o.Mints {
+		if o.onlyHashes {
+			out = append(out, []string{mint.Mint})
+			continue
+		}
+		out = append(out, []string{mint.Signature, mint.Mint})
+	}
+	return
+}
+
+func (o *editionMintsOutput) Text(w io.Writer) error {
+	for _, row := range o.Rows() {
+		fmt.Fprintln(w, strings.Join(row, ","))
+	}
+	return nil
+}
+
 func init() {
 	metaplexMintCmd.AddCommand(metaplexMintListEditionCmd)
 	metaplexMintListEditionCmd.Flags().Uint64("valid-account-length", 15, "the expected account length on the mind edition transaction")
@@ -0,0 +1,78 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/streamingfast/solana-go"
+	"github.com/streamingfast/solana-go/programs/system"
+	"github.com/streamingfast/solana-go/rpc"
+)
+
+var nonceCmd = &cobra.Command{
+	Use:   "nonce",
+	Short: "System durable nonce accounts",
+	Long: `System durable nonce accounts.
+
+A durable nonce account stores a block hash that does not expire until
+the nonce is advanced. Passing --nonce-account to any command sending a
+transaction makes it use the stored nonce instead of a recent block hash,
+so the transaction can be signed offline or by multiple parties without
+expiring after about a minute.
+`,
+}
+
+func init() {
+	RootCmd.AddCommand(nonceCmd)
+}
+
+// nonceAccount is the decoded state of a System nonce account
+type nonceAccount struct {
+	Address              solana.PublicKey
+	Lamports             uint64
+	Initialized          bool
+	Authority            solana.PublicKey
+	Nonce                solana.PublicKey
+	LamportsPerSignature uint64
+}
+
+func fetchNonceAccount(rpcClient *rpc.Client, address solana.PublicKey) (*nonceAccount, error) {
+	resp, err := rpcClient.GetAccountInfo(address)
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve nonce account %q: %w", address.String(), err)
+	}
+
+	if resp.Value.Owner != system.PROGRAM_ID {
+		return nil, fmt.Errorf("account %q is not owned by the System program", address.String())
+	}
+
+	data := resp.Value.Data
+	if len(data) != nonceAccountSize {
+		return nil, fmt.Errorf("account %q is not a nonce account, expected %d bytes of data but got %d", address.String(), nonceAccountSize, len(data))
+	}
+
+	// Layout is: version (u32), state (u32), authority, nonce, lamports per signature (u64)
+	return &nonceAccount{
+		Address:              address,
+		Lamports:             uint64(resp.Value.Lamports),
+		Initialized:          binary.LittleEndian.Uint32(data[4:8]) == 1,
+		Authority:            solana.PublicKeyFromBytes(data[8:40]),
+		Nonce:                solana.PublicKeyFromBytes(data[40:72]),
+		LamportsPerSignature: binary.LittleEndian.Uint64(data[72:80]),
+	}, nil
+}
@@ -0,0 +1,98 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/streamingfast/solana-go"
+)
+
+var nonceAdvanceCmd = &cobra.Command{
+	Use:   "advance {nonce_account}",
+	Short: "Advance the nonce stored in a durable nonce account",
+	Long: `Advance the nonce stored in a durable nonce account.
+
+Advancing the nonce invalidates every transaction signed with the current
+one that was not yet sent. The nonce authority must be present in the
+vault, it signs the transaction and pays for its fee.
+`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		rpcClient := getClient()
+		wsClient, err := getWsClient(ctx)
+		if err != nil {
+			return fmt.Errorf("unable to retrieve ws client: %w", err)
+		}
+		vault := mustGetSignerWallet()
+
+		nonceAddr, err := resolveAddress(args[0])
+		if err != nil {
+			return fmt.Errorf("decoding nonce account addr: %w", err)
+		}
+
+		nonce, err := fetchNonceAccount(rpcClient, nonceAddr)
+		if err != nil {
+			return err
+		}
+
+		if !nonce.Initialized {
+			return fmt.Errorf("nonce account %q is not initialized", nonceAddr.String())
+		}
+
+		var authority *solana.PrivateKey
+		for _, privateKey := range vault.KeyBag {
+			if privateKey.PublicKey() == nonce.Authority {
+				key := privateKey
+				authority = &key
+			}
+		}
+
+		if authority == nil {
+			return fmt.Errorf("nonce authority %q must be present in the vault to sign the advance transaction", nonce.Authority.String())
+		}
+
+		trx, err := newTransaction(rpcClient, []solana.Instruction{
+			newAdvanceNonceAccountInstruction(nonceAddr, nonce.Authority),
+		})
+		if err != nil {
+			return err
+		}
+
+		printInfo("Advancing nonce account %s, current nonce is %s\n", nonceAddr.String(), nonce.Nonce.String())
+		trxHash, err := sendTransaction(ctx, rpcClient, wsClient, trx, func(key solana.PublicKey) *solana.PrivateKey {
+			if key == nonce.Authority {
+				return authority
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("unable to send transaction: %w", err)
+		}
+
+		if trxHash == "" {
+			return nil
+		}
+
+		return printOutput(newTransactionOutput(trxHash, "Nonce advanced, with transaction hash: %s\n", trxHash).
+			withAccount("nonce_account", nonceAddr))
+	},
+}
+
+func init() {
+	nonceCmd.AddCommand(nonceAdvanceCmd)
+}
@@ -0,0 +1,141 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/streamingfast/solana-go"
+	"github.com/streamingfast/solana-go/programs/system"
+	"go.uber.org/zap"
+)
+
+var nonceCreateCmd = &cobra.Command{
+	Use:   "create {funder}",
+	Short: "Create and initialize a new durable nonce account",
+	Long: `Create and initialize a new durable nonce account.
+
+The {funder} account must be present in the vault, it pays for the rent
+exemption of the nonce account and for the transaction fee. The nonce
+authority defaults to {funder}, use --authority to set another one.
+
+The address of the nonce account is generated randomly, its private key
+signs the creation and is discarded afterwards, so the transaction can't be
+written unsigned with --dump-unsigned.
+`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		if viper.GetBool("global-dump-unsigned") {
+			return fmt.Errorf("creating a nonce account requires its new keypair to sign, it cannot be combined with --dump-unsigned")
+		}
+
+		rpcClient := getClient()
+		wsClient, err := getWsClient(ctx)
+		if err != nil {
+			return fmt.Errorf("unable to retrieve ws client: %w", err)
+		}
+		vault := mustGetSignerWallet()
+
+		funderAddr, err := resolveAddress(args[0])
+		if err != nil {
+			return fmt.Errorf("decoding funder addr: %w", err)
+		}
+
+		authorityAddr := funderAddr
+		if authority := viper.GetString("nonce-create-cmd-authority"); authority != "" {
+			authorityAddr, err = resolveAddress(authority)
+			if err != nil {
+				return fmt.Errorf("decoding authority addr: %w", err)
+			}
+		}
+
+		var lamports uint64
+		if amount := viper.GetString("nonce-create-cmd-lamports"); amount != "" {
+			lamports, err = strconv.ParseUint(amount, 10, 64)
+			if err != nil {
+				return fmt.Errorf("unable to parse lamports %q: %w", amount, err)
+			}
+		} else {
+			rentExemption, err := rpcClient.GetMinimumBalanceForRentExemption(nonceAccountSize)
+			if err != nil {
+				return fmt.Errorf("unable to retrieve rent exemption amount: %w", err)
+			}
+			lamports = uint64(rentExemption)
+		}
+
+		var funder *solana.PrivateKey
+		for _, privateKey := range vault.KeyBag {
+			if privateKey.PublicKey() == funderAddr {
+				key := privateKey
+				funder = &key
+			}
+		}
+
+		if funder == nil {
+			return fmt.Errorf("funder account %q must be present in the vault to sign the create transaction", funderAddr.String())
+		}
+
+		nonceAccount := solana.NewAccount()
+		nonceAddr := nonceAccount.PublicKey()
+
+		trx, err := newTransaction(rpcClient, []solana.Instruction{
+			system.NewCreateAccountInstruction(lamports, nonceAccountSize, system.PROGRAM_ID, funderAddr, nonceAddr),
+			newInitializeNonceAccountInstruction(nonceAddr, authorityAddr),
+		})
+		if err != nil {
+			return err
+		}
+
+		zlog.Debug("signing create nonce account transaction",
+			zap.Stringer("funder", funderAddr),
+			zap.Stringer("nonce_account", nonceAddr),
+			zap.Stringer("authority", authorityAddr),
+			zap.Uint64("lamports", lamports),
+		)
+
+		printInfo("Creating nonce account %s funded with %s, authority is %s\n", nonceAddr.String(), formatLamports(lamports), authorityAddr.String())
+		trxHash, err := sendTransaction(ctx, rpcClient, wsClient, trx, func(key solana.PublicKey) *solana.PrivateKey {
+			switch key {
+			case funderAddr:
+				return funder
+			case nonceAddr:
+				return &nonceAccount.PrivateKey
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("unable to send transaction: %w", err)
+		}
+
+		if trxHash == "" {
+			return nil
+		}
+
+		return printOutput(newTransactionOutput(trxHash, "Nonce account %s created, with transaction hash: %s\n", nonceAddr.String(), trxHash).
+			withAccount("nonce_account", nonceAddr).
+			withAccount("authority", authorityAddr))
+	},
+}
+
+func init() {
+	nonceCmd.AddCommand(nonceCreateCmd)
+
+	nonceCreateCmd.Flags().String("authority", "", "Authority of the nonce account, allowed to advance and withdraw from it (defaults to {funder})")
+	nonceCreateCmd.Flags().String("lamports", "", "Amount of lamports to fund the nonce account with (defaults to the rent exemption amount)")
+}
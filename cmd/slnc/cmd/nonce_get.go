@@ -0,0 +1,87 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/ryanuber/columnize"
+	"github.com/spf13/cobra"
+)
+
+var nonceGetCmd = &cobra.Command{
+	Use:   "get {nonce_account}",
+	Short: "Retrieve the state of a durable nonce account",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		rpcClient := getClient()
+
+		nonceAddr, err := resolveAddress(args[0])
+		if err != nil {
+			return fmt.Errorf("decoding nonce account addr: %w", err)
+		}
+
+		nonce, err := fetchNonceAccount(rpcClient, nonceAddr)
+		if err != nil {
+			return err
+		}
+
+		if !nonce.Initialized {
+			return fmt.Errorf("nonce account %q is not initialized", nonce.Address.String())
+		}
+
+		return printOutput(&nonceOutput{
+			Address:              nonce.Address.String(),
+			Lamports:             nonce.Lamports,
+			Authority:            nonce.Authority.String(),
+			Nonce:                nonce.Nonce.String(),
+			LamportsPerSignature: nonce.LamportsPerSignature,
+		})
+	},
+}
+
+type nonceOutput struct {
+	Address              string `json:"address"`
+	Lamports             uint64 `json:"lamports"`
+	Authority            string `json:"authority"`
+	Nonce                string `json:"nonce"`
+	LamportsPerSignature uint64 `json:"lamports_per_signature"`
+}
+
+func (o *nonceOutput) Columns() []string {
+	return []string{"Address", "Lamports", "Authority", "Nonce", "Lamports Per Signature"}
+}
+
+func (o *nonceOutput) Rows() [][]string {
+	return [][]string{{o.Address, fmt.Sprintf("%d", o.Lamports), o.Authority, o.Nonce, fmt.Sprintf("%d", o.LamportsPerSignature)}}
+}
+
+func (o *nonceOutput) Text(w io.Writer) error {
+	out := []string{
+		"Address | " + o.Address,
+		"Balance | " + formatLamports(o.Lamports),
+		"Authority | " + o.Authority,
+		"Nonce | " + o.Nonce,
+		fmt.Sprintf("Fee | %d lamports per signature", o.LamportsPerSignature),
+	}
+
+	_, err := fmt.Fprintln(w, columnize.Format(out, nil))
+	return err
+}
+
+func init() {
+	nonceCmd.AddCommand(nonceGetCmd)
+}
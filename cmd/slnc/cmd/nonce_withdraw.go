@@ -0,0 +1,134 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/streamingfast/solana-go"
+	"go.uber.org/zap"
+)
+
+var nonceWithdrawCmd = &cobra.Command{
+	Use:   "withdraw {nonce_account} {destination} {amount}",
+	Short: "Withdraw SOL from a durable nonce account",
+	Long: `Withdraw SOL from a durable nonce account.
+
+The nonce authority must be present in the vault, it signs the transaction
+and pays for its fee. The {amount} is expressed in SOL (for example 1.5),
+use --lamports to express it in lamports instead. Withdrawing the whole
+balance closes the nonce account.
+`,
+	Args: cobra.ExactArgs(3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		rpcClient := getClient()
+		wsClient, err := getWsClient(ctx)
+		if err != nil {
+			return fmt.Errorf("unable to retrieve ws client: %w", err)
+		}
+		vault := mustGetSignerWallet()
+
+		nonceAddr, err := resolveAddress(args[0])
+		if err != nil {
+			return fmt.Errorf("decoding nonce account addr: %w", err)
+		}
+
+		toAddr, err := resolveAddress(args[1])
+		if err != nil {
+			return fmt.Errorf("decoding destination addr: %w", err)
+		}
+
+		var lamports uint64
+		if viper.GetBool("nonce-withdraw-cmd-lamports") {
+			lamports, err = strconv.ParseUint(args[2], 10, 64)
+		} else {
+			lamports, err = parseSOLAmount(args[2])
+		}
+		if err != nil {
+			return fmt.Errorf("unable to parse amount %q: %w", args[2], err)
+		}
+
+		if lamports == 0 {
+			return fmt.Errorf("refusing to withdraw an amount of 0")
+		}
+
+		nonce, err := fetchNonceAccount(rpcClient, nonceAddr)
+		if err != nil {
+			return err
+		}
+
+		if lamports > nonce.Lamports {
+			return fmt.Errorf("insufficient funds in nonce account %q: balance is %s but withdraw requires %s",
+				nonceAddr.String(),
+				formatLamports(nonce.Lamports),
+				formatLamports(lamports),
+			)
+		}
+
+		var authority *solana.PrivateKey
+		for _, privateKey := range vault.KeyBag {
+			if privateKey.PublicKey() == nonce.Authority {
+				key := privateKey
+				authority = &key
+			}
+		}
+
+		if authority == nil {
+			return fmt.Errorf("nonce authority %q must be present in the vault to sign the withdraw transaction", nonce.Authority.String())
+		}
+
+		trx, err := newTransaction(rpcClient, []solana.Instruction{
+			newWithdrawNonceAccountInstruction(lamports, nonceAddr, toAddr, nonce.Authority),
+		})
+		if err != nil {
+			return err
+		}
+
+		zlog.Debug("signing withdraw nonce account transaction",
+			zap.Stringer("nonce_account", nonceAddr),
+			zap.Stringer("to_addr", toAddr),
+			zap.Uint64("lamports", lamports),
+		)
+
+		printInfo("Withdrawing %s (%d lamports) from nonce account %s to %s\n", formatLamports(lamports), lamports, nonceAddr.String(), toAddr.String())
+		trxHash, err := sendTransaction(ctx, rpcClient, wsClient, trx, func(key solana.PublicKey) *solana.PrivateKey {
+			if key == nonce.Authority {
+				return authority
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("unable to send transaction: %w", err)
+		}
+
+		if trxHash == "" {
+			return nil
+		}
+
+		return printOutput(newTransactionOutput(trxHash, "Withdraw successful, with transaction hash: %s\n", trxHash).
+			withAccount("nonce_account", nonceAddr).
+			withAccount("to", toAddr))
+	},
+}
+
+func init() {
+	nonceCmd.AddCommand(nonceWithdrawCmd)
+
+	nonceWithdrawCmd.Flags().Bool("lamports", false, "Interpret {amount} as a number of lamports instead of SOL")
+}
@@ -0,0 +1,144 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/streamingfast/solana-go"
+)
+
+// offchainSigningDomain starts every off-chain message, its leading 0xff
+// byte keeps a signed off-chain message from ever being a valid
+// transaction message.
+var offchainSigningDomain = []byte("\xffsolana offchain")
+
+const (
+	offchainHeaderVersion = 0
+
+	// offchainHeaderSize is the size of the signing domain, the version,
+	// the format and the length of the message.
+	offchainHeaderSize = 20
+
+	// offchainMaxLedgerLength is the longest message hardware wallets sign,
+	// the packet data size less the header.
+	offchainMaxLedgerLength = 1232 - offchainHeaderSize
+	offchainMaxLength       = 65535 - offchainHeaderSize
+)
+
+// The formats of an off-chain message, the restricted ones being the only
+// ones hardware wallets display.
+const (
+	offchainFormatRestrictedASCII = 0
+	offchainFormatLimitedUTF8     = 1
+	offchainFormatExtendedUTF8    = 2
+)
+
+// encodeOffchainMessage wraps `message` in version 0 of the Solana off-chain
+// message format, the format being derived from its content and length as
+// the Solana SDK does.
+func encodeOffchainMessage(message []byte) ([]byte, error) {
+	if len(message) == 0 {
+		return nil, fmt.Errorf("off-chain message is empty")
+	}
+
+	var format byte
+	switch {
+	case len(message) <= offchainMaxLedgerLength && isPrintableASCII(message):
+		format = offchainFormatRestrictedASCII
+	case len(message) <= offchainMaxLedgerLength && utf8.Valid(message):
+		format = offchainFormatLimitedUTF8
+	case len(message) <= offchainMaxLength && utf8.Valid(message):
+		format = offchainFormatExtendedUTF8
+	case !utf8.Valid(message):
+		return nil, fmt.Errorf("off-chain message is not valid UTF-8")
+	default:
+		return nil, fmt.Errorf("off-chain message is %d bytes long, at most %d bytes are supported", len(message), offchainMaxLength)
+	}
+
+	buf := new(bytes.Buffer)
+	buf.Write(offchainSigningDomain)
+	buf.WriteByte(offchainHeaderVersion)
+	buf.WriteByte(format)
+	binary.Write(buf, binary.LittleEndian, uint16(len(message)))
+	buf.Write(message)
+
+	return buf.Bytes(), nil
+}
+
+func isPrintableASCII(data []byte) bool {
+	for _, b := range data {
+		if b < 0x20 || b > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// Encodings of a signature on the command line.
+const (
+	signatureEncodingHex    = "hex"
+	signatureEncodingBase58 = "base58"
+	signatureEncodingBase64 = "base64"
+)
+
+func encodeSignature(signature solana.Signature, encoding string) (string, error) {
+	switch encoding {
+	case signatureEncodingHex:
+		return hex.EncodeToString(signature[:]), nil
+	case signatureEncodingBase58:
+		return signature.String(), nil
+	case signatureEncodingBase64:
+		return base64.StdEncoding.EncodeToString(signature[:]), nil
+	}
+	return "", fmt.Errorf("invalid signature encoding %q, valid encodings are hex, base58, base64", encoding)
+}
+
+// decodeSignature decodes a signature in `encoding`, or in whichever of
+// the encodings yields 64 bytes when `encoding` is empty. The encoded
+// lengths of a signature differ, so this is never ambiguous.
+func decodeSignature(in string, encoding string) (solana.Signature, error) {
+	if encoding == "" {
+		for _, candidate := range []string{signatureEncodingHex, signatureEncodingBase58, signatureEncodingBase64} {
+			if signature, err := decodeSignature(in, candidate); err == nil {
+				return signature, nil
+			}
+		}
+		return solana.Signature{}, fmt.Errorf("invalid signature %q, expected 64 bytes encoded in hex, base58 or base64", in)
+	}
+
+	var data []byte
+	var err error
+	switch encoding {
+	case signatureEncodingHex:
+		data, err = hex.DecodeString(in)
+	case signatureEncodingBase58:
+		return solana.NewSignatureFromBase58(in)
+	case signatureEncodingBase64:
+		data, err = base64.StdEncoding.DecodeString(in)
+	default:
+		return solana.Signature{}, fmt.Errorf("invalid signature encoding %q, valid encodings are hex, base58, base64", encoding)
+	}
+	if err != nil {
+		return solana.Signature{}, fmt.Errorf("invalid %s signature: %w", encoding, err)
+	}
+
+	return solana.NewSignatureFromBytes(data)
+}
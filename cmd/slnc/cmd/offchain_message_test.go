@@ -0,0 +1,141 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"strings"
+	"testing"
+
+	"github.com/streamingfast/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The serialized messages and their hashes are the vectors of the
+// `offchain_message` tests of the Solana SDK, which `solana
+// sign-offchain-message` signs.
+func TestEncodeOffchainMessage_SolanaVectors(t *testing.T) {
+	tests := []struct {
+		name     string
+		message  string
+		expected []byte
+		hash     string
+	}{
+		{
+			name:    "ascii",
+			message: "Test Message",
+			expected: []byte{
+				255, 115, 111, 108, 97, 110, 97, 32, 111, 102, 102, 99, 104, 97, 105, 110, 0, 0, 12, 0,
+				84, 101, 115, 116, 32, 77, 101, 115, 115, 97, 103, 101,
+			},
+			hash: "HG5JydBGjtjTfD3sSn21ys5NTWPpXzmqifiGC2BVUjkD",
+		},
+		{
+			name:    "utf8",
+			message: "Тестовое сообщение",
+			expected: []byte{
+				255, 115, 111, 108, 97, 110, 97, 32, 111, 102, 102, 99, 104, 97, 105, 110, 0, 1, 35, 0,
+				208, 162, 208, 181, 209, 129, 209, 130, 208, 190, 208, 178, 208, 190, 208, 181, 32, 209,
+				129, 208, 190, 208, 190, 208, 177, 209, 137, 208, 181, 208, 189, 208, 184, 208, 181,
+			},
+			hash: "6GXTveatZQLexkX4WeTpJ3E7uk1UojRXpKp43c4ArSun",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			encoded, err := encodeOffchainMessage([]byte(test.message))
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, encoded)
+			assert.Equal(t, test.hash, solana.PublicKey(sha256.Sum256(encoded)).String())
+		})
+	}
+}
+
+func TestEncodeOffchainMessage_Formats(t *testing.T) {
+	tests := []struct {
+		name          string
+		message       []byte
+		expected      byte
+		expectedError string
+	}{
+		{"printable ascii", []byte("Hello, World!"), offchainFormatRestrictedASCII, ""},
+		{"ascii at ledger limit", bytes.Repeat([]byte("a"), offchainMaxLedgerLength), offchainFormatRestrictedASCII, ""},
+		{"ascii control character", []byte("Hello,\nWorld!"), offchainFormatLimitedUTF8, ""},
+		{"utf8", []byte("Héllo"), offchainFormatLimitedUTF8, ""},
+		{"utf8 at ledger limit", append([]byte("é"), bytes.Repeat([]byte("a"), offchainMaxLedgerLength-2)...), offchainFormatLimitedUTF8, ""},
+		{"ascii over ledger limit", bytes.Repeat([]byte("a"), offchainMaxLedgerLength+1), offchainFormatExtendedUTF8, ""},
+		{"at max length", bytes.Repeat([]byte("a"), offchainMaxLength), offchainFormatExtendedUTF8, ""},
+		{"over max length", bytes.Repeat([]byte("a"), offchainMaxLength+1), 0, "off-chain message is 65516 bytes long, at most 65515 bytes are supported"},
+		{"empty", nil, 0, "off-chain message is empty"},
+		{"invalid utf8", []byte{'a', 0xff, 'b'}, 0, "off-chain message is not valid UTF-8"},
+		{"invalid utf8 over ledger limit", append(bytes.Repeat([]byte("a"), offchainMaxLedgerLength), 0xff), 0, "off-chain message is not valid UTF-8"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			encoded, err := encodeOffchainMessage(test.message)
+			if test.expectedError != "" {
+				assert.EqualError(t, err, test.expectedError)
+				return
+			}
+			require.NoError(t, err)
+
+			require.Len(t, encoded, offchainHeaderSize+len(test.message))
+			assert.Equal(t, offchainSigningDomain, encoded[:16])
+			assert.Equal(t, byte(offchainHeaderVersion), encoded[16])
+			assert.Equal(t, test.expected, encoded[17])
+			assert.Equal(t, []byte{byte(len(test.message)), byte(len(test.message) >> 8)}, encoded[18:20])
+			assert.Equal(t, test.message, encoded[offchainHeaderSize:])
+		})
+	}
+}
+
+func TestDecodeSignature_RoundTrip(t *testing.T) {
+	pub, priv, err := solana.NewRandomPrivateKey()
+	require.NoError(t, err)
+
+	message, err := encodeOffchainMessage([]byte("Test Message"))
+	require.NoError(t, err)
+	signature, err := priv.Sign(message)
+	require.NoError(t, err)
+
+	for _, encoding := range []string{signatureEncodingHex, signatureEncodingBase58, signatureEncodingBase64} {
+		t.Run(encoding, func(t *testing.T) {
+			encoded, err := encodeSignature(signature, encoding)
+			require.NoError(t, err)
+
+			decoded, err := decodeSignature(encoded, encoding)
+			require.NoError(t, err)
+			assert.Equal(t, signature, decoded)
+			assert.True(t, decoded.Verify(pub, message))
+
+			detected, err := decodeSignature(encoded, "")
+			require.NoError(t, err)
+			assert.Equal(t, signature, detected)
+		})
+	}
+
+	_, err = decodeSignature(strings.Repeat("0", 126), signatureEncodingHex)
+	assert.Error(t, err, "63 bytes")
+
+	_, err = decodeSignature("not a signature", "")
+	assert.EqualError(t, err, `invalid signature "not a signature", expected 64 bytes encoded in hex, base58 or base64`)
+
+	_, err = encodeSignature(signature, "base32")
+	assert.EqualError(t, err, `invalid signature encoding "base32", valid encodings are hex, base58, base64`)
+}
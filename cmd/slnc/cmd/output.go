@@ -0,0 +1,190 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ryanuber/columnize"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	outputFormatTable = "table"
+	outputFormatJSON  = "json"
+	outputFormatYAML  = "yaml"
+	outputFormatCSV   = "csv"
+)
+
+var outputFormats = []string{outputFormatTable, outputFormatJSON, outputFormatYAML, outputFormatCSV}
+
+// tabular is implemented by command outputs that can be laid out as rows,
+// it's used by the `csv` format and by the `table` one unless the output
+// is also a `texter`.
+type tabular interface {
+	Columns() []string
+	Rows() [][]string
+}
+
+// texter is implemented by command outputs having a human readable form
+// other than a plain table, it's used by the `table` format.
+type texter interface {
+	Text(w io.Writer) error
+}
+
+func outputFormat() string {
+	return strings.ToLower(viper.GetString("global-output"))
+}
+
+func validateOutputFormat() error {
+	format := outputFormat()
+	for _, candidate := range outputFormats {
+		if format == candidate {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("invalid output format %q, valid formats are %s", format, strings.Join(outputFormats, ", "))
+}
+
+// isMachineOutput returns true when the output is meant to be parsed, in
+// which case informative messages go to standard error.
+func isMachineOutput() bool {
+	return outputFormat() != outputFormatTable
+}
+
+// printInfo prints an informative message, like the progress of an
+// operation, that is not part of the command output itself.
+func printInfo(format string, args ...interface{}) {
+	out := os.Stdout
+	if isMachineOutput() {
+		out = os.Stderr
+	}
+
+	fmt.Fprintf(out, format, args...)
+}
+
+// printOutput renders `v` to standard output in the format selected by
+// `--output`. The `json` and `yaml` formats use the `json` struct tags of
+// `v`, those are the stable schema scripts can rely on.
+func printOutput(v interface{}) error {
+	return writeOutput(os.Stdout, v)
+}
+
+func writeOutput(w io.Writer, v interface{}) error {
+	switch outputFormat() {
+	case outputFormatJSON:
+		cnt, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return fmt.Errorf("unable to encode output: %w", err)
+		}
+
+		_, err = fmt.Fprintln(w, string(cnt))
+		return err
+
+	case outputFormatYAML:
+		// Going through JSON so that YAML keys follow the `json` struct tags
+		cnt, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("unable to encode output: %w", err)
+		}
+
+		var generic interface{}
+		if err := yaml.Unmarshal(cnt, &generic); err != nil {
+			return fmt.Errorf("unable to encode output: %w", err)
+		}
+
+		out, err := yaml.Marshal(generic)
+		if err != nil {
+			return fmt.Errorf("unable to encode output: %w", err)
+		}
+
+		_, err = w.Write(out)
+		return err
+
+	case outputFormatCSV:
+		table, ok := v.(tabular)
+		if !ok {
+			return fmt.Errorf("output format %q is not supported by this command, use %q or %q", outputFormatCSV, outputFormatJSON, outputFormatYAML)
+		}
+
+		writer := csv.NewWriter(w)
+		if err := writer.Write(table.Columns()); err != nil {
+			return err
+		}
+		if err := writer.WriteAll(table.Rows()); err != nil {
+			return err
+		}
+		return nil
+	}
+
+	if text, ok := v.(texter); ok {
+		return text.Text(w)
+	}
+
+	if table, ok := v.(tabular); ok {
+		lines := []string{strings.Join(table.Columns(), " | ")}
+		for _, row := range table.Rows() {
+			lines = append(lines, strings.Join(row, " | "))
+		}
+
+		_, err := fmt.Fprintln(w, columnize.Format(lines, nil))
+		return err
+	}
+
+	cnt, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to encode output: %w", err)
+	}
+
+	_, err = fmt.Fprintln(w, string(cnt))
+	return err
+}
+
+// transactionOutput is the output of commands sending a transaction, the
+// `accounts` are the notable addresses involved, keyed by their role.
+type transactionOutput struct {
+	Signature string            `json:"signature"`
+	Accounts  map[string]string `json:"accounts,omitempty"`
+
+	message string
+}
+
+func newTransactionOutput(signature string, message string, args ...interface{}) *transactionOutput {
+	return &transactionOutput{
+		Signature: signature,
+		message:   fmt.Sprintf(message, args...),
+	}
+}
+
+func (o *transactionOutput) withAccount(role string, address fmt.Stringer) *transactionOutput {
+	if o.Accounts == nil {
+		o.Accounts = map[string]string{}
+	}
+
+	o.Accounts[role] = address.String()
+	return o
+}
+
+func (o *transactionOutput) Text(w io.Writer) error {
+	_, err := fmt.Fprint(w, o.message)
+	return err
+}
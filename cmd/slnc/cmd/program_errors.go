@@ -0,0 +1,172 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/viper"
+	"github.com/streamingfast/solana-go"
+	"github.com/streamingfast/solana-go/programs/system"
+	"github.com/streamingfast/solana-go/programs/token"
+)
+
+var defaultMetaplexMetaProgramID = solana.MustPublicKeyFromBase58("metaqbxxUerdq28cj1RbAWkYQm3ybzjb6a8bt518x1s")
+
+// programErrors lists the custom errors of a program, indexed by their code
+type programErrors struct {
+	program string
+	errors  []string
+}
+
+var systemProgramErrors = &programErrors{
+	program: "System",
+	errors: []string{
+		"AccountAlreadyInUse",
+		"ResultWithNegativeLamports",
+		"InvalidProgramId",
+		"InvalidAccountDataLength",
+		"MaxSeedLengthExceeded",
+		"AddressWithSeedMismatch",
+		"NonceNoRecentBlockhashes",
+		"NonceBlockhashNotExpired",
+		"NonceUnexpectedBlockhashValue",
+	},
+}
+
+var tokenProgramErrors = &programErrors{
+	program: "SPL Token",
+	errors: []string{
+		"NotRentExempt",
+		"InsufficientFunds",
+		"InvalidMint",
+		"MintMismatch",
+		"OwnerMismatch",
+		"FixedSupply",
+		"AlreadyInUse",
+		"InvalidNumberOfProvidedSigners",
+		"InvalidNumberOfRequiredSigners",
+		"UninitializedState",
+		"NativeNotSupported",
+		"NonNativeHasBalance",
+		"InvalidInstruction",
+		"InvalidState",
+		"Overflow",
+		"AuthorityTypeNotSupported",
+		"MintCannotFreeze",
+		"AccountFrozen",
+		"MintDecimalsMismatch",
+		"NonNativeNotSupported",
+	},
+}
+
+var metaplexProgramErrors = &programErrors{
+	program: "Metaplex Token Metadata",
+	errors: []string{
+		"InstructionUnpackError",
+		"InstructionPackError",
+		"NotRentExempt",
+		"AlreadyInitialized",
+		"Uninitialized",
+		"InvalidMetadataKey",
+		"InvalidEditionKey",
+		"UpdateAuthorityIncorrect",
+		"UpdateAuthorityIsNotSigner",
+		"NotMintAuthority",
+		"InvalidMintAuthority",
+		"NameTooLong",
+		"SymbolTooLong",
+		"UriTooLong",
+		"UpdateAuthorityMustBeEqualToMetadataAuthorityAndSigner",
+		"MintMismatch",
+		"EditionsMustHaveExactlyOneToken",
+	},
+}
+
+func knownProgramErrors(programID solana.PublicKey) *programErrors {
+	switch programID {
+	case system.PROGRAM_ID:
+		return systemProgramErrors
+	case token.PROGRAM_ID:
+		return tokenProgramErrors
+	case defaultMetaplexMetaProgramID:
+		return metaplexProgramErrors
+	}
+
+	if programID.String() == viper.GetString("metaplex-global-meta-program-id") {
+		return metaplexProgramErrors
+	}
+	return nil
+}
+
+// transactionProgramIDs returns the program invoked by each instruction of
+// `trx`, in order.
+func transactionProgramIDs(trx *solana.Transaction) []solana.PublicKey {
+	out := make([]solana.PublicKey, len(trx.Message.Instructions))
+	for i, instruction := range trx.Message.Instructions {
+		if int(instruction.ProgramIDIndex) < len(trx.Message.AccountKeys) {
+			out[i] = trx.Message.AccountKeys[instruction.ProgramIDIndex]
+		}
+	}
+	return out
+}
+
+// describeTransactionError turns the JSON transaction error returned by the
+// RPC node into a readable message, naming the custom program errors known
+// for the program of the failing instruction, `programIDs` being the
+// program invoked by each instruction. The message keeps the
+// `Instruction <n>: custom program error: 0x<code>` form used by the nodes.
+func describeTransactionError(raw json.RawMessage, programIDs []solana.PublicKey) string {
+	var name string
+	if err := json.Unmarshal(raw, &name); err == nil {
+		return name
+	}
+
+	var instructionError struct {
+		InstructionError []json.RawMessage `json:"InstructionError"`
+	}
+	if err := json.Unmarshal(raw, &instructionError); err != nil || len(instructionError.InstructionError) != 2 {
+		return string(raw)
+	}
+
+	var index int
+	if err := json.Unmarshal(instructionError.InstructionError[0], &index); err != nil {
+		return string(raw)
+	}
+
+	detail := instructionError.InstructionError[1]
+	if err := json.Unmarshal(detail, &name); err == nil {
+		return fmt.Sprintf("Instruction %d: %s", index, name)
+	}
+
+	var custom struct {
+		Custom *uint32 `json:"Custom"`
+	}
+	if err := json.Unmarshal(detail, &custom); err != nil || custom.Custom == nil {
+		return fmt.Sprintf("Instruction %d: %s", index, string(detail))
+	}
+
+	code := *custom.Custom
+	out := fmt.Sprintf("Instruction %d: custom program error: 0x%x", index, code)
+	if index < 0 || index >= len(programIDs) {
+		return out
+	}
+
+	if known := knownProgramErrors(programIDs[index]); known != nil && int(code) < len(known.errors) {
+		out += fmt.Sprintf(" (%s: %s)", known.program, known.errors[code])
+	}
+	return out
+}
@@ -19,7 +19,6 @@ import (
 	"strconv"
 
 	"github.com/spf13/cobra"
-	"github.com/streamingfast/solana-go"
 	"github.com/streamingfast/solana-go/rpc"
 )
 
@@ -31,7 +30,7 @@ var requestCmd = &cobra.Command{
 
 		client := getClient()
 
-		address, err := solana.PublicKeyFromBase58(args[0])
+		address, err := resolveAddress(args[0])
 		if err != nil {
 			return fmt.Errorf("invalid account address %q: %w", args[0], err)
 		}
@@ -41,13 +40,13 @@ var requestCmd = &cobra.Command{
 			return fmt.Errorf("invalid lamport value, expected a int value, got : %s", args[1])
 		}
 
-		airDrop, err := client.RequestAirdrop(&address, uint64(lamport), rpc.CommitmentProcessed)
+		airDrop, err := client.RequestAirdrop(&address, uint64(lamport), getCommitment(rpc.CommitmentProcessed))
 		if err != nil {
 			return fmt.Errorf("airdrop request failed: %w", err)
 		}
 
-		fmt.Println("Air drop succeeded, transaction hash:", airDrop)
-		return nil
+		return printOutput(newTransactionOutput(airDrop, "Air drop succeeded, transaction hash: %s\n", airDrop).
+			withAccount("recipient", address))
 	},
 }
 
@@ -52,16 +52,51 @@ var zlog, tracer = logging.ApplicationLogger("slnc", "github.com/streamingfast/s
 func init() {
 	cobra.OnInitialize(initConfig)
 
-	RootCmd.PersistentFlags().StringP("vault-file", "", "./solana-vault.json", "Wallet file that contains encrypted key material")
+	RootCmd.PersistentFlags().String("config-file", "", "Configuration file holding the profiles (defaults to 'slnc/config.yaml' in the user configuration directory, ~/.config on Linux)")
+	RootCmd.PersistentFlags().String("profile", "", "Profile of the configuration file to use instead of the current one")
+	RootCmd.PersistentFlags().StringP("vault-file", "", "./solana-vault.json", "Wallet file that contains encrypted key material, a local path or a gs://, s3://, az:// or file:// URL")
 	RootCmd.PersistentFlags().String("default-vault-key", "", "Default key to select from vault")
-	RootCmd.PersistentFlags().StringP("rpc-url", "u", defaultRPCURL, "API endpoint of solana blockchain node")
-	RootCmd.PersistentFlags().String("ws-url", defaultWSURL, "websocket API endpoint of solana blockchain node")
+	RootCmd.PersistentFlags().StringP("rpc-url", "u", defaultRPCURL, "API endpoint of solana blockchain node (or one of mainnet, devnet, testnet, localnet), a comma separated list of endpoints fails over between them and spreads reads across them")
+	RootCmd.PersistentFlags().String("ws-url", defaultWSURL, "websocket API endpoint of solana blockchain node (or one of mainnet, devnet, testnet, localnet), a comma separated list of endpoints fails over between them")
+	RootCmd.PersistentFlags().Float64("rps", 0, "Maximum number of JSON-RPC requests per second sent to the RPC endpoints (0 means no limit)")
+	RootCmd.PersistentFlags().Int("max-concurrency", 10, "Maximum number of JSON-RPC requests in flight, also the parallelism of bulk commands")
+	RootCmd.PersistentFlags().Int("max-retries", 5, "Maximum number of retries, with exponential backoff, of a JSON-RPC request rate limited or failed by the RPC endpoints")
 	RootCmd.PersistentFlags().StringSliceP("http-header", "H", []string{}, "HTTP header to add to JSON-RPC requests")
+	RootCmd.PersistentFlags().String("kms-key", "", "Key wrapping the vault data keys: the cryptoKey path with kms-gcp, the key ARN, alias or id with kms-aws, the '[mount/]name' of the key with hashicorp-transit")
+	RootCmd.PersistentFlags().String("kms-endpoint", "", "Address of the key management service, overrides the default one of kms-aws and the VAULT_ADDR of hashicorp-transit")
 	RootCmd.PersistentFlags().StringP("kms-gcp-keypath", "", "", "Path to the cryptoKeys within a keyRing on GCP")
+	RootCmd.PersistentFlags().MarkDeprecated("kms-gcp-keypath", "use --kms-key instead")
+	RootCmd.PersistentFlags().Bool("agent", false, "Sign with the keys held by the signing agent (see 'slnc agent start') instead of opening the vault")
+	RootCmd.PersistentFlags().String("agent-socket", "", "Unix socket of the signing agent (defaults to 'slnc/agent.sock' in $XDG_RUNTIME_DIR, or in a per-user directory of the temporary directory)")
+	RootCmd.PersistentFlags().String("audit-log", "", "Audit log recording every signature made with a vault key (defaults to 'audit.log' next to the configuration file)")
+	RootCmd.PersistentFlags().StringSlice("shamir-share-files", []string{}, "Share files used to open a vault of type shamir, asked interactively when not set")
+	RootCmd.PersistentFlags().String("commitment", "", "Commitment level of the queries and transaction confirmations, one of processed, confirmed, finalized (defaults to the one of each command)")
+	RootCmd.PersistentFlags().StringP("output", "o", outputFormatTable, "Output format, one of "+strings.Join(outputFormats, ", ")+", the json and yaml formats have a stable schema meant for scripts")
+	RootCmd.PersistentFlags().Bool("sign-only", false, "Sign the transaction with the vault keys available and write it as a transaction envelope instead of sending it")
+	RootCmd.PersistentFlags().Bool("dump-unsigned", false, "Write the transaction as an unsigned transaction envelope instead of signing and sending it, the vault is not opened: its keys and watch-only addresses stand for the signers")
+	RootCmd.PersistentFlags().String("transaction-file", "-", "File where the transaction envelope is written with --sign-only or --dump-unsigned, '-' for standard output")
+	RootCmd.PersistentFlags().Bool("dry-run", false, "Simulate the unsigned transaction and print its logs, compute units and balance changes instead of sending it, failing when the simulation does")
+	RootCmd.PersistentFlags().String("nonce-account", "", "Durable nonce account to use instead of a recent block hash, the nonce is advanced by the transaction")
+	RootCmd.PersistentFlags().String("nonce-authority", "", "Authority of the durable nonce account, must match the one stored in --nonce-account (defaults to the stored one)")
+	RootCmd.PersistentFlags().Uint64("compute-unit-limit", 0, "Maximum compute units the transaction can consume, a Compute Budget instruction is added when set")
+	RootCmd.PersistentFlags().Uint64("priority-fee", 0, "Priority fee of the transaction, in micro-lamports per compute unit")
+	RootCmd.PersistentFlags().Bool("auto-priority-fee", false, "Derive the priority fee from the recent prioritization fees paid for the accounts written by the transaction")
+	RootCmd.PersistentFlags().Uint64("auto-priority-fee-percentile", 75, "Percentile of the recent prioritization fees used by --auto-priority-fee")
+	RootCmd.PersistentFlags().Uint64("max-priority-fee", 0, "Upper bound of the priority fee derived by --auto-priority-fee, in micro-lamports per compute unit (0 means no bound)")
 	RootCmd.PersistentPreRunE = func(cmd *cobra.Command, _ []string) error {
 		cmd.SilenceErrors = true
 		cmd.SilenceUsage = true
-		return nil
+		runningCommand = cmd.CommandPath()
+		// The config commands must keep working with a broken profile to fix it
+		if !isConfigCommand(cmd) {
+			if err := applyProfile(); err != nil {
+				return err
+			}
+		}
+		if err := validateCommitment(); err != nil {
+			return err
+		}
+		return validateOutputFormat()
 	}
 }
 
@@ -76,7 +111,7 @@ func initConfig() {
 // Execute executes the configured RootCmd
 func Execute() {
 	if err := RootCmd.Execute(); err != nil {
-		fmt.Println(err)
+		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
 }
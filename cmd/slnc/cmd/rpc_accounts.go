@@ -0,0 +1,103 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/streamingfast/solana-go"
+	"github.com/streamingfast/solana-go/programs/token"
+	"github.com/streamingfast/solana-go/rpc"
+)
+
+// maxMultipleAccounts is the most accounts a `getMultipleAccounts` request
+// can ask for.
+const maxMultipleAccounts = 100
+
+// rpcAccount is an account as returned by the RPC methods listing accounts
+// with the `base64` encoding.
+type rpcAccount struct {
+	Lamports uint64           `json:"lamports"`
+	Owner    solana.PublicKey `json:"owner"`
+	Data     solana.Data      `json:"data"`
+}
+
+// fetchMultipleAccounts retrieves `keys` through `getMultipleAccounts`, in
+// batches of `maxMultipleAccounts`. The returned accounts are in the order
+// of `keys`, nil for the ones that don't exist.
+func fetchMultipleAccounts(rpcClient *rpc.Client, keys []solana.PublicKey) ([]*rpcAccount, error) {
+	out := make([]*rpcAccount, 0, len(keys))
+	for start := 0; start < len(keys); start += maxMultipleAccounts {
+		end := start + maxMultipleAccounts
+		if end > len(keys) {
+			end = len(keys)
+		}
+
+		addresses := make([]string, end-start)
+		for i, key := range keys[start:end] {
+			addresses[i] = key.String()
+		}
+
+		var resp struct {
+			Value []*rpcAccount `json:"value"`
+		}
+		if err := rpcClient.DoRequest(&resp, "getMultipleAccounts", addresses, map[string]interface{}{
+			"encoding":   "base64",
+			"commitment": getCommitment(rpc.CommitmentConfirmed),
+		}); err != nil {
+			return nil, fmt.Errorf("unable to retrieve accounts: %w", err)
+		}
+
+		if len(resp.Value) != len(addresses) {
+			return nil, fmt.Errorf("unable to retrieve accounts: expected %d accounts, got %d", len(addresses), len(resp.Value))
+		}
+
+		out = append(out, resp.Value...)
+	}
+	return out, nil
+}
+
+// fetchTokenAccountsByOwner retrieves the SPL token accounts owned by
+// `owner` through `getTokenAccountsByOwner`.
+func fetchTokenAccountsByOwner(rpcClient *rpc.Client, owner solana.PublicKey) ([]*token.Account, error) {
+	var resp struct {
+		Value []struct {
+			Pubkey  solana.PublicKey `json:"pubkey"`
+			Account *rpcAccount      `json:"account"`
+		} `json:"value"`
+	}
+	if err := rpcClient.DoRequest(&resp, "getTokenAccountsByOwner", owner.String(), map[string]interface{}{
+		"programId": token.PROGRAM_ID.String(),
+	}, map[string]interface{}{
+		"encoding":   "base64",
+		"commitment": getCommitment(rpc.CommitmentConfirmed),
+	}); err != nil {
+		return nil, fmt.Errorf("unable to retrieve token accounts of %s: %w", owner, err)
+	}
+
+	out := make([]*token.Account, 0, len(resp.Value))
+	for _, keyed := range resp.Value {
+		if keyed.Account == nil {
+			continue
+		}
+
+		account := &token.Account{}
+		if err := account.Decode(keyed.Pubkey, keyed.Account.Data); err != nil {
+			return nil, fmt.Errorf("unable to decode token account %s: %w", keyed.Pubkey, err)
+		}
+		out = append(out, account)
+	}
+	return out, nil
+}
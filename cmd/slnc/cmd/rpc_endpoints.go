@@ -0,0 +1,352 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// Endpoints failing are put aside for a cooldown that doubles on each
+// consecutive failure, they are health checked before being used again.
+const (
+	endpointMinCooldown  = 2 * time.Second
+	endpointMaxCooldown  = 1 * time.Minute
+	endpointProbeTimeout = 5 * time.Second
+)
+
+// splitEndpoints turns a comma separated list of endpoints, like the one
+// accepted by `--rpc-url`, into sanitized URLs.
+func splitEndpoints(in string) []string {
+	return splitEndpointsWith(in, sanitizeAPIURL)
+}
+
+// splitWSEndpoints is `splitEndpoints` for `--ws-url`, cluster monikers
+// are turned into websocket URLs.
+func splitWSEndpoints(in string) []string {
+	return splitEndpointsWith(in, sanitizeWSURL)
+}
+
+func splitEndpointsWith(in string, sanitize func(string) string) (out []string) {
+	for _, endpoint := range strings.Split(in, ",") {
+		if endpoint = sanitize(strings.TrimSpace(endpoint)); endpoint != "" {
+			out = append(out, endpoint)
+		}
+	}
+	return
+}
+
+type rpcEndpoint struct {
+	url       string
+	failures  int
+	downUntil time.Time
+}
+
+// rpcEndpointPool picks the endpoint to use for each JSON-RPC request. Reads
+// are spread round-robin across the healthy endpoints while transactions go
+// to the first healthy one, in the order the endpoints were given.
+type rpcEndpointPool struct {
+	lock      sync.Mutex
+	endpoints []*rpcEndpoint
+	next      int
+
+	probe func(endpoint string) error
+}
+
+func newRPCEndpointPool(urls []string, probe func(endpoint string) error) *rpcEndpointPool {
+	pool := &rpcEndpointPool{probe: probe}
+	for _, url := range urls {
+		pool.endpoints = append(pool.endpoints, &rpcEndpoint{url: url})
+	}
+	return pool
+}
+
+// pick returns the endpoint to use next, skipping the ones in `exclude`
+// which were already tried for the current request. Endpoints cooling down
+// are used only when no other one is available.
+func (p *rpcEndpointPool) pick(sticky bool, exclude map[*rpcEndpoint]bool) *rpcEndpoint {
+	p.lock.Lock()
+	start := 0
+	if !sticky {
+		start = p.next
+		p.next = (p.next + 1) % len(p.endpoints)
+	}
+
+	var candidates []*rpcEndpoint
+	for i := 0; i < len(p.endpoints); i++ {
+		endpoint := p.endpoints[(start+i)%len(p.endpoints)]
+		if !exclude[endpoint] {
+			candidates = append(candidates, endpoint)
+		}
+	}
+
+	var fallback *rpcEndpoint
+	now := time.Now()
+	for _, endpoint := range candidates {
+		if endpoint.failures == 0 {
+			p.lock.Unlock()
+			return endpoint
+		}
+
+		if fallback == nil || endpoint.downUntil.Before(fallback.downUntil) {
+			fallback = endpoint
+		}
+	}
+	p.lock.Unlock()
+
+	// Every remaining endpoint failed recently, the ones past their cooldown
+	// get health checked before being trusted again
+	for _, endpoint := range candidates {
+		if now.Before(p.downUntil(endpoint)) {
+			continue
+		}
+
+		if err := p.probe(endpoint.url); err != nil {
+			zlog.Debug("endpoint still unhealthy", zap.String("endpoint", endpoint.url), zap.Error(err))
+			p.markDown(endpoint)
+			continue
+		}
+
+		p.markUp(endpoint)
+		return endpoint
+	}
+
+	return fallback
+}
+
+func (p *rpcEndpointPool) downUntil(endpoint *rpcEndpoint) time.Time {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return endpoint.downUntil
+}
+
+func (p *rpcEndpointPool) markDown(endpoint *rpcEndpoint) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	cooldown := endpointMinCooldown << uint(endpoint.failures)
+	if cooldown > endpointMaxCooldown || cooldown <= 0 {
+		cooldown = endpointMaxCooldown
+	}
+
+	endpoint.failures++
+	endpoint.downUntil = time.Now().Add(cooldown)
+}
+
+func (p *rpcEndpointPool) markUp(endpoint *rpcEndpoint) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	endpoint.failures = 0
+	endpoint.downUntil = time.Time{}
+}
+
+// failoverTransport sends the JSON-RPC requests addressed to `primary` to
+// the endpoints of the pool, failing over to the next one on network
+// errors, rate limiting and server errors. Other requests go through as is.
+type failoverTransport struct {
+	primary string
+	pool    *rpcEndpointPool
+	next    http.RoundTripper
+}
+
+func newFailoverTransport(endpoints []string, next http.RoundTripper) *failoverTransport {
+	t := &failoverTransport{primary: endpoints[0], next: next}
+	t.pool = newRPCEndpointPool(endpoints, t.probe)
+	return t
+}
+
+func (t *failoverTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	if strings.TrimRight(request.URL.String(), "/") != t.primary || request.Body == nil {
+		return t.next.RoundTrip(request)
+	}
+
+	body, err := ioutil.ReadAll(request.Body)
+	request.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read request body: %w", err)
+	}
+
+	sticky := isTransactionRequest(body)
+	tried := map[*rpcEndpoint]bool{}
+	for {
+		endpoint := t.pool.pick(sticky, tried)
+		tried[endpoint] = true
+		last := len(tried) == len(t.pool.endpoints)
+
+		response, err := t.send(request, endpoint.url, body)
+		if err == nil && !isRetryableStatus(response.StatusCode) {
+			t.pool.markUp(endpoint)
+			return response, nil
+		}
+
+		t.pool.markDown(endpoint)
+		if last {
+			return response, err
+		}
+
+		if err != nil {
+			zlog.Info("endpoint failed, failing over to next one", zap.String("endpoint", endpoint.url), zap.Error(err))
+		} else {
+			zlog.Info("endpoint failed, failing over to next one", zap.String("endpoint", endpoint.url), zap.Int("status", response.StatusCode))
+			response.Body.Close()
+		}
+	}
+}
+
+func (t *failoverTransport) send(original *http.Request, endpoint string, body []byte) (*http.Response, error) {
+	request, err := http.NewRequestWithContext(original.Context(), original.Method, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	request.Header = original.Header.Clone()
+
+	return t.next.RoundTrip(request)
+}
+
+// probe calls `getHealth` on the endpoint, it's healthy only when the node
+// reports being caught up with the cluster.
+func (t *failoverTransport) probe(endpoint string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), endpointProbeTimeout)
+	defer cancel()
+
+	body := []byte(`{"jsonrpc":"2.0","id":1,"method":"getHealth"}`)
+	request, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := t.next.RoundTrip(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	var out struct {
+		Result string `json:"result"`
+		Error  *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&out); err != nil {
+		return fmt.Errorf("invalid health response (status %d): %w", response.StatusCode, err)
+	}
+
+	if out.Error != nil {
+		return fmt.Errorf("unhealthy: %s", out.Error.Message)
+	}
+	if out.Result != "ok" {
+		return fmt.Errorf("unhealthy: %q", out.Result)
+	}
+	return nil
+}
+
+func isRetryableStatus(status int) bool {
+	return status == http.StatusTooManyRequests || status >= 500
+}
+
+// isTransactionRequest returns true for requests that should not be spread
+// across endpoints, sending a transaction to the preferred endpoint keeps
+// its confirmation consistent with the reads following it.
+func isTransactionRequest(body []byte) bool {
+	var request struct {
+		Method string `json:"method"`
+	}
+	if err := json.Unmarshal(body, &request); err != nil {
+		return false
+	}
+
+	return request.Method == "sendTransaction" || request.Method == "requestAirdrop"
+}
+
+var (
+	rpcHTTPClient     *http.Client
+	rpcHTTPClientOnce sync.Once
+)
+
+// getRPCHTTPClient returns the HTTP client of the JSON-RPC requests, they go
+// through the retry policy and through a `failoverTransport` when multiple
+// endpoints are configured. It has its own transport, the one of the other
+// HTTP requests of the process is left as is.
+func getRPCHTTPClient(endpoints []string) *http.Client {
+	rpcHTTPClientOnce.Do(func() {
+		var transport http.RoundTripper = http.DefaultTransport
+		if defaultTransport, ok := transport.(*http.Transport); ok {
+			transport = defaultTransport.Clone()
+		}
+
+		if len(endpoints) > 1 {
+			zlog.Debug("installing failover transport", zap.Strings("endpoints", endpoints))
+			transport = newFailoverTransport(endpoints, transport)
+		}
+
+		rpcHTTPClient = &http.Client{
+			Transport: newRetryTransport(
+				endpoints[0],
+				transport,
+				viper.GetFloat64("global-rps"),
+				getMaxConcurrency(),
+				viper.GetInt("global-max-retries"),
+			),
+		}
+	})
+	return rpcHTTPClient
+}
+
+var routeRPCRequestsOnce sync.Once
+
+// routeRPCRequests sends the requests addressed to `endpoint` through
+// `client`. The JSON-RPC client of solana-go takes no HTTP client and always
+// sends through `http.DefaultTransport`, so it's the one wrapped, every other
+// request still goes to the original transport.
+func routeRPCRequests(endpoint string, client *http.Client) {
+	routeRPCRequestsOnce.Do(func() {
+		http.DefaultTransport = &rpcRouteTransport{endpoint: endpoint, rpc: client.Transport, next: http.DefaultTransport}
+	})
+}
+
+type rpcRouteTransport struct {
+	endpoint string
+	rpc      http.RoundTripper
+	next     http.RoundTripper
+}
+
+func (t *rpcRouteTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	if strings.TrimRight(request.URL.String(), "/") == t.endpoint {
+		return t.rpc.RoundTrip(request)
+	}
+	return t.next.RoundTrip(request)
+}
+
+// getMaxConcurrency is the maximum number of JSON-RPC requests in flight,
+// bulk commands also use it as their parallelism.
+func getMaxConcurrency() int {
+	if concurrency := viper.GetInt("global-max-concurrency"); concurrency > 0 {
+		return concurrency
+	}
+	return 1
+}
@@ -0,0 +1,197 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	retryBaseDelay = 500 * time.Millisecond
+	retryMaxDelay  = 30 * time.Second
+)
+
+// rateLimiter spaces out events so that at most `rps` of them happen per
+// second, a zero `rps` disables it.
+type rateLimiter struct {
+	lock     sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+func newRateLimiter(rps float64) *rateLimiter {
+	if rps <= 0 {
+		return &rateLimiter{}
+	}
+	return &rateLimiter{interval: time.Duration(float64(time.Second) / rps)}
+}
+
+func (l *rateLimiter) wait(ctx context.Context) error {
+	if l.interval == 0 {
+		return nil
+	}
+
+	l.lock.Lock()
+	now := time.Now()
+	at := l.next
+	if at.Before(now) {
+		at = now
+	}
+	l.next = at.Add(l.interval)
+	l.lock.Unlock()
+
+	return sleepContext(ctx, at.Sub(now))
+}
+
+func sleepContext(ctx context.Context, delay time.Duration) error {
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// retryTransport applies the client side policy to the JSON-RPC requests
+// addressed to `primary`: requests are kept under the requests per second
+// budget and the maximum concurrency, and retried with an exponential
+// backoff when the endpoint rate limits us or fails.
+type retryTransport struct {
+	primary    string
+	next       http.RoundTripper
+	limiter    *rateLimiter
+	slots      chan struct{}
+	maxRetries int
+}
+
+func newRetryTransport(primary string, next http.RoundTripper, rps float64, maxConcurrency int, maxRetries int) *retryTransport {
+	t := &retryTransport{
+		primary:    primary,
+		next:       next,
+		limiter:    newRateLimiter(rps),
+		maxRetries: maxRetries,
+	}
+
+	if maxConcurrency > 0 {
+		t.slots = make(chan struct{}, maxConcurrency)
+	}
+	return t
+}
+
+func (t *retryTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	if strings.TrimRight(request.URL.String(), "/") != t.primary || request.Body == nil {
+		return t.next.RoundTrip(request)
+	}
+
+	body, err := ioutil.ReadAll(request.Body)
+	request.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read request body: %w", err)
+	}
+
+	ctx := request.Context()
+	for attempt := 0; ; attempt++ {
+		response, err := t.send(request, body)
+		if err == nil && !isRetryableStatus(response.StatusCode) {
+			return response, nil
+		}
+
+		if attempt >= t.maxRetries {
+			return response, err
+		}
+
+		delay := retryDelay(attempt, response)
+		if err != nil {
+			zlog.Info("JSON-RPC request failed, retrying", zap.Int("attempt", attempt+1), zap.Duration("delay", delay), zap.Error(err))
+		} else {
+			zlog.Info("JSON-RPC request failed, retrying", zap.Int("attempt", attempt+1), zap.Duration("delay", delay), zap.Int("status", response.StatusCode))
+			response.Body.Close()
+		}
+
+		if err := sleepContext(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (t *retryTransport) send(original *http.Request, body []byte) (*http.Response, error) {
+	ctx := original.Context()
+	if t.slots != nil {
+		select {
+		case t.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		defer func() { <-t.slots }()
+	}
+
+	if err := t.limiter.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	request := original.Clone(ctx)
+	request.Body = ioutil.NopCloser(bytes.NewReader(body))
+	request.ContentLength = int64(len(body))
+
+	return t.next.RoundTrip(request)
+}
+
+// retryJitter is seeded per process so that concurrent invocations do not
+// retry in lockstep.
+var retryJitter = struct {
+	sync.Mutex
+	*rand.Rand
+}{Rand: rand.New(rand.NewSource(time.Now().UnixNano()))}
+
+// retryDelay is an exponential backoff with full jitter, a `Retry-After`
+// sent by the endpoint takes precedence when it's longer.
+func retryDelay(attempt int, response *http.Response) time.Duration {
+	ceiling := retryBaseDelay << uint(attempt)
+	if ceiling > retryMaxDelay || ceiling <= 0 {
+		ceiling = retryMaxDelay
+	}
+
+	retryJitter.Lock()
+	delay := time.Duration(retryJitter.Int63n(int64(ceiling)) + 1)
+	retryJitter.Unlock()
+
+	if response != nil {
+		if seconds, err := strconv.Atoi(response.Header.Get("Retry-After")); err == nil {
+			if retryAfter := time.Duration(seconds) * time.Second; retryAfter > delay && retryAfter <= retryMaxDelay {
+				delay = retryAfter
+			}
+		}
+	}
+
+	return delay
+}
@@ -17,6 +17,7 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"io"
 	"math/big"
 	"strings"
 
@@ -34,7 +35,7 @@ var serumGetMarketCmd = &cobra.Command{
 	RunE: func(cmd *cobra.Command, args []string) error {
 		ctx := cmd.Context()
 
-		marketAddr, err := solana.PublicKeyFromBase58(args[0])
+		marketAddr, err := resolveAddress(args[0])
 		if err != nil {
 			return fmt.Errorf("decoding market addr: %w", err)
 		}
@@ -56,32 +57,78 @@ var serumGetMarketCmd = &cobra.Command{
 		}
 		totalSize := new(big.Float).Add(askSize, bidSize)
 
-		output := []string{
-			"Price | Quantity | Depth",
-			"Asks",
-		}
-		output = append(output, outputOrderBook(asks, totalSize, true)...)
-		output = append(output, "------- | --------")
-		output = append(output, outputOrderBook(bids, totalSize, false)...)
-		output = append(output, "Bids")
+		return printOutput(&serumMarketBookOutput{
+			Name:         market.Name,
+			RequestQueue: market.Market.GetRequestQueue().String(),
+			EventQueue:   market.Market.GetEventQueue().String(),
+			BaseMint:     market.Market.GetBaseMint().String(),
+			BaseLotSize:  market.Market.GetBaseLotSize(),
+			QuoteMint:    market.Market.GetQuoteMint().String(),
+			QuoteLotSize: market.Market.GetQuoteLotSize(),
+			Asks:         newOrderBookLevelsOutput(asks),
+			Bids:         newOrderBookLevelsOutput(bids),
+			asks:         asks,
+			bids:         bids,
+			totalSize:    totalSize,
+		})
+	},
+}
 
-		fmt.Println(market.Name)
+type serumMarketBookOutput struct {
+	Name         string                  `json:"name"`
+	RequestQueue string                  `json:"request_queue"`
+	EventQueue   string                  `json:"event_queue"`
+	BaseMint     string                  `json:"base_mint"`
+	BaseLotSize  uint64                  `json:"base_lot_size"`
+	QuoteMint    string                  `json:"quote_mint"`
+	QuoteLotSize uint64                  `json:"quote_lot_size"`
+	Asks         []*orderBookLevelOutput `json:"asks"`
+	Bids         []*orderBookLevelOutput `json:"bids"`
+
+	asks      []*orderBookEntry
+	bids      []*orderBookEntry
+	totalSize *big.Float
+}
 
-		fmt.Println("Request RequestQueue: ", market.Market.GetRequestQueue())
-		fmt.Println("Event RequestQueue: ", market.Market.GetEventQueue())
+type orderBookLevelOutput struct {
+	Price    string `json:"price"`
+	Quantity string `json:"quantity"`
+}
 
-		fmt.Println("Base")
-		fmt.Println("base mint", market.Market.GetBaseMint().String())
-		fmt.Println("base lot size", market.Market.GetBaseLotSize())
+func newOrderBookLevelsOutput(entries []*orderBookEntry) []*orderBookLevelOutput {
+	out := []*orderBookLevelOutput{}
+	for _, entry := range entries {
+		out = append(out, &orderBookLevelOutput{Price: entry.price.String(), Quantity: entry.quantity.String()})
+	}
+	return out
+}
 
-		fmt.Println("")
-		fmt.Println("Quote")
-		fmt.Println("quote mint", market.Market.GetQuoteMint().String())
-		fmt.Println("quote lot size", market.Market.GetQuoteLotSize())
+func (o *serumMarketBookOutput) Text(w io.Writer) error {
+	output := []string{
+		"Price | Quantity | Depth",
+		"Asks",
+	}
+	output = append(output, outputOrderBook(o.asks, o.totalSize, true)...)
+	output = append(output, "------- | --------")
+	output = append(output, outputOrderBook(o.bids, o.totalSize, false)...)
+	output = append(output, "Bids")
 
-		fmt.Println(columnize.Format(output, nil))
-		return nil
-	},
+	fmt.Fprintln(w, o.Name)
+
+	fmt.Fprintln(w, "Request RequestQueue: ", o.RequestQueue)
+	fmt.Fprintln(w, "Event RequestQueue: ", o.EventQueue)
+
+	fmt.Fprintln(w, "Base")
+	fmt.Fprintln(w, "base mint", o.BaseMint)
+	fmt.Fprintln(w, "base lot size", o.BaseLotSize)
+
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Quote")
+	fmt.Fprintln(w, "quote mint", o.QuoteMint)
+	fmt.Fprintln(w, "quote lot size", o.QuoteLotSize)
+
+	fmt.Fprintln(w, columnize.Format(output, nil))
+	return nil
 }
 
 type orderBookEntry struct {
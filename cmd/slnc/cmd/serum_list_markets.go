@@ -17,7 +17,6 @@ package cmd
 import (
 	"fmt"
 
-	"github.com/ryanuber/columnize"
 	"github.com/spf13/cobra"
 	"github.com/streamingfast/solana-go/programs/serum"
 )
@@ -33,18 +32,30 @@ var serumListMarketsCmd = &cobra.Command{
 			return fmt.Errorf("unable to retrieve markets: %w", err)
 		}
 
-		out := []string{"Pairs | Market Address"}
-
-		for _, market := range markets {
-			out = append(out, fmt.Sprintf("%s | %s ", market.Name, market.Address.String()))
+		out := make(serumMarketsOutput, len(markets))
+		for i, market := range markets {
+			out[i] = &serumMarketOutput{Name: market.Name, Address: market.Address.String()}
 		}
 
-		fmt.Println(columnize.Format(out, nil))
-
-		return nil
+		return printOutput(out)
 	},
 }
 
 func init() {
 	serumListCmd.AddCommand(serumListMarketsCmd)
 }
+
+type serumMarketOutput struct {
+	Name    string `json:"name"`
+	Address string `json:"address"`
+}
+
+type serumMarketsOutput []*serumMarketOutput
+
+func (o serumMarketsOutput) Columns() []string { return []string{"Pairs", "Market Address"} }
+func (o serumMarketsOutput) Rows() (out [][]string) {
+	for _, m := range o {
+		out = append(out, []string{m.Name, m.Address})
+	}
+	return
+}
@@ -0,0 +1,201 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/streamingfast/slnc/policy"
+	"github.com/streamingfast/slnc/signer"
+	"github.com/streamingfast/solana-go"
+	"go.uber.org/zap"
+)
+
+var serveSignerCmd = &cobra.Command{
+	Use:   "signer",
+	Short: "Serve an HTTP API signing transactions with the vault keys",
+	Long: `Serve an HTTP API signing transactions with the vault keys.
+
+The vault is opened once at start, backend services then get their
+transactions signed without holding any key. Every request under /v1 must
+carry one of the tokens listed in --auth-tokens-file (one per line, empty
+lines and lines starting with # are ignored):
+
+    Authorization: Bearer <token>
+
+Endpoints:
+
+    GET  /healthz   liveness, not authenticated
+    GET  /v1/keys   the keys available for signing
+    POST /v1/sign   {"transaction": "<base64>", "signers": ["<pubkey>", ...]}
+
+The sign endpoint decodes the serialized transaction, checks it against the
+policy below, then signs it with every required signer held by the vault
+(restricted to "signers" when set). It answers with the signatures and the
+base64 encoded transaction including them. Transactions breaking the policy
+are refused with a 403 explaining why, as are the ones breaking the
+spending policy of a signing key (see 'slnc vault policy'). Every signed
+transaction is recorded in the audit log (see 'slnc vault audit').
+
+Policy:
+
+    --allowed-programs        only these programs may be invoked or receive
+                              accounts assigned by the System program, list
+                              the Compute Budget program to accept priority
+                              fees (required, unless --allow-any is passed)
+    --max-lamports            bound on the lamports moved by the System
+                              program instructions of a transaction
+    --allowed-destinations    only these accounts may receive SOL, tokens,
+                              approvals or authorities
+
+Keys and addresses accept the @label notation. Serve over TLS with
+--tls-cert and --tls-key, or keep --listen-addr on a loopback interface
+behind a TLS terminating proxy: tokens travel in clear text otherwise.
+`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tokens, err := readAuthTokens(viper.GetString("serve-signer-cmd-auth-tokens-file"))
+		if err != nil {
+			return err
+		}
+
+		rules := &policy.Rules{MaxLamports: viper.GetUint64("serve-signer-cmd-max-lamports")}
+		if rules.AllowedPrograms, err = resolveAddresses(viper.GetStringSlice("serve-signer-cmd-allowed-programs")); err != nil {
+			return fmt.Errorf("invalid --allowed-programs: %w", err)
+		}
+		if rules.AllowedDestinations, err = resolveAddresses(viper.GetStringSlice("serve-signer-cmd-allowed-destinations")); err != nil {
+			return fmt.Errorf("invalid --allowed-destinations: %w", err)
+		}
+		if len(rules.AllowedPrograms) == 0 && !viper.GetBool("serve-signer-cmd-allow-any") {
+			return fmt.Errorf("--allowed-programs is required, pass --allow-any to sign transactions invoking any program")
+		}
+
+		wallet, err := getWallet()
+		if err != nil {
+			return err
+		}
+
+		privateKeys := map[solana.PublicKey]solana.PrivateKey{}
+		var keys []*signer.Key
+		for _, privateKey := range wallet.KeyBag {
+			pub := privateKey.PublicKey()
+			privateKeys[pub] = privateKey
+
+			key := &signer.Key{PublicKey: pub}
+			if metadata := wallet.KeyMetadata(pub); metadata != nil {
+				key.Label = metadata.Label
+			}
+			keys = append(keys, key)
+		}
+
+		sign := func(pub solana.PublicKey, message []byte) (solana.Signature, error) {
+			privateKey, found := privateKeys[pub]
+			if !found {
+				return solana.Signature{}, fmt.Errorf("key %s not in vault", pub)
+			}
+			return signWithKey(privateKey, message)
+		}
+
+		server := signer.NewServer(keys, sign, rules, tokens, zlog)
+		server.SetSignersCheck(func(trx *solana.Transaction, summary *policy.Summary, signers []solana.PublicKey) error {
+			policies := signerPolicies(signers)
+			if len(policies) == 0 {
+				return nil
+			}
+			return enforceSpendingPoliciesOn(trx, summary, policies)
+		})
+		server.SetSignedHook(recordSignatures)
+
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			<-signals
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			if err := server.Shutdown(ctx); err != nil {
+				zlog.Warn("signer shutdown", zap.Error(err))
+			}
+		}()
+
+		addr := viper.GetString("serve-signer-cmd-listen-addr")
+		fmt.Fprintf(os.Stderr, "Signer serving %d keys on %q.\n", len(keys), addr)
+
+		return server.ListenAndServe(addr, viper.GetString("serve-signer-cmd-tls-cert"), viper.GetString("serve-signer-cmd-tls-key"))
+	},
+}
+
+func init() {
+	serveCmd.AddCommand(serveSignerCmd)
+
+	serveSignerCmd.Flags().String("listen-addr", "127.0.0.1:9010", "Address the HTTP API listens on")
+	serveSignerCmd.Flags().String("auth-tokens-file", "", "File listing the bearer tokens accepted by the API, one per line (required)")
+	serveSignerCmd.Flags().String("tls-cert", "", "TLS certificate file, the API is served over HTTPS when set along --tls-key")
+	serveSignerCmd.Flags().String("tls-key", "", "TLS private key file")
+	serveSignerCmd.Flags().StringSlice("allowed-programs", []string{}, "Programs the signed transactions may invoke (required, unless --allow-any)")
+	serveSignerCmd.Flags().Bool("allow-any", false, "Sign transactions invoking any program when --allowed-programs is not set")
+	serveSignerCmd.Flags().Uint64("max-lamports", 0, "Maximum lamports moved by a signed transaction, 0 means no limit")
+	serveSignerCmd.Flags().StringSlice("allowed-destinations", []string{}, "Accounts the signed transactions may move funds or authorities to, any when empty")
+}
+
+func resolveAddresses(in []string) (out []solana.PublicKey, err error) {
+	for _, address := range in {
+		key, err := resolveAddress(address)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, key)
+	}
+	return out, nil
+}
+
+func readAuthTokens(filename string) ([]string, error) {
+	if filename == "" {
+		return nil, fmt.Errorf("--auth-tokens-file is required")
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open auth tokens file: %w", err)
+	}
+	defer file.Close()
+
+	var tokens []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		tokens = append(tokens, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read auth tokens file: %w", err)
+	}
+
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("auth tokens file %q lists no token", filename)
+	}
+
+	return tokens, nil
+}
@@ -15,32 +15,127 @@
 package cmd
 
 import (
-	"encoding/hex"
 	"fmt"
+	"io"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 	"github.com/streamingfast/solana-go"
 )
 
 var signMessageCmd = &cobra.Command{
-	Use:   "sign {keyfile} {message_string}",
-	Short: "sign a message using keyfile",
-	Args:  cobra.ExactArgs(2),
+	Use:   "sign [{keyfile}] {message_string}",
+	Short: "sign a message using keyfile or a vault key",
+	Long: `Sign a message using a solana-keygen keyfile or, with --vault-key, a key
+of the vault.
+
+With --offchain, the message is wrapped in the Solana off-chain message
+format before being signed, like 'solana sign-offchain-message' does. Its
+header makes sure the signature can't be replayed as a transaction
+signature, so keys having a spending policy only sign off-chain messages.
+Signatures made with vault keys are recorded in the audit log.
+
+    slnc sign --vault-key @treasury --offchain --encoding base58 "I own this address"
+    slnc verify @treasury "I own this address" 4vJ9... --offchain
+`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if viper.GetString("sign-cmd-vault-key") != "" {
+			return cobra.ExactArgs(1)(cmd, args)
+		}
+		return cobra.ExactArgs(2)(cmd, args)
+	},
 	RunE: func(_ *cobra.Command, args []string) error {
-		priv, err := solana.PrivateKeyFromSolanaKeygenFile(args[0])
-		if err != nil {
+		encoding := viper.GetString("sign-cmd-encoding")
+		if _, err := encodeSignature(solana.Signature{}, encoding); err != nil {
 			return err
 		}
-		sig, err := priv.Sign([]byte(args[1]))
+
+		message := []byte(args[len(args)-1])
+		offchain := viper.GetBool("sign-cmd-offchain")
+		if offchain {
+			var err error
+			if message, err = encodeOffchainMessage(message); err != nil {
+				return err
+			}
+		}
+
+		var signer solana.PublicKey
+		var signature solana.Signature
+		if vaultKey := viper.GetString("sign-cmd-vault-key"); vaultKey != "" {
+			var err error
+			if signer, signature, err = signWithVaultKey(vaultKey, message, offchain); err != nil {
+				return err
+			}
+		} else {
+			priv, err := solana.PrivateKeyFromSolanaKeygenFile(args[0])
+			if err != nil {
+				return err
+			}
+			signer = priv.PublicKey()
+			if signature, err = priv.Sign(message); err != nil {
+				return err
+			}
+		}
+
+		encoded, err := encodeSignature(signature, encoding)
 		if err != nil {
 			return err
 		}
-		sigb := [64]byte(sig)
-		fmt.Println(hex.EncodeToString(sigb[:]))
-		return nil
+
+		return printOutput(&signatureOutput{Signature: encoded, PublicKey: signer.String()})
 	},
 }
 
+// signWithVaultKey signs `message` with the vault key `address`, recording
+// the signature in the audit log.
+func signWithVaultKey(address string, message []byte, offchain bool) (solana.PublicKey, solana.Signature, error) {
+	pub, err := resolveAddress(address)
+	if err != nil {
+		return pub, solana.Signature{}, fmt.Errorf("invalid vault key %q: %w", address, err)
+	}
+
+	wallet, err := getWallet()
+	if err != nil {
+		return pub, solana.Signature{}, err
+	}
+
+	privateKey, found := wallet.PrivateKey(pub)
+	if !found {
+		return pub, solana.Signature{}, fmt.Errorf("key %s not found in vault", pub)
+	}
+
+	// A raw message can be a transaction message, signing it would bypass
+	// the policy of the key.
+	if !offchain && keyPolicy(pub) != nil {
+		return pub, solana.Signature{}, fmt.Errorf("key %s has a spending policy, it only signs messages with --offchain", pub)
+	}
+
+	signature, err := signWithKey(privateKey, message)
+	if err != nil {
+		return pub, solana.Signature{}, fmt.Errorf("failed to sign with key %q: %w", pub, err)
+	}
+
+	if err := recordMessageSignature(pub, message, signature); err != nil {
+		return pub, solana.Signature{}, err
+	}
+
+	return pub, signature, nil
+}
+
+type signatureOutput struct {
+	Signature string `json:"signature"`
+	PublicKey string `json:"public_key"`
+}
+
+func (o *signatureOutput) Text(w io.Writer) error {
+	_, err := fmt.Fprintln(w, o.Signature)
+	return err
+}
+
 func init() {
 	RootCmd.AddCommand(signMessageCmd)
+
+	signMessageCmd.Flags().String("vault-key", "", "Key of the vault to sign with instead of a keyfile, an address or @label")
+	signMessageCmd.Flags().String("encoding", signatureEncodingHex, "Encoding of the signature, one of hex, base58, base64")
+	signMessageCmd.Flags().Bool("offchain", false, "Sign the message in the Solana off-chain message format")
 }
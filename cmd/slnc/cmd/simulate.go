@@ -0,0 +1,236 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/ryanuber/columnize"
+	bin "github.com/streamingfast/binary"
+	"github.com/streamingfast/solana-go"
+	"github.com/streamingfast/solana-go/rpc"
+)
+
+type simulatedAccount struct {
+	Lamports uint64 `json:"lamports"`
+}
+
+type simulationResult struct {
+	Err           json.RawMessage     `json:"err"`
+	Logs          []string            `json:"logs"`
+	Accounts      []*simulatedAccount `json:"accounts"`
+	UnitsConsumed *uint64             `json:"unitsConsumed"`
+}
+
+// simulation is the outcome of simulating a transaction along with the
+// balances of the accounts it writes to, before and after. A balance after
+// is nil when unknown: the node returns no account when the simulation
+// fails, nor for an account closed by the transaction.
+type simulation struct {
+	Result       *simulationResult
+	Accounts     []solana.PublicKey
+	PreBalances  []uint64
+	PostBalances []*uint64
+}
+
+func (s *simulation) Failed() bool {
+	return len(s.Result.Err) != 0 && string(s.Result.Err) != "null"
+}
+
+// errSimulationFailed is returned by `printSimulation` when the simulated
+// transaction failed.
+var errSimulationFailed = errors.New("transaction simulation failed")
+
+// simulateTransaction runs `trx` against the current state of the cluster
+// without committing it. Signatures are not verified so a transaction
+// missing some of them can still be simulated, `replaceBlockhash` has the
+// node use its latest block hash instead of the one of `trx`.
+func simulateTransaction(rpcClient *rpc.Client, trx *solana.Transaction, replaceBlockhash bool) (*simulation, error) {
+	var writables []solana.PublicKey
+	for _, key := range trx.Message.AccountKeys {
+		if trx.IsWritable(key) {
+			writables = append(writables, key)
+		}
+	}
+
+	addresses := make([]string, len(writables))
+	for i, key := range writables {
+		addresses[i] = key.String()
+	}
+
+	var preAccounts struct {
+		Value []*simulatedAccount `json:"value"`
+	}
+	if err := rpcClient.DoRequest(&preAccounts, "getMultipleAccounts", addresses, map[string]interface{}{
+		"encoding":   "base64",
+		"commitment": getCommitment(rpc.CommitmentConfirmed),
+	}); err != nil {
+		return nil, fmt.Errorf("unable to retrieve accounts: %w", err)
+	}
+
+	// The node expects a signature slot for each required signer, even
+	// though they are not verified.
+	unsigned := *trx
+	if required := int(trx.Message.Header.NumRequiredSignatures); len(unsigned.Signatures) != required {
+		unsigned.Signatures = make([]solana.Signature, required)
+		copy(unsigned.Signatures, trx.Signatures)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := bin.NewEncoder(buf).Encode(&unsigned); err != nil {
+		return nil, fmt.Errorf("unable to encode transaction: %w", err)
+	}
+
+	var out struct {
+		Value *simulationResult `json:"value"`
+	}
+	if err := rpcClient.DoRequest(&out, "simulateTransaction", base64.StdEncoding.EncodeToString(buf.Bytes()), map[string]interface{}{
+		"encoding":               "base64",
+		"commitment":             getCommitment(rpc.CommitmentConfirmed),
+		"sigVerify":              false,
+		"replaceRecentBlockhash": replaceBlockhash,
+		"accounts": map[string]interface{}{
+			"encoding":  "base64",
+			"addresses": addresses,
+		},
+	}); err != nil {
+		return nil, fmt.Errorf("unable to simulate transaction: %w", err)
+	}
+
+	if out.Value == nil {
+		return nil, fmt.Errorf("unable to simulate transaction: empty response")
+	}
+
+	sim := &simulation{
+		Result:       out.Value,
+		Accounts:     writables,
+		PreBalances:  accountBalances(preAccounts.Value, len(writables)),
+		PostBalances: make([]*uint64, len(writables)),
+	}
+
+	if !sim.Failed() {
+		for i, account := range out.Value.Accounts {
+			if i < len(writables) && account != nil {
+				lamports := account.Lamports
+				sim.PostBalances[i] = &lamports
+			}
+		}
+	}
+
+	return sim, nil
+}
+
+func accountBalances(accounts []*simulatedAccount, count int) []uint64 {
+	out := make([]uint64, count)
+	for i, account := range accounts {
+		if i < count && account != nil {
+			out[i] = account.Lamports
+		}
+	}
+	return out
+}
+
+// simulationOutputLock keeps the reports of concurrent simulations, like
+// the ones of `mint-edition-from-file`, from interleaving.
+var simulationOutputLock sync.Mutex
+
+func printSimulation(trx *solana.Transaction, sim *simulation) error {
+	simulationOutputLock.Lock()
+	defer simulationOutputLock.Unlock()
+
+	out := &simulationOutput{
+		Success:       !sim.Failed(),
+		UnitsConsumed: sim.Result.UnitsConsumed,
+		Balances:      []*simulationBalanceOutput{},
+		Logs:          sim.Result.Logs,
+	}
+
+	if sim.Failed() {
+		out.Error = describeTransactionError(sim.Result.Err, transactionProgramIDs(trx))
+	}
+
+	for i, account := range sim.Accounts {
+		out.Balances = append(out.Balances, &simulationBalanceOutput{
+			Account: account.String(),
+			Pre:     sim.PreBalances[i],
+			Post:    sim.PostBalances[i],
+		})
+	}
+
+	if err := printOutput(out); err != nil {
+		return err
+	}
+
+	if sim.Failed() {
+		return fmt.Errorf("%w: %s", errSimulationFailed, out.Error)
+	}
+	return nil
+}
+
+type simulationOutput struct {
+	Success       bool                       `json:"success"`
+	Error         string                     `json:"error,omitempty"`
+	UnitsConsumed *uint64                    `json:"units_consumed"`
+	Balances      []*simulationBalanceOutput `json:"balances"`
+	Logs          []string                   `json:"logs"`
+}
+
+type simulationBalanceOutput struct {
+	Account string  `json:"account"`
+	Pre     uint64  `json:"pre"`
+	Post    *uint64 `json:"post"`
+}
+
+func (o *simulationOutput) Text(w io.Writer) error {
+	if o.Success {
+		fmt.Fprintln(w, "Simulation succeeded, transaction was not sent")
+	} else {
+		fmt.Fprintf(w, "Simulation failed: %s\n", o.Error)
+	}
+
+	if o.UnitsConsumed != nil {
+		fmt.Fprintf(w, "Compute units consumed: %d\n", *o.UnitsConsumed)
+	}
+
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Balances:")
+	out := []string{"Account | Pre | Post | Delta"}
+	for _, balance := range o.Balances {
+		post, delta := "n/a", "n/a"
+		if balance.Post != nil {
+			post = formatLamports(*balance.Post)
+			delta = "+" + formatLamports(*balance.Post-balance.Pre)
+			if *balance.Post < balance.Pre {
+				delta = "-" + formatLamports(balance.Pre-*balance.Post)
+			}
+		}
+
+		out = append(out, fmt.Sprintf("%s | %s | %s | %s", balance.Account, formatLamports(balance.Pre), post, delta))
+	}
+	fmt.Fprintln(w, columnize.Format(out, nil))
+
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Logs:")
+	for _, line := range o.Logs {
+		fmt.Fprintln(w, "  "+line)
+	}
+	return nil
+}
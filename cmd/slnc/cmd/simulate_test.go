@@ -0,0 +1,98 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/streamingfast/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSimulateTransaction_PostBalances(t *testing.T) {
+	from, _, err := solana.NewRandomPrivateKey()
+	require.NoError(t, err)
+	to, _, err := solana.NewRandomPrivateKey()
+	require.NoError(t, err)
+	trx, _ := newTestTransfer(t, from, to, lamportsPerSOL, 1)
+
+	preAccounts := map[string]interface{}{
+		"context": map[string]interface{}{"slot": 1},
+		"value":   []interface{}{map[string]interface{}{"lamports": 3 * lamportsPerSOL}, map[string]interface{}{"lamports": lamportsPerSOL}},
+	}
+
+	tests := []struct {
+		name     string
+		result   map[string]interface{}
+		expected []*uint64
+		text     string
+	}{
+		{
+			name: "succeeded",
+			result: map[string]interface{}{
+				"err":      nil,
+				"accounts": []interface{}{map[string]interface{}{"lamports": 2*lamportsPerSOL - 5000}, map[string]interface{}{"lamports": 2 * lamportsPerSOL}},
+			},
+			expected: []*uint64{uint64Ptr(2*lamportsPerSOL - 5000), uint64Ptr(2 * lamportsPerSOL)},
+			text:     "-1.000005000 SOL",
+		},
+		{
+			name: "closed account",
+			result: map[string]interface{}{
+				"err":      nil,
+				"accounts": []interface{}{nil, map[string]interface{}{"lamports": 2 * lamportsPerSOL}},
+			},
+			expected: []*uint64{nil, uint64Ptr(2 * lamportsPerSOL)},
+			text:     "n/a",
+		},
+		{
+			name: "failed",
+			result: map[string]interface{}{
+				"err":      map[string]interface{}{"InstructionError": []interface{}{0, "Custom"}},
+				"accounts": nil,
+			},
+			expected: []*uint64{nil, nil},
+			text:     "n/a",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			newTestRPCServer(t, map[string]interface{}{
+				"getMultipleAccounts": preAccounts,
+				"simulateTransaction": map[string]interface{}{"context": map[string]interface{}{"slot": 1}, "value": test.result},
+			})
+
+			sim, err := simulateTransaction(getClient(), trx, true)
+			require.NoError(t, err)
+			assert.Equal(t, []uint64{3 * lamportsPerSOL, lamportsPerSOL}, sim.PreBalances)
+			assert.Equal(t, test.expected, sim.PostBalances)
+
+			out := &simulationOutput{}
+			for i, account := range sim.Accounts {
+				out.Balances = append(out.Balances, &simulationBalanceOutput{Account: account.String(), Pre: sim.PreBalances[i], Post: sim.PostBalances[i]})
+			}
+			buf := new(bytes.Buffer)
+			require.NoError(t, out.Text(buf))
+			assert.Contains(t, buf.String(), test.text)
+		})
+	}
+}
+
+func uint64Ptr(v uint64) *uint64 {
+	return &v
+}
@@ -0,0 +1,312 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/streamingfast/slnc/internal/lockfile"
+	"github.com/streamingfast/slnc/policy"
+	"github.com/streamingfast/solana-go"
+	"github.com/streamingfast/solana-go/programs/token"
+)
+
+// spendingLock serializes the check and record of the daily spending, the
+// signer service evaluating requests concurrently. Other processes are kept
+// out by the lock file of the ledger, see `lockSpendingLedger`.
+var spendingLock sync.Mutex
+
+// spendingLedger is the SOL moved by each key during the current UTC day,
+// persisted next to the configuration file to enforce the daily limits.
+type spendingLedger map[string]*keySpending
+
+type keySpending struct {
+	Day      string `json:"day"`
+	Lamports uint64 `json:"lamports"`
+
+	// Messages lists the SHA-256 of the transaction messages charged during
+	// the day, so signing one of them again is not charged twice.
+	Messages []string `json:"messages,omitempty"`
+}
+
+func spendingLedgerFile() string {
+	return filepath.Join(filepath.Dir(getConfigFile()), "spending.json")
+}
+
+func today() string {
+	return time.Now().UTC().Format("2006-01-02")
+}
+
+func readSpendingLedger() (spendingLedger, error) {
+	filename := spendingLedgerFile()
+	cnt, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return spendingLedger{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read spending ledger: %w", err)
+	}
+
+	ledger := spendingLedger{}
+	if err := json.Unmarshal(cnt, &ledger); err != nil {
+		return nil, fmt.Errorf("unable to decode spending ledger %q: %w", filename, err)
+	}
+	return ledger, nil
+}
+
+func (l spendingLedger) today(key solana.PublicKey) *keySpending {
+	if spending := l[key.String()]; spending != nil && spending.Day == today() {
+		return spending
+	}
+	return &keySpending{Day: today()}
+}
+
+func (l spendingLedger) spentToday(key solana.PublicKey) uint64 {
+	return l.today(key).Lamports
+}
+
+func (l spendingLedger) charged(key solana.PublicKey, messageHash string) bool {
+	return containsString(l.today(key).Messages, messageHash)
+}
+
+func (l spendingLedger) write() error {
+	filename := spendingLedgerFile()
+	cnt, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to encode spending ledger: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filename), 0700); err != nil {
+		return fmt.Errorf("unable to create spending ledger directory: %w", err)
+	}
+
+	tmpFile, err := ioutil.TempFile(filepath.Dir(filename), ".slnc-spending-*")
+	if err != nil {
+		return fmt.Errorf("unable to create temporary file: %w", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.Write(cnt); err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("unable to write spending ledger: %w", err)
+	}
+
+	if err := tmpFile.Close(); err != nil {
+		return fmt.Errorf("unable to write spending ledger: %w", err)
+	}
+
+	if err := os.Rename(tmpFile.Name(), filename); err != nil {
+		return fmt.Errorf("unable to write spending ledger %q: %w", filename, err)
+	}
+	return nil
+}
+
+// keyPolicy returns the spending policy of `key` in the opened vault, nil
+// when it has none.
+func keyPolicy(key solana.PublicKey) *policy.KeyPolicy {
+	openedWalletLock.Lock()
+	defer openedWalletLock.Unlock()
+
+	if openedWallet == nil {
+		return nil
+	}
+
+	if metadata := openedWallet.Metadata[key.String()]; metadata != nil && !metadata.Policy.IsEmpty() {
+		return metadata.Policy
+	}
+	return nil
+}
+
+// signerPolicies returns the spending policies of `signers`, by key.
+func signerPolicies(signers []solana.PublicKey) map[solana.PublicKey]*policy.KeyPolicy {
+	policies := map[solana.PublicKey]*policy.KeyPolicy{}
+	for _, signer := range signers {
+		if p := keyPolicy(signer); p != nil {
+			policies[signer] = p
+		}
+	}
+	return policies
+}
+
+// lockSpendingLedger takes the lock file of the ledger so concurrent
+// processes do not lose each other's spending.
+func lockSpendingLedger() (unlock func(), err error) {
+	unlock, err = lockfile.Lock(spendingLedgerFile()+".lock", 30*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("unable to lock spending ledger: %w", err)
+	}
+	return unlock, nil
+}
+
+// enforceSpendingPolicies checks the transaction against the policies of
+// the vault keys about to sign it. Once accepted, the SOL it moves counts
+// toward the daily limit of the keys, even if it's never sent: simulations
+// are not signed, so they are never charged, and signing the same message
+// again, like a retry does, is charged once.
+func enforceSpendingPolicies(trx *solana.Transaction, signers []solana.PublicKey) error {
+	policies := signerPolicies(signers)
+	if len(policies) == 0 {
+		return nil
+	}
+
+	summary, err := policy.Analyze(trx)
+	if err != nil {
+		return fmt.Errorf("unable to analyze transaction for spending policies: %w", err)
+	}
+
+	return enforceSpendingPoliciesOn(trx, summary, policies)
+}
+
+func enforceSpendingPoliciesOn(trx *solana.Transaction, summary *policy.Summary, policies map[solana.PublicKey]*policy.KeyPolicy) error {
+	message, err := encodeMessage(trx)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(message)
+	messageHash := hex.EncodeToString(sum[:])
+
+	for _, p := range policies {
+		if p.NeedsMints() {
+			if err := summary.ResolveMints(tokenAccountMint); err != nil {
+				return fmt.Errorf("spending policy: %w", err)
+			}
+			break
+		}
+	}
+
+	spendingLock.Lock()
+	defer spendingLock.Unlock()
+
+	unlock, err := lockSpendingLedger()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	ledger, err := readSpendingLedger()
+	if err != nil {
+		return err
+	}
+
+	violation := &policy.Violation{}
+	for key, p := range policies {
+		spent := ledger.spentToday(key)
+		if ledger.charged(key, messageHash) {
+			// Already counted in `spent`, it would be counted twice otherwise
+			spent -= summary.Lamports(&key)
+		}
+
+		if err := p.Check(key, summary, spent); err != nil {
+			var keyViolation *policy.Violation
+			if !errors.As(err, &keyViolation) {
+				return err
+			}
+			violation.Reasons = append(violation.Reasons, keyViolation.Reasons...)
+		}
+	}
+	if len(violation.Reasons) > 0 {
+		return violation
+	}
+
+	changed := false
+	for key, p := range policies {
+		lamports := summary.Lamports(&key)
+		if p.MaxLamportsPerDay == 0 || lamports == 0 || ledger.charged(key, messageHash) {
+			continue
+		}
+
+		spending := ledger.today(key)
+		spending.Lamports += lamports
+		spending.Messages = append(spending.Messages, messageHash)
+		ledger[key.String()] = spending
+		changed = true
+	}
+
+	if changed {
+		return ledger.write()
+	}
+	return nil
+}
+
+// enforceMessagePolicy checks `message`, about to be signed by `key`,
+// against the spending policy of the key. Off-chain messages can't be
+// mistaken for a transaction and are accepted, any other message must be a
+// transaction message, checked like `signTransaction` does.
+func enforceMessagePolicy(key solana.PublicKey, message []byte) error {
+	p := keyPolicy(key)
+	if p == nil || bytes.HasPrefix(message, offchainSigningDomain) {
+		return nil
+	}
+
+	trx, err := decodeTransactionMessage(message)
+	if err != nil {
+		return fmt.Errorf("key %s has a spending policy, it only signs transactions and off-chain messages: %w", key, err)
+	}
+
+	summary, err := policy.Analyze(trx)
+	if err != nil {
+		return fmt.Errorf("unable to analyze transaction for spending policies: %w", err)
+	}
+
+	return enforceSpendingPoliciesOn(trx, summary, map[solana.PublicKey]*policy.KeyPolicy{key: p})
+}
+
+// decodeTransactionMessage decodes `message` as the message of an unsigned
+// transaction, it must encode back to the exact same bytes.
+func decodeTransactionMessage(message []byte) (trx *solana.Transaction, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("invalid transaction message: %v", r)
+		}
+	}()
+
+	// Prefixed by an empty list of signatures
+	trx, err = solana.TransactionFromData(append([]byte{0}, message...))
+	if err != nil {
+		return nil, fmt.Errorf("invalid transaction message: %w", err)
+	}
+
+	encoded, err := encodeMessage(trx)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(encoded, message) {
+		return nil, fmt.Errorf("invalid transaction message")
+	}
+	return trx, nil
+}
+
+func tokenAccountMint(tokenAccount solana.PublicKey) (solana.PublicKey, error) {
+	acct, err := getClient().GetAccountInfo(tokenAccount)
+	if err != nil {
+		return solana.PublicKey{}, err
+	}
+
+	account := &token.Account{}
+	if err := account.Decode(tokenAccount, acct.Value.Data); err != nil {
+		return solana.PublicKey{}, fmt.Errorf("unable to decode token account: %w", err)
+	}
+	return account.Mint, nil
+}
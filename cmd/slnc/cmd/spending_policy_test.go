@@ -0,0 +1,75 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/streamingfast/slnc/policy"
+	"github.com/streamingfast/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestTransfer(t *testing.T, from, to solana.PublicKey, lamports uint64, blockhash byte) (*solana.Transaction, *policy.Summary) {
+	trx, err := solana.NewTransaction([]solana.Instruction{newSystemTransferInstruction(lamports, from, to)}, solana.PublicKey{blockhash}, solana.TransactionPayer(from))
+	require.NoError(t, err)
+
+	summary, err := policy.Analyze(trx)
+	require.NoError(t, err)
+
+	return trx, summary
+}
+
+func TestEnforceSpendingPoliciesOn_DailyLimit(t *testing.T) {
+	viper.Set("global-config-file", filepath.Join(t.TempDir(), "config.yaml"))
+	t.Cleanup(func() { viper.Set("global-config-file", "") })
+
+	from, _, err := solana.NewRandomPrivateKey()
+	require.NoError(t, err)
+	to, _, err := solana.NewRandomPrivateKey()
+	require.NoError(t, err)
+
+	policies := map[solana.PublicKey]*policy.KeyPolicy{from: {MaxLamportsPerDay: 5 * lamportsPerSOL}}
+	spent := func() uint64 {
+		ledger, err := readSpendingLedger()
+		require.NoError(t, err)
+		return ledger.spentToday(from)
+	}
+
+	first, firstSummary := newTestTransfer(t, from, to, 3*lamportsPerSOL, 1)
+	require.NoError(t, enforceSpendingPoliciesOn(first, firstSummary, policies))
+	assert.Equal(t, uint64(3*lamportsPerSOL), spent())
+
+	// Signing the same message again, like a retry, is charged once
+	require.NoError(t, enforceSpendingPoliciesOn(first, firstSummary, policies))
+	assert.Equal(t, uint64(3*lamportsPerSOL), spent())
+
+	second, secondSummary := newTestTransfer(t, from, to, 3*lamportsPerSOL, 2)
+	err = enforceSpendingPoliciesOn(second, secondSummary, policies)
+	var violation *policy.Violation
+	require.ErrorAs(t, err, &violation)
+	assert.Equal(t, uint64(3*lamportsPerSOL), spent())
+
+	third, thirdSummary := newTestTransfer(t, from, to, 2*lamportsPerSOL, 3)
+	require.NoError(t, enforceSpendingPoliciesOn(third, thirdSummary, policies))
+	assert.Equal(t, uint64(5*lamportsPerSOL), spent())
+
+	// Still accepted at the limit, its 3 SOL being already part of the 5
+	require.NoError(t, enforceSpendingPoliciesOn(first, firstSummary, policies))
+	assert.Equal(t, uint64(5*lamportsPerSOL), spent())
+}
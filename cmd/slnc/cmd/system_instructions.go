@@ -0,0 +1,136 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	bin "github.com/streamingfast/binary"
+	"github.com/streamingfast/solana-go"
+	"github.com/streamingfast/solana-go/programs/system"
+)
+
+const lamportsPerSOL = 1_000_000_000
+
+// lamportsPerSignature is the fee charged by the cluster for each signature
+// of a transaction, it's used to estimate the fee before sending.
+const lamportsPerSignature = 5000
+
+// nonceAccountSize is the size of the data of a System nonce account
+const nonceAccountSize = 80
+
+var sysvarRecentBlockHashes = solana.MustPublicKeyFromBase58("SysvarRecentB1ockHashes11111111111111111111")
+
+func newSystemTransferInstruction(lamports uint64, from, to solana.PublicKey) *system.Instruction {
+	return &system.Instruction{
+		BaseVariant: bin.BaseVariant{
+			TypeID: 2,
+			Impl: &system.Transfer{
+				Lamports: bin.Uint64(lamports),
+				Accounts: &system.TransferAccounts{
+					From: &solana.AccountMeta{PublicKey: from, IsSigner: true, IsWritable: true},
+					To:   &solana.AccountMeta{PublicKey: to, IsWritable: true},
+				},
+			},
+		},
+	}
+}
+
+// systemNonceInstruction carries the accounts of the nonce related System
+// instructions, for which `system.Instruction` does not know the accounts.
+type systemNonceInstruction struct {
+	*system.Instruction
+	accounts []*solana.AccountMeta
+}
+
+func (i *systemNonceInstruction) Accounts() []*solana.AccountMeta {
+	return i.accounts
+}
+
+func newAdvanceNonceAccountInstruction(nonceAccount, authority solana.PublicKey) *systemNonceInstruction {
+	return &systemNonceInstruction{
+		Instruction: &system.Instruction{
+			BaseVariant: bin.BaseVariant{TypeID: 4, Impl: &system.AdvanceNonceAccount{}},
+		},
+		accounts: []*solana.AccountMeta{
+			{PublicKey: nonceAccount, IsWritable: true},
+			{PublicKey: sysvarRecentBlockHashes},
+			{PublicKey: authority, IsSigner: true},
+		},
+	}
+}
+
+func newWithdrawNonceAccountInstruction(lamports uint64, nonceAccount, to, authority solana.PublicKey) *systemNonceInstruction {
+	return &systemNonceInstruction{
+		Instruction: &system.Instruction{
+			BaseVariant: bin.BaseVariant{TypeID: 5, Impl: &system.WithdrawNonceAccount{Lamports: bin.Uint64(lamports)}},
+		},
+		accounts: []*solana.AccountMeta{
+			{PublicKey: nonceAccount, IsWritable: true},
+			{PublicKey: to, IsWritable: true},
+			{PublicKey: sysvarRecentBlockHashes},
+			{PublicKey: system.SYSVAR_RENT},
+			{PublicKey: authority, IsSigner: true},
+		},
+	}
+}
+
+func newInitializeNonceAccountInstruction(nonceAccount, authority solana.PublicKey) *systemNonceInstruction {
+	return &systemNonceInstruction{
+		Instruction: &system.Instruction{
+			BaseVariant: bin.BaseVariant{TypeID: 6, Impl: &system.InitializeNonceAccount{AuthorizedAccount: authority}},
+		},
+		accounts: []*solana.AccountMeta{
+			{PublicKey: nonceAccount, IsWritable: true},
+			{PublicKey: sysvarRecentBlockHashes},
+			{PublicKey: system.SYSVAR_RENT},
+		},
+	}
+}
+
+// parseSOLAmount turns a decimal SOL amount like `1.25` into lamports, it
+// refuses amounts that have more precision than a lamport.
+func parseSOLAmount(in string) (uint64, error) {
+	in = strings.TrimSpace(in)
+	if in == "" || strings.HasPrefix(in, "-") || strings.HasPrefix(in, "+") {
+		return 0, fmt.Errorf("invalid SOL amount %q", in)
+	}
+
+	whole, fraction := in, ""
+	if idx := strings.Index(in, "."); idx >= 0 {
+		whole, fraction = in[:idx], in[idx+1:]
+	}
+
+	if len(fraction) > 9 {
+		return 0, fmt.Errorf("invalid SOL amount %q, at most 9 decimals are supported", in)
+	}
+
+	value, ok := new(big.Int).SetString(whole+fraction+strings.Repeat("0", 9-len(fraction)), 10)
+	if !ok {
+		return 0, fmt.Errorf("invalid SOL amount %q", in)
+	}
+
+	if !value.IsUint64() {
+		return 0, fmt.Errorf("SOL amount %q is too large", in)
+	}
+
+	return value.Uint64(), nil
+}
+
+func formatLamports(lamports uint64) string {
+	return fmt.Sprintf("%d.%09d SOL", lamports/lamportsPerSOL, lamports%lamportsPerSOL)
+}
@@ -15,34 +15,126 @@
 package cmd
 
 import (
-	"context"
 	"fmt"
+	"strconv"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/streamingfast/solana-go"
+	"go.uber.org/zap"
 )
 
 var systemTransferCmd = &cobra.Command{
 	Use:   "transfer {from} {to} {amount}",
 	Short: "Create and sign a native SOL token transfer",
-	Args:  cobra.ExactArgs(3),
+	Long: `Create and sign a native SOL token transfer.
+
+The {from} account must be present in the vault, it signs the transaction
+and pays for its fee. The {amount} is expressed in SOL (for example 1.5),
+use --lamports to express it in lamports instead.
+`,
+	Args: cobra.ExactArgs(3),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		client := getClient()
-		// v := mustGetWallet()
-		ctx := context.Background()
+		ctx := cmd.Context()
+		rpcClient := getClient()
+		wsClient, err := getWsClient(ctx)
+		if err != nil {
+			return fmt.Errorf("unable to retrieve ws client: %w", err)
+		}
+		vault := mustGetSignerWallet()
+
+		fromAddr, err := resolveAddress(args[0])
+		if err != nil {
+			return fmt.Errorf("decoding from addr: %w", err)
+		}
+
+		toAddr, err := resolveAddress(args[1])
+		if err != nil {
+			return fmt.Errorf("decoding to addr: %w", err)
+		}
+
+		var lamports uint64
+		if viper.GetBool("system-transfer-cmd-lamports") {
+			lamports, err = strconv.ParseUint(args[2], 10, 64)
+		} else {
+			lamports, err = parseSOLAmount(args[2])
+		}
+		if err != nil {
+			return fmt.Errorf("unable to parse amount %q: %w", args[2], err)
+		}
+
+		if lamports == 0 {
+			return fmt.Errorf("refusing to transfer an amount of 0")
+		}
+
+		var signer *solana.Account
+		for _, privateKey := range vault.KeyBag {
+			if privateKey.PublicKey() == fromAddr {
+				signer = &solana.Account{PrivateKey: privateKey}
+			}
+		}
 
-		from := args[0]
-		to := args[1]
-		amount := args[2]
+		if signer == nil {
+			return fmt.Errorf("from account %q must be present in the vault to sign the send transaction", fromAddr.String())
+		}
 
-		fmt.Println(from, to, amount)
+		trx, err := newTransaction(rpcClient, []solana.Instruction{
+			newSystemTransferInstruction(lamports, fromAddr, toAddr),
+		})
+		if err != nil {
+			return err
+		}
 
-		_ = client
-		_ = ctx
+		// The fee covers every signature, a nonce authority's included, and
+		// the priority fee
+		fee, err := estimateTransactionFee(trx)
+		if err != nil {
+			return err
+		}
 
-		return nil
+		balance, err := rpcClient.GetBalance(fromAddr, nil)
+		if err != nil {
+			return fmt.Errorf("unable to retrieve balance of %q: %w", fromAddr.String(), err)
+		}
+
+		if uint64(balance.Value) < lamports+fee {
+			return fmt.Errorf("insufficient funds in %q: balance is %s but transfer requires %s (including an estimated fee of %s)",
+				fromAddr.String(),
+				formatLamports(uint64(balance.Value)),
+				formatLamports(lamports+fee),
+				formatLamports(fee),
+			)
+		}
+
+		zlog.Debug("signing transfer transaction",
+			zap.String("from_addr", fromAddr.String()),
+			zap.String("to_addr", toAddr.String()),
+			zap.Uint64("lamports", lamports),
+		)
+
+		printInfo("Transferring %s (%d lamports) from %s to %s\n", formatLamports(lamports), lamports, fromAddr.String(), toAddr.String())
+		trxHash, err := sendTransaction(ctx, rpcClient, wsClient, trx, func(key solana.PublicKey) *solana.PrivateKey {
+			if signer.PublicKey() == key {
+				return &signer.PrivateKey
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("unable to send transaction: %w", err)
+		}
+
+		if trxHash == "" {
+			return nil
+		}
+
+		return printOutput(newTransactionOutput(trxHash, "Transfer successful, with transaction hash: %s\n", trxHash).
+			withAccount("from", fromAddr).
+			withAccount("to", toAddr))
 	},
 }
 
 func init() {
 	systemCmd.AddCommand(systemTransferCmd)
+
+	systemTransferCmd.Flags().Bool("lamports", false, "Interpret {amount} as a number of lamports instead of SOL")
 }
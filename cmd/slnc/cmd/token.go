@@ -14,7 +14,15 @@
 
 package cmd
 
-import "github.com/spf13/cobra"
+import (
+	"fmt"
+	"io"
+
+	"github.com/ryanuber/columnize"
+	"github.com/spf13/cobra"
+	"github.com/streamingfast/solana-go"
+	"github.com/streamingfast/solana-go/programs/token"
+)
 
 var tokenCmd = &cobra.Command{
 	Use:   "token",
@@ -24,3 +32,152 @@ var tokenCmd = &cobra.Command{
 func init() {
 	RootCmd.AddCommand(tokenCmd)
 }
+
+// mintOutput is the output of an SPL token mint, amounts are strings since
+// they can be over what a JSON number can hold precisely.
+type mintOutput struct {
+	Address         string  `json:"address"`
+	Initialized     bool    `json:"initialized"`
+	Supply          string  `json:"supply"`
+	Decimals        uint8   `json:"decimals"`
+	MintAuthority   *string `json:"mint_authority"`
+	FreezeAuthority *string `json:"freeze_authority"`
+
+	dataLength int
+}
+
+func newMintOutput(address solana.PublicKey, mint *token.Mint, dataLength int) *mintOutput {
+	out := &mintOutput{
+		Address:     address.String(),
+		Initialized: mint.IsInitialized,
+		Supply:      fmt.Sprintf("%d", mint.Supply),
+		Decimals:    mint.Decimals,
+		dataLength:  dataLength,
+	}
+
+	if mint.MintAuthorityOption != 0 {
+		authority := mint.MintAuthority.String()
+		out.MintAuthority = &authority
+	}
+
+	if mint.FreezeAuthorityOption != 0 {
+		authority := mint.FreezeAuthority.String()
+		out.FreezeAuthority = &authority
+	}
+
+	return out
+}
+
+func (o *mintOutput) Columns() []string { return mintsOutput{}.Columns() }
+func (o *mintOutput) Rows() [][]string  { return mintsOutput{o}.Rows() }
+
+func (o *mintOutput) Text(w io.Writer) error {
+	if !o.Initialized {
+		_, err := fmt.Fprintln(w, "Uninitialized mint. Data length", o.dataLength)
+		return err
+	}
+
+	var out []string
+
+	out = append(out, fmt.Sprintf("Supply | %s", o.Supply))
+	out = append(out, fmt.Sprintf("Decimals | %d", o.Decimals))
+
+	if o.MintAuthority != nil {
+		out = append(out, fmt.Sprintf("Token Authority | %s", *o.MintAuthority))
+	} else {
+		out = append(out, "No mint authority")
+	}
+
+	if o.FreezeAuthority != nil {
+		out = append(out, fmt.Sprintf("Freeze Authority | %s", *o.FreezeAuthority))
+	} else {
+		out = append(out, "No freeze authority")
+	}
+
+	_, err := fmt.Fprintln(w, columnize.Format(out, nil))
+	return err
+}
+
+type mintsOutput []*mintOutput
+
+func (o mintsOutput) Columns() []string {
+	return []string{"Account", "Decimals", "Supply", "Token Authority", "Freeze Authority"}
+}
+
+func (o mintsOutput) Rows() (out [][]string) {
+	for _, m := range o {
+		mintAuthority := "No mint authority"
+		if m.MintAuthority != nil {
+			mintAuthority = *m.MintAuthority
+		}
+
+		freezeAuthority := "No freeze authority"
+		if m.FreezeAuthority != nil {
+			freezeAuthority = *m.FreezeAuthority
+		}
+
+		out = append(out, []string{m.Address, fmt.Sprintf("%d", m.Decimals), m.Supply, mintAuthority, freezeAuthority})
+	}
+	return
+}
+
+type tokenAccountOutput struct {
+	Address     string `json:"address"`
+	Initialized bool   `json:"initialized"`
+	Mint        string `json:"mint"`
+	Owner       string `json:"owner"`
+	Amount      string `json:"amount"`
+
+	dataLength int
+}
+
+func newTokenAccountOutput(address solana.PublicKey, account *token.Account, dataLength int) *tokenAccountOutput {
+	return &tokenAccountOutput{
+		Address:     address.String(),
+		Initialized: account.IsInitialized,
+		Mint:        account.Mint.String(),
+		Owner:       account.Owner.String(),
+		Amount:      fmt.Sprintf("%d", account.Amount),
+		dataLength:  dataLength,
+	}
+}
+
+func (o *tokenAccountOutput) Columns() []string { return tokenAccountsOutput{}.Columns() }
+func (o *tokenAccountOutput) Rows() [][]string  { return tokenAccountsOutput{o}.Rows() }
+
+func (o *tokenAccountOutput) Text(w io.Writer) error {
+	if !o.Initialized {
+		_, err := fmt.Fprintln(w, "Uninitialized Account. Data length", o.dataLength)
+		return err
+	}
+
+	out := []string{
+		fmt.Sprintf("Amount | %s", o.Amount),
+		fmt.Sprintf("Mint | %s", o.Mint),
+		fmt.Sprintf("Owner | %s", o.Owner),
+	}
+
+	_, err := fmt.Fprintln(w, columnize.Format(out, nil))
+	return err
+}
+
+type tokenAccountsOutput []*tokenAccountOutput
+
+func newTokenAccountsOutput(accounts []*token.Account) tokenAccountsOutput {
+	out := make(tokenAccountsOutput, len(accounts))
+	for i, a := range accounts {
+		out[i] = newTokenAccountOutput(a.Key, a, 0)
+	}
+	return out
+}
+
+func (o tokenAccountsOutput) Columns() []string {
+	return []string{"Address", "Mint", "Owner", "Amount"}
+}
+
+func (o tokenAccountsOutput) Rows() (out [][]string) {
+	for _, a := range o {
+		out = append(out, []string{a.Address, a.Mint, a.Owner, a.Amount})
+	}
+	return
+}
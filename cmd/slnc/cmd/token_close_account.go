@@ -21,18 +21,18 @@ var tokenCloseAccountCmd = &cobra.Command{
 		if err != nil {
 			return fmt.Errorf("unable to setup websocket client: %w", err)
 		}
-		vault := mustGetWallet()
-		accountKey, err := solana.PublicKeyFromBase58(args[0])
+		vault := mustGetSignerWallet()
+		accountKey, err := resolveAddress(args[0])
 		if err != nil {
 			return fmt.Errorf("decoding account key: %w", err)
 		}
 
-		destinationKey, err := solana.PublicKeyFromBase58(args[1])
+		destinationKey, err := resolveAddress(args[1])
 		if err != nil {
 			return fmt.Errorf("decoding destination key: %w", err)
 		}
 
-		ownerKey, err := solana.PublicKeyFromBase58(args[2])
+		ownerKey, err := resolveAddress(args[2])
 		if err != nil {
 			return fmt.Errorf("decoding owner key: %w", err)
 		}
@@ -51,15 +51,32 @@ var tokenCloseAccountCmd = &cobra.Command{
 		if signer == nil {
 			return fmt.Errorf("spl token account owner %q must be present in the vault to sign the send transaction", ownerKey.String())
 		}
-		fmt.Printf("Closing account %s, sending remaining lamports to %s\n", accountKey.String(), destinationKey.String())
+		printInfo("Closing account %s, sending remaining lamports to %s\n", accountKey.String(), destinationKey.String())
 
-		trxHash, err := token.DoCloseAccount(ctx, rpcCli, wsCli, accountKey, destinationKey, ownerKey, signer)
+		trx, err := newTransaction(rpcCli, []solana.Instruction{
+			token.NewCloseAccount(accountKey, destinationKey, ownerKey),
+		})
+		if err != nil {
+			return err
+		}
+
+		trxHash, err := sendTransaction(ctx, rpcCli, wsCli, trx, func(key solana.PublicKey) *solana.PrivateKey {
+			if key == signer.PublicKey() {
+				return &signer.PrivateKey
+			}
+			return nil
+		})
 		if err != nil {
 			return fmt.Errorf("unable to send transaction: %w", err)
 		}
 
-		fmt.Printf("Close Account successful, with transaction hash: %s\n", trxHash)
-		return nil
+		if trxHash == "" {
+			return nil
+		}
+
+		return printOutput(newTransactionOutput(trxHash, "Close Account successful, with transaction hash: %s\n", trxHash).
+			withAccount("account", accountKey).
+			withAccount("destination", destinationKey))
 	},
 }
 
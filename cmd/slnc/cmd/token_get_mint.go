@@ -17,9 +17,7 @@ package cmd
 import (
 	"fmt"
 
-	"github.com/ryanuber/columnize"
 	"github.com/spf13/cobra"
-	"github.com/streamingfast/solana-go"
 	"github.com/streamingfast/solana-go/programs/token"
 )
 
@@ -28,7 +26,7 @@ var tokenGetMintCmd = &cobra.Command{
 	Short: "Retrieves mint information",
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		mintAddress, err := solana.PublicKeyFromBase58(args[0])
+		mintAddress, err := resolveAddress(args[0])
 		if err != nil {
 			return fmt.Errorf("decoding mint addr: %w", err)
 		}
@@ -45,31 +43,7 @@ var tokenGetMintCmd = &cobra.Command{
 			return fmt.Errorf("unable to retrieve int information: %w", err)
 		}
 
-		if !mint.IsInitialized {
-			fmt.Println("Uninitialized mint. Data length", len(acct.Value.Data))
-			return nil
-		}
-
-		var out []string
-
-		out = append(out, fmt.Sprintf("Supply | %d", mint.Supply))
-		out = append(out, fmt.Sprintf("Decimals | %d", mint.Decimals))
-
-		if mint.MintAuthorityOption != 0 {
-			out = append(out, fmt.Sprintf("Token Authority | %s", mint.MintAuthority))
-		} else {
-			out = append(out, "No mint authority")
-		}
-
-		if mint.FreezeAuthorityOption != 0 {
-			out = append(out, fmt.Sprintf("Freeze Authority | %s", mint.FreezeAuthority))
-		} else {
-			out = append(out, "No freeze authority")
-		}
-
-		fmt.Println(columnize.Format(out, nil))
-
-		return nil
+		return printOutput(newMintOutput(mintAddress, mint, len(acct.Value.Data)))
 	},
 }
 
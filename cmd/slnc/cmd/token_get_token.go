@@ -17,9 +17,7 @@ package cmd
 import (
 	"fmt"
 
-	"github.com/ryanuber/columnize"
 	"github.com/spf13/cobra"
-	"github.com/streamingfast/solana-go"
 	"github.com/streamingfast/solana-go/programs/token"
 )
 
@@ -28,7 +26,7 @@ var tokenGetAccountCmd = &cobra.Command{
 	Short: "Retrieves token information",
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		tokenAddress, err := solana.PublicKeyFromBase58(args[0])
+		tokenAddress, err := resolveAddress(args[0])
 		if err != nil {
 			return fmt.Errorf("decoding mint addr: %w", err)
 		}
@@ -45,20 +43,7 @@ var tokenGetAccountCmd = &cobra.Command{
 			return fmt.Errorf("unable to retrieve int information: %w", err)
 		}
 
-		if !account.IsInitialized {
-			fmt.Println("Uninitialized Account. Data length", len(acct.Value.Data))
-			return nil
-		}
-
-		var out []string
-
-		out = append(out, fmt.Sprintf("Amount | %d", account.Amount))
-		out = append(out, fmt.Sprintf("Mint | %s", account.Mint.String()))
-		out = append(out, fmt.Sprintf("Owner | %s", account.Owner.String()))
-
-		fmt.Println(columnize.Format(out, nil))
-
-		return nil
+		return printOutput(newTokenAccountOutput(tokenAddress, account, len(acct.Value.Data)))
 	},
 }
 
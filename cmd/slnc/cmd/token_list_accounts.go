@@ -16,14 +16,9 @@ package cmd
 
 import (
 	"fmt"
-	"strings"
 
-	"github.com/spf13/viper"
-
-	"github.com/streamingfast/solana-go"
-
-	"github.com/ryanuber/columnize"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 	"github.com/streamingfast/solana-go/programs/token"
 )
 
@@ -33,9 +28,11 @@ var tokenListAccountsCmd = &cobra.Command{
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		rpcCli := getClient()
-		toCSV := viper.GetBool("token-list-accounts-cmd-to-csv")
+		if viper.GetBool("token-list-accounts-cmd-to-csv") {
+			viper.Set("global-output", outputFormatCSV)
+		}
 
-		ownerAddr, err := solana.PublicKeyFromBase58(args[0])
+		ownerAddr, err := resolveAddress(args[0])
 		if err != nil {
 			return fmt.Errorf("decoding owner addr: %w", err)
 		}
@@ -45,35 +42,12 @@ var tokenListAccountsCmd = &cobra.Command{
 			return fmt.Errorf("unable to retrieve mints: %w", err)
 		}
 
-		if toCSV {
-			fmt.Println("address,mint,owner,amount")
-			for _, a := range accounts {
-				line := []string{
-					fmt.Sprintf("%s", a.Key.String()),
-					fmt.Sprintf("%s", a.Mint.String()),
-					fmt.Sprintf("%s", a.Owner.String()),
-					fmt.Sprintf("%d", a.Amount),
-				}
-				fmt.Println(strings.Join(line, ","))
-			}
-		} else {
-			out := []string{"Address | Mint | Owner | Amount"}
-			for _, a := range accounts {
-				line := []string{
-					fmt.Sprintf("%s", a.Key.String()),
-					fmt.Sprintf("%s", a.Mint.String()),
-					fmt.Sprintf("%s", a.Owner.String()),
-					fmt.Sprintf("%d", a.Amount),
-				}
-				out = append(out, strings.Join(line, " | "))
-			}
-			fmt.Println(columnize.Format(out, nil))
-		}
-		return nil
+		return printOutput(newTokenAccountsOutput(accounts))
 	},
 }
 
 func init() {
 	tokenListAccountsCmd.Flags().Bool("to-csv", false, "outputs the data in csv format")
+	tokenListAccountsCmd.Flags().MarkDeprecated("to-csv", "use --output csv instead")
 	tokenListCmd.AddCommand(tokenListAccountsCmd)
 }
@@ -16,11 +16,10 @@ package cmd
 
 import (
 	"fmt"
-	"strings"
 
-	"github.com/ryanuber/columnize"
 	"github.com/spf13/cobra"
 	"github.com/streamingfast/solana-go/programs/token"
+	"github.com/streamingfast/solana-go/rpc"
 )
 
 var tokenListMintsCmd = &cobra.Command{
@@ -29,35 +28,44 @@ var tokenListMintsCmd = &cobra.Command{
 	RunE: func(cmd *cobra.Command, _ []string) error {
 		rpcCli := getClient()
 
-		mints, err := token.FetchMints(rpcCli)
+		mints, err := fetchMints(rpcCli)
 		if err != nil {
 			return fmt.Errorf("unable to retrieve mints: %w", err)
 		}
-		out := []string{"Account | Decimals | Supply | Token Authority | Freeze Authority"}
-		for _, m := range mints {
-			line := []string{
-				fmt.Sprintf("%d", m.MintAuthorityOption),
-				fmt.Sprintf("%d", m.Supply),
-				fmt.Sprintf("%d", m.Decimals),
-			}
-			if m.MintAuthorityOption != 0 {
-				line = append(line, fmt.Sprintf("%s", m.MintAuthority))
-			} else {
-				line = append(line, "No mint authority")
-			}
-			if m.FreezeAuthorityOption != 0 {
-				line = append(line, fmt.Sprintf("%s", m.FreezeAuthority))
-			} else {
-				line = append(line, "No freeze authority")
-			}
-			out = append(out, strings.Join(line, " | "))
-		}
 
-		fmt.Println(columnize.Format(out, nil))
-		return nil
+		return printOutput(mints)
 	},
 }
 
 func init() {
 	tokenListCmd.AddCommand(tokenListMintsCmd)
 }
+
+// fetchMints retrieves all the mints of the SPL Token program along with
+// their address, which `token.FetchMints` does not return.
+func fetchMints(rpcCli *rpc.Client) (mintsOutput, error) {
+	resp, err := rpcCli.GetProgramAccounts(
+		token.PROGRAM_ID,
+		&rpc.GetProgramAccountsOpts{
+			Filters: []rpc.RPCFilter{
+				{
+					DataSize: token.MINT_SIZE,
+				},
+			},
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var out mintsOutput
+	for _, keyedAcct := range resp {
+		mint := &token.Mint{}
+		if err := mint.Decode(keyedAcct.Account.Data); err != nil {
+			return nil, fmt.Errorf("unable to decode mint %q: %w", keyedAcct.Pubkey.String(), err)
+		}
+
+		out = append(out, newMintOutput(keyedAcct.Pubkey, mint, len(keyedAcct.Account.Data)))
+	}
+	return out, nil
+}
@@ -3,7 +3,6 @@ package cmd
 import (
 	"fmt"
 	associatedtokenaccount "github.com/streamingfast/solana-go/programs/associated-token-account"
-	"github.com/streamingfast/solana-go/rpc/confirm"
 	"go.uber.org/zap"
 	"strconv"
 
@@ -25,13 +24,13 @@ var tokenMintToCmd = &cobra.Command{
 		if err != nil {
 			return fmt.Errorf("unable to setup websocket client: %w", err)
 		}
-		vault := mustGetWallet()
-		mintAddr, err := solana.PublicKeyFromBase58(args[0])
+		vault := mustGetSignerWallet()
+		mintAddr, err := resolveAddress(args[0])
 		if err != nil {
 			return fmt.Errorf("decoding account key: %w", err)
 		}
 
-		recipientAddr, err := solana.PublicKeyFromBase58(args[1])
+		recipientAddr, err := resolveAddress(args[1])
 		if err != nil {
 			return fmt.Errorf("decoding account key: %w", err)
 		}
@@ -85,14 +84,9 @@ var tokenMintToCmd = &cobra.Command{
 			signer.PublicKey(),
 		))
 
-		blockHashResult, err := rpcCli.GetLatestBlockhash(rpc.CommitmentFinalized)
+		trx, err := newTransaction(rpcCli, instructions)
 		if err != nil {
-			return fmt.Errorf("unable retrieve recent block hash: %w", err)
-		}
-
-		trx, err := solana.NewTransaction(instructions, blockHashResult.Value.Blockhash)
-		if err != nil {
-			return fmt.Errorf("unable to create transaction: %w", err)
+			return err
 		}
 
 		zlog.Debug("issuing whitelist token",
@@ -101,8 +95,8 @@ var tokenMintToCmd = &cobra.Command{
 			zap.String("spl_toke_account", recipientSPLTokenAccount.String()),
 		)
 
-		zlog.Debug("signing transactions")
-		_, err = trx.Sign(func(key solana.PublicKey) *solana.PrivateKey {
+		printInfo("Minting %s to %s\n", mintAddr.String(), recipientAddr.String())
+		trxHash, err := sendTransaction(ctx, rpcCli, wsCli, trx, func(key solana.PublicKey) *solana.PrivateKey {
 			// create account need to be signed by the private key of the new account
 			// that is not in the vault and will be lost after the execution.
 			if signer.PublicKey() == key {
@@ -111,16 +105,16 @@ var tokenMintToCmd = &cobra.Command{
 			return nil
 		})
 		if err != nil {
-			return fmt.Errorf("unable to sign transaction: %w", err)
+			return fmt.Errorf("unable to send transaction: %w", err)
 		}
 
-		fmt.Printf("Minting %s to %s\n", mintAddr.String(), recipientAddr.String())
-		trxHash, err := confirm.SendAndConfirmTransaction(ctx, rpcCli, wsCli, trx)
-		if err != nil {
-			return fmt.Errorf("unable to send transaction: %w", err)
+		if trxHash == "" {
+			return nil
 		}
-		fmt.Printf("Mint To successful, with transaction hash: %s\n", trxHash)
-		return nil
+
+		return printOutput(newTransactionOutput(trxHash, "Mint To successful, with transaction hash: %s\n", trxHash).
+			withAccount("mint", mintAddr).
+			withAccount("recipient", recipientAddr))
 	},
 }
 
@@ -16,12 +16,11 @@ package cmd
 
 import (
 	"fmt"
-	"os"
+	"io"
 
 	"github.com/streamingfast/solana-go/rpc"
 
 	"github.com/spf13/cobra"
-	"github.com/streamingfast/solana-go"
 	"github.com/streamingfast/solana-go/programs/tokenregistry"
 	"github.com/streamingfast/solana-go/text"
 )
@@ -34,7 +33,7 @@ var tokenRegistryGetCmd = &cobra.Command{
 		client := getClient()
 
 		address := args[0]
-		pubKey, err := solana.PublicKeyFromBase58(address)
+		pubKey, err := resolveAddress(address)
 		if err != nil {
 			return fmt.Errorf("invalid mint address %q: %w", address, err)
 		}
@@ -42,21 +41,25 @@ var tokenRegistryGetCmd = &cobra.Command{
 		t, err := tokenregistry.GetTokenRegistryEntry(client, pubKey)
 		if err != nil {
 			if err == rpc.ErrNotFound {
-				fmt.Printf("No token registry entry found for given mint %q", pubKey.String())
-				return nil
+				return fmt.Errorf("no token registry entry found for given mint %q", pubKey.String())
 			}
 			return fmt.Errorf("unable to retrieve token registry entry for mint %q: %w", pubKey.String(), err)
 		}
 
-		err = text.NewEncoder(os.Stdout).Encode(t, nil)
-		if err != nil {
-			return fmt.Errorf("unable to text encode token registry entry: %w", err)
-		}
-
-		return nil
+		return printOutput(newTokenRegistryEntryOutput(t))
 	},
 }
 
 func init() {
 	tokenRegistryCmd.AddCommand(tokenRegistryGetCmd)
 }
+
+func (o *tokenRegistryEntryOutput) Columns() []string { return tokenRegistryEntriesOutput{}.Columns() }
+func (o *tokenRegistryEntryOutput) Rows() [][]string  { return tokenRegistryEntriesOutput{o}.Rows() }
+
+func (o *tokenRegistryEntryOutput) Text(w io.Writer) error {
+	if err := text.NewEncoder(w).Encode(o.entry, nil); err != nil {
+		return fmt.Errorf("unable to text encode token registry entry: %w", err)
+	}
+	return nil
+}
@@ -16,13 +16,9 @@ package cmd
 
 import (
 	"fmt"
-	"strings"
-
-	"github.com/ryanuber/columnize"
 
 	"github.com/spf13/cobra"
 	"github.com/streamingfast/solana-go/programs/tokenregistry"
-	_ "github.com/streamingfast/solana-go/programs/tokenregistry"
 )
 
 var tokenRegistryListCmd = &cobra.Command{
@@ -37,31 +33,67 @@ var tokenRegistryListCmd = &cobra.Command{
 			return fmt.Errorf("unable to retrieve entries: %w", err)
 		}
 
-		out := []string{"Is Initialized | Account Address | Registration Authority | Logo | Name | Symbol | Website"}
-
-		for _, e := range entries {
-			initalized := "false"
-			if e.IsInitialized {
-				initalized = "true"
-			}
-
-			line := []string{
-				initalized,
-				e.MintAddress.String(),
-				e.RegistrationAuthority.String(),
-				e.Logo.String(),
-				e.Name.String(),
-				e.Symbol.String(),
-				e.Website.String(),
-			}
-			out = append(out, strings.Join(line, " | "))
+		out := make(tokenRegistryEntriesOutput, len(entries))
+		for i, e := range entries {
+			out[i] = newTokenRegistryEntryOutput(e)
 		}
 
-		fmt.Println(columnize.Format(out, nil))
-		return nil
+		return printOutput(out)
 	},
 }
 
 func init() {
 	tokenRegistryCmd.AddCommand(tokenRegistryListCmd)
 }
+
+type tokenRegistryEntryOutput struct {
+	Initialized           bool   `json:"initialized"`
+	MintAddress           string `json:"mint_address"`
+	RegistrationAuthority string `json:"registration_authority"`
+	Logo                  string `json:"logo"`
+	Name                  string `json:"name"`
+	Symbol                string `json:"symbol"`
+	Website               string `json:"website"`
+
+	entry *tokenregistry.TokenMeta
+}
+
+func newTokenRegistryEntryOutput(e *tokenregistry.TokenMeta) *tokenRegistryEntryOutput {
+	out := &tokenRegistryEntryOutput{
+		Initialized: e.IsInitialized,
+		Logo:        e.Logo.String(),
+		Name:        e.Name.String(),
+		Symbol:      e.Symbol.String(),
+		Website:     e.Website.String(),
+		entry:       e,
+	}
+
+	if e.MintAddress != nil {
+		out.MintAddress = e.MintAddress.String()
+	}
+	if e.RegistrationAuthority != nil {
+		out.RegistrationAuthority = e.RegistrationAuthority.String()
+	}
+	return out
+}
+
+type tokenRegistryEntriesOutput []*tokenRegistryEntryOutput
+
+func (o tokenRegistryEntriesOutput) Columns() []string {
+	return []string{"Is Initialized", "Account Address", "Registration Authority", "Logo", "Name", "Symbol", "Website"}
+}
+
+func (o tokenRegistryEntriesOutput) Rows() (out [][]string) {
+	for _, e := range o {
+		out = append(out, []string{
+			fmt.Sprintf("%t", e.Initialized),
+			e.MintAddress,
+			e.RegistrationAuthority,
+			e.Logo,
+			e.Name,
+			e.Symbol,
+			e.Website,
+		})
+	}
+	return
+}
@@ -17,8 +17,6 @@ package cmd
 import (
 	"fmt"
 
-	"github.com/streamingfast/solana-go/rpc"
-
 	"github.com/spf13/viper"
 
 	"github.com/spf13/cobra"
@@ -32,11 +30,15 @@ var tokenRegistryRegisterCmd = &cobra.Command{
 	Short: "register meta data for a token",
 	Args:  cobra.ExactArgs(5),
 	RunE: func(cmd *cobra.Command, args []string) (err error) {
-		vault := mustGetWallet()
+		if viper.GetBool("global-dump-unsigned") {
+			return fmt.Errorf("registering a token requires the keypair of its new metadata account to sign, it cannot be combined with --dump-unsigned")
+		}
+
+		vault := mustGetSignerWallet()
 		client := getClient()
 
 		var tokenAddress solana.PublicKey
-		if tokenAddress, err = solana.PublicKeyFromBase58(args[0]); err != nil {
+		if tokenAddress, err = resolveAddress(args[0]); err != nil {
 			return fmt.Errorf("invalid token address %q: %w", args[0], err)
 		}
 
@@ -68,7 +70,7 @@ var tokenRegistryRegisterCmd = &cobra.Command{
 
 		// TODO: we shoul check on chain if the symbol exists
 
-		registrarPubKey, err := solana.PublicKeyFromBase58(pkeyStr)
+		registrarPubKey, err := resolveAddress(pkeyStr)
 		if err != nil {
 			return fmt.Errorf("invalid registrar key %q: %w", pkeyStr, err)
 		}
@@ -84,11 +86,6 @@ var tokenRegistryRegisterCmd = &cobra.Command{
 			return fmt.Errorf("registrar key must be present in the vault to register a token")
 		}
 
-		blockHashResult, err := client.GetLatestBlockhash(rpc.CommitmentProcessed)
-		if err != nil {
-			return fmt.Errorf("unable retrieve recent block hash: %w", err)
-		}
-
 		tokenMetaAccount := solana.NewAccount()
 
 		lamport, err := client.GetMinimumBalanceForRentExemption(tokenregistry.TOKEN_META_SIZE)
@@ -101,12 +98,12 @@ var tokenRegistryRegisterCmd = &cobra.Command{
 		createAccountInstruction := system.NewCreateAccountInstruction(uint64(lamport), tokenregistry.TOKEN_META_SIZE, tokenRegistryProgramID, registrarPubKey, tokenMetaAccount.PublicKey())
 		registerTokenInstruction := tokenregistry.NewRegisterTokenInstruction(logo, name, symbol, website, tokenMetaAccount.PublicKey(), registrarPubKey, tokenAddress)
 
-		trx, err := solana.NewTransaction([]solana.Instruction{createAccountInstruction, registerTokenInstruction}, blockHashResult.Value.Blockhash, solana.TransactionPayer(registrarPubKey))
+		trx, err := newTransaction(client, []solana.Instruction{createAccountInstruction, registerTokenInstruction}, solana.TransactionPayer(registrarPubKey))
 		if err != nil {
-			return fmt.Errorf("unable to craft transaction: %w", err)
+			return err
 		}
 
-		_, err = trx.Sign(func(key solana.PublicKey) *solana.PrivateKey {
+		trxHash, err := sendTransaction(cmd.Context(), client, nil, trx, func(key solana.PublicKey) *solana.PrivateKey {
 			// create account need to be signed by the private key of the new account
 			// that is not in the vault and will be lost after the execution.
 			if key == tokenMetaAccount.PublicKey() {
@@ -121,18 +118,16 @@ var tokenRegistryRegisterCmd = &cobra.Command{
 			return nil
 		})
 		if err != nil {
-			return fmt.Errorf("unable to sign transaction: %w", err)
+			return fmt.Errorf("unable to send transaction: %w", err)
 		}
 
-		trxHash, err := client.SendTransaction(trx, nil)
-		if err != nil {
-			return fmt.Errorf("unable to send transaction: %w", err)
+		if trxHash == "" {
+			return nil
 		}
 
-		fmt.Printf("Token Register successfully, with transaction hash: %s\n", trxHash)
-		fmt.Printf("  Account Address Registerd: %s\n", tokenAddress.String())
-		fmt.Printf("  Token Registry Meta Address: %s\n", tokenMetaAccount.PublicKey().String())
-		return nil
+		return printOutput(newTransactionOutput(trxHash, "Token Register successfully, with transaction hash: %s\n  Account Address Registerd: %s\n  Token Registry Meta Address: %s\n", trxHash, tokenAddress.String(), tokenMetaAccount.PublicKey().String()).
+			withAccount("mint", tokenAddress).
+			withAccount("token_meta", tokenMetaAccount.PublicKey()))
 	},
 }
 
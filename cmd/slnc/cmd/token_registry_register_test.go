@@ -0,0 +1,94 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestRPCServer answers the JSON-RPC methods of `results`, recording the
+// methods called.
+func newTestRPCServer(t *testing.T, results map[string]interface{}) *[]string {
+	var lock sync.Mutex
+	var methods []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			ID     interface{} `json:"id"`
+			Method string      `json:"method"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+
+		lock.Lock()
+		methods = append(methods, request.Method)
+		lock.Unlock()
+
+		result, found := results[request.Method]
+		if !found {
+			t.Errorf("unexpected JSON-RPC method %q", request.Method)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": request.ID, "result": result})
+	}))
+	t.Cleanup(server.Close)
+
+	viper.Set("global-rpc-url", server.URL)
+	t.Cleanup(func() { viper.Set("global-rpc-url", "") })
+
+	return &methods
+}
+
+func TestTokenRegistryRegister_DryRun(t *testing.T) {
+	newTestVaultFile(t)
+	methods := newTestRPCServer(t, map[string]interface{}{
+		"getMinimumBalanceForRentExemption": 2039280,
+		"getLatestBlockhash": map[string]interface{}{
+			"context": map[string]interface{}{"slot": 1},
+			"value":   map[string]interface{}{"blockhash": "EkSnNWid2cvwEVnVx9aBqawnmiCNiDgp3gUdkDPTKN1N", "lastValidBlockHeight": 1},
+		},
+		"getMultipleAccounts": map[string]interface{}{
+			"context": map[string]interface{}{"slot": 1},
+			"value":   []interface{}{nil, nil},
+		},
+		"simulateTransaction": map[string]interface{}{
+			"context": map[string]interface{}{"slot": 1},
+			"value":   map[string]interface{}{"err": nil, "logs": []string{}, "accounts": []interface{}{nil, nil}, "unitsConsumed": 1200},
+		},
+	})
+
+	viper.Set("global-dry-run", true)
+	viper.Set("token-registry-register-cmd-registrar", "@payroll")
+	t.Cleanup(func() {
+		viper.Set("global-dry-run", false)
+		viper.Set("token-registry-register-cmd-registrar", "")
+	})
+
+	// The vault is not opened, which would prompt for its passphrase
+	err := tokenRegistryRegisterCmd.RunE(tokenRegistryRegisterCmd, []string{
+		"So11111111111111111111111111111111111111112", "Wrapped SOL", "SOL", "https://example.com/sol.png", "https://example.com",
+	})
+	require.NoError(t, err)
+	assert.Nil(t, openedWallet)
+	assert.Equal(t, []string{"getMinimumBalanceForRentExemption", "getLatestBlockhash", "getMultipleAccounts", "simulateTransaction"}, *methods)
+}
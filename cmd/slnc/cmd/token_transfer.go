@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strconv"
 
+	associatedtokenaccount "github.com/streamingfast/solana-go/programs/associated-token-account"
 	"github.com/streamingfast/solana-go/rpc"
 
 	"github.com/spf13/cobra"
@@ -22,13 +23,13 @@ var tokenTransferCmd = &cobra.Command{
 		if err != nil {
 			return fmt.Errorf("unable to setup websocket client: %w", err)
 		}
-		vault := mustGetWallet()
-		recipient, err := solana.PublicKeyFromBase58(args[0])
+		vault := mustGetSignerWallet()
+		recipient, err := resolveAddress(args[0])
 		if err != nil {
 			return fmt.Errorf("decoding recipient addr: %w", err)
 		}
 
-		splTokenAccount, err := solana.PublicKeyFromBase58(args[1])
+		splTokenAccount, err := resolveAddress(args[1])
 		if err != nil {
 			return fmt.Errorf("decoding owner spl token accout: %w", err)
 		}
@@ -49,8 +50,7 @@ var tokenTransferCmd = &cobra.Command{
 		}
 
 		if !account.IsInitialized {
-			fmt.Println("uninitialized SPL token account. Data length", len(acct.Value.Data))
-			return fmt.Errorf("uninitialized SPL token account. data length: %w", err)
+			return fmt.Errorf("uninitialized SPL token account, data length: %d", len(acct.Value.Data))
 		}
 
 		var sender *solana.Account
@@ -63,16 +63,48 @@ var tokenTransferCmd = &cobra.Command{
 		if sender == nil {
 			return fmt.Errorf("spl token account owner %q must be present in the vault to sign the send transaction", account.Owner.String())
 		}
-		fmt.Printf("Sending %d token %s (mint: %s) to %q from %q\n", amount, account.Key, account.Mint, recipient.String(), account.Owner.String())
+		printInfo("Sending %d token %s (mint: %s) to %q from %q\n", amount, account.Key, account.Mint, recipient.String(), account.Owner.String())
 
-		recipientSplTokenAccount, trxHash, err := token.TransferToken(ctx, rpcCli, wsCli, 1, account.Key, account.Mint, recipient, sender)
+		recipientSplTokenAccount := associatedtokenaccount.MustGetAssociatedTokenAddress(account.Mint, token.PROGRAM_ID, recipient)
+
+		instructions := []solana.Instruction{}
+		_, err = rpcCli.GetAccountInfo(recipientSplTokenAccount)
+		if err != nil && err != rpc.ErrNotFound {
+			return fmt.Errorf("failed to look up recipient spl token account %q: %w", recipientSplTokenAccount.String(), err)
+		}
+		if err == rpc.ErrNotFound {
+			instructions = append(instructions, associatedtokenaccount.NewCreateInstruction(
+				sender.PublicKey(),
+				recipientSplTokenAccount,
+				recipient,
+				account.Mint,
+				token.PROGRAM_ID,
+			))
+		}
+
+		instructions = append(instructions, token.NewTransferInstruction(amount, account.Key, recipientSplTokenAccount, sender.PublicKey()))
+
+		trx, err := newTransaction(rpcCli, instructions)
+		if err != nil {
+			return err
+		}
+
+		trxHash, err := sendTransaction(ctx, rpcCli, wsCli, trx, func(key solana.PublicKey) *solana.PrivateKey {
+			if key == sender.PublicKey() {
+				return &sender.PrivateKey
+			}
+			return nil
+		})
 		if err != nil {
 			return fmt.Errorf("unable to send transaction: %w", err)
 		}
 
-		fmt.Printf("Token Transfer successfull, with transaction hash: %s\n", trxHash)
-		fmt.Printf("  Recipient SPL Token Account: %s\n", recipientSplTokenAccount.String())
-		return nil
+		if trxHash == "" {
+			return nil
+		}
+
+		return printOutput(newTransactionOutput(trxHash, "Token Transfer successfull, with transaction hash: %s\n  Recipient SPL Token Account: %s\n", trxHash, recipientSplTokenAccount.String()).
+			withAccount("recipient_token_account", recipientSplTokenAccount))
 	},
 }
 
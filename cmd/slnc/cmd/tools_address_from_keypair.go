@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"io"
 
 	"github.com/spf13/cobra"
 	"github.com/streamingfast/solana-go"
@@ -20,11 +21,19 @@ var fromKeypairAddressToolsCmd = &cobra.Command{
 		}
 
 		pkey := solana.PrivateKey(keypair[:])
-		fmt.Printf("%s\n", pkey.PublicKey().String())
-		return nil
+		return printOutput(&addressOutput{Address: pkey.PublicKey().String()})
 	},
 }
 
+type addressOutput struct {
+	Address string `json:"address"`
+}
+
+func (o *addressOutput) Text(w io.Writer) error {
+	_, err := fmt.Fprintln(w, o.Address)
+	return err
+}
+
 func init() {
 	addressToolsCmd.AddCommand(fromKeypairAddressToolsCmd)
 }
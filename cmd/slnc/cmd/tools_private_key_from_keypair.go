@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 
 	"github.com/streamingfast/dstore"
@@ -22,11 +23,19 @@ var fromKeypairPrivateKeyToolsCmd = &cobra.Command{
 			return fmt.Errorf("unable to open keypair: %w", err)
 		}
 		pkey := solana.PrivateKey(keypair[:])
-		fmt.Printf("%s\n", pkey.String())
-		return nil
+		return printOutput(&privateKeyOutput{PrivateKey: pkey.String()})
 	},
 }
 
+type privateKeyOutput struct {
+	PrivateKey string `json:"private_key"`
+}
+
+func (o *privateKeyOutput) Text(w io.Writer) error {
+	_, err := fmt.Fprintln(w, o.PrivateKey)
+	return err
+}
+
 func init() {
 	privateKeytoolsCmd.AddCommand(fromKeypairPrivateKeyToolsCmd)
 }
@@ -36,6 +45,7 @@ func readKeypair(ctx context.Context, keypairFilePath string) ([]uint8, error) {
 	if err != nil {
 		return nil, fmt.Errorf("unable to open keypair: %w", err)
 	}
+	defer file.Close()
 
 	cnt, err := ioutil.ReadAll(file)
 	if err != nil {
@@ -31,20 +31,14 @@ var toKeypairPrivateKeyToolsCmd = &cobra.Command{
 	RunE: func(cmd *cobra.Command, args []string) error {
 		privateKey := args[0]
 		keypairPath := args[1]
-		fmt.Println("Converting: ", privateKey)
+		printInfo("Converting:  %s\n", privateKey)
 		pkey, err := solana.PrivateKeyFromBase58(privateKey)
 		if err != nil {
 			return fmt.Errorf("unable to deo decode private key")
 		}
 
-		values := []string{}
-		for _, b := range pkey {
-			values = append(values, fmt.Sprintf("%d", b))
-		}
-
-		cnt := fmt.Sprintf("[%s]", strings.Join(values, ","))
-		fmt.Println("Writing keypair: ", keypairPath)
-		if err := ioutil.WriteFile(keypairPath, []byte(cnt), os.ModePerm); err != nil {
+		printInfo("Writing keypair:  %s\n", keypairPath)
+		if err := ioutil.WriteFile(keypairPath, encodeKeypair(pkey), os.ModePerm); err != nil {
 			return fmt.Errorf("unable to write file")
 		}
 		return nil
@@ -54,3 +48,14 @@ var toKeypairPrivateKeyToolsCmd = &cobra.Command{
 func init() {
 	privateKeytoolsCmd.AddCommand(toKeypairPrivateKeyToolsCmd)
 }
+
+// encodeKeypair encodes `privateKey` in the solana-keygen keypair file
+// format, a JSON array of its 64 bytes.
+func encodeKeypair(privateKey solana.PrivateKey) []byte {
+	values := []string{}
+	for _, b := range privateKey {
+		values = append(values, fmt.Sprintf("%d", b))
+	}
+
+	return []byte(fmt.Sprintf("[%s]", strings.Join(values, ",")))
+}
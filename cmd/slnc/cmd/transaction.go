@@ -0,0 +1,471 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/viper"
+	bin "github.com/streamingfast/binary"
+	"github.com/streamingfast/solana-go"
+	"github.com/streamingfast/solana-go/rpc"
+	"github.com/streamingfast/solana-go/rpc/confirm"
+	"github.com/streamingfast/solana-go/rpc/ws"
+	"go.uber.org/zap"
+)
+
+type getterFunc = func(key solana.PublicKey) *solana.PrivateKey
+
+// newTransaction fetches a recent block hash and assembles `instructions`
+// into a transaction ready to be handed to `sendTransaction`.
+//
+// When `--nonce-account` is set, the durable nonce stored in the account is
+// used instead of a recent block hash and an `AdvanceNonceAccount`
+// instruction is prepended to `instructions`. The Compute Budget
+// instructions requested through the global flags come right after it.
+func newTransaction(rpcClient *rpc.Client, instructions []solana.Instruction, opts ...solana.TransactionOption) (*solana.Transaction, error) {
+	var prefix []solana.Instruction
+	var blockHash solana.PublicKey
+
+	if nonceAccountAddr := viper.GetString("global-nonce-account"); nonceAccountAddr != "" {
+		nonce, err := getGlobalNonceAccount(rpcClient, nonceAccountAddr)
+		if err != nil {
+			return nil, err
+		}
+
+		zlog.Debug("using durable nonce",
+			zap.Stringer("nonce_account", nonce.Address),
+			zap.Stringer("nonce_authority", nonce.Authority),
+			zap.Stringer("nonce", nonce.Nonce),
+		)
+
+		prefix = append(prefix, newAdvanceNonceAccountInstruction(nonce.Address, nonce.Authority))
+		blockHash = nonce.Nonce
+	} else {
+		blockHashResult, err := rpcClient.GetLatestBlockhash(getCommitment(rpc.CommitmentFinalized))
+		if err != nil {
+			return nil, fmt.Errorf("unable retrieve recent block hash: %w", err)
+		}
+
+		zlog.Debug("found block hash", zap.String("block_hash", blockHashResult.Value.Blockhash.String()))
+		blockHash = blockHashResult.Value.Blockhash
+	}
+
+	budgetInstructions, err := computeBudgetInstructions(rpcClient, instructions)
+	if err != nil {
+		return nil, err
+	}
+	prefix = append(prefix, budgetInstructions...)
+
+	if len(prefix) > 0 {
+		// The fee payer defaults to the first signer of the first instruction,
+		// which would now be one of the prefixed ones, so it's pinned
+		// beforehand. Options passed by the caller are applied after and take
+		// precedence.
+		if payer, found := firstSigner(instructions); found {
+			opts = append([]solana.TransactionOption{solana.TransactionPayer(payer)}, opts...)
+		}
+
+		instructions = append(prefix, instructions...)
+	}
+
+	trx, err := solana.NewTransaction(instructions, blockHash, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create transaction: %w", err)
+	}
+
+	return trx, nil
+}
+
+func getGlobalNonceAccount(rpcClient *rpc.Client, nonceAccountAddr string) (*nonceAccount, error) {
+	address, err := resolveAddress(nonceAccountAddr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid nonce account %q: %w", nonceAccountAddr, err)
+	}
+
+	nonce, err := fetchNonceAccount(rpcClient, address)
+	if err != nil {
+		return nil, err
+	}
+
+	if !nonce.Initialized {
+		return nil, fmt.Errorf("nonce account %q is not initialized", address.String())
+	}
+
+	if authorityAddr := viper.GetString("global-nonce-authority"); authorityAddr != "" {
+		authority, err := resolveAddress(authorityAddr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid nonce authority %q: %w", authorityAddr, err)
+		}
+
+		if authority != nonce.Authority {
+			return nil, fmt.Errorf("nonce authority %q does not match authority %q of nonce account %q", authority.String(), nonce.Authority.String(), address.String())
+		}
+	}
+
+	return nonce, nil
+}
+
+func firstSigner(instructions []solana.Instruction) (solana.PublicKey, bool) {
+	if len(instructions) == 0 {
+		return solana.PublicKey{}, false
+	}
+
+	for _, account := range instructions[0].Accounts() {
+		if account.IsSigner {
+			return account.PublicKey, true
+		}
+	}
+	return solana.PublicKey{}, false
+}
+
+// withNonceAuthority extends `getter` so that the authority of the durable
+// nonce added by `newTransaction`, if any, is resolved from the vault.
+func withNonceAuthority(trx *solana.Transaction, getter getterFunc) getterFunc {
+	if viper.GetString("global-nonce-account") == "" || len(trx.Message.Instructions) == 0 {
+		return getter
+	}
+
+	advance := trx.Message.Instructions[0]
+	if len(advance.Accounts) != 3 || int(advance.Accounts[2]) >= len(trx.Message.AccountKeys) {
+		return getter
+	}
+
+	authority := trx.Message.AccountKeys[advance.Accounts[2]]
+	return func(key solana.PublicKey) *solana.PrivateKey {
+		if privateKey := getter(key); privateKey != nil {
+			return privateKey
+		}
+
+		if key != authority {
+			return nil
+		}
+
+		for _, k := range mustGetWallet().KeyBag {
+			if k.PublicKey() == key {
+				return &k
+			}
+		}
+		return nil
+	}
+}
+
+// sendTransaction signs `trx` with the keys resolved by `getter` and sends it
+// to the cluster, waiting for confirmation when a `wsClient` is provided.
+//
+// When either `--sign-only` or `--dump-unsigned` is set, the transaction is
+// written as a transaction envelope instead of being sent, and the returned
+// hash is empty. Callers should then skip reporting about a sent transaction.
+// The same goes for `--dry-run`, which simulates the unsigned transaction and
+// prints the outcome instead of sending it, failing when the simulation does.
+func sendTransaction(ctx context.Context, rpcClient *rpc.Client, wsClient *ws.Client, trx *solana.Transaction, getter getterFunc) (string, error) {
+	if viper.GetBool("global-dry-run") && isOfflineTransactionMode() {
+		return "", fmt.Errorf("flag --dry-run cannot be combined with --sign-only or --dump-unsigned")
+	}
+
+	if viper.GetBool("global-dump-unsigned") {
+		return "", writeTransactionEnvelope(trx)
+	}
+
+	// Simulations don't verify signatures, the transaction is simulated
+	// unsigned so the vault keys are neither unlocked nor charged.
+	if viper.GetBool("global-dry-run") {
+		sim, err := simulateTransaction(rpcClient, trx, true)
+		if err != nil {
+			return "", err
+		}
+
+		return "", printSimulation(trx, sim)
+	}
+
+	signed, err := signTransaction(trx, withNonceAuthority(trx, getter))
+	if err != nil {
+		return "", fmt.Errorf("unable to sign transaction: %w", err)
+	}
+
+	if viper.GetBool("global-sign-only") {
+		if err := recordSignatures(trx, signed); err != nil {
+			return "", err
+		}
+		return "", writeTransactionEnvelope(trx)
+	}
+
+	if missing := missingSigners(trx); len(missing) > 0 {
+		return "", fmt.Errorf("signer key %q not found. Ensure all the signer keys are in the vault", missing[0].String())
+	}
+
+	if err := recordSignatures(trx, signed); err != nil {
+		return "", err
+	}
+
+	return broadcastTransaction(ctx, rpcClient, wsClient, trx)
+}
+
+func broadcastTransaction(ctx context.Context, rpcClient *rpc.Client, wsClient *ws.Client, trx *solana.Transaction) (string, error) {
+	zlog.Info("transaction signed, sending to chain", zap.Stringer("trx_sign", trx.Signatures[0]))
+	if wsClient == nil {
+		return rpcClient.SendTransaction(trx, nil)
+	}
+
+	return confirm.SendAndConfirmTransaction(ctx, rpcClient, wsClient, trx)
+}
+
+func isOfflineTransactionMode() bool {
+	return viper.GetBool("global-sign-only") || viper.GetBool("global-dump-unsigned")
+}
+
+// isUnsignedTransactionMode returns whether the transaction is built but
+// not signed, under --dry-run or --dump-unsigned.
+func isUnsignedTransactionMode() bool {
+	return viper.GetBool("global-dry-run") || viper.GetBool("global-dump-unsigned")
+}
+
+// signTransaction adds a signature for every required signer that `getter`
+// can resolve, leaving the others untouched. Contrary to `trx.Sign`, it does
+// not fail when a signer is missing so a transaction can be signed by
+// multiple parties. The spending policies of the signing keys are enforced
+// first. It returns the keys that signed during this call, the caller
+// records them with `recordSignatures` once the transaction leaves slnc.
+func signTransaction(trx *solana.Transaction, getter getterFunc) (signed []solana.PublicKey, err error) {
+	message, err := encodeMessage(trx)
+	if err != nil {
+		return nil, err
+	}
+
+	signers := trx.Message.AccountKeys[0:trx.Message.Header.NumRequiredSignatures]
+	if len(trx.Signatures) != len(signers) {
+		signatures := make([]solana.Signature, len(signers))
+		copy(signatures, trx.Signatures)
+		trx.Signatures = signatures
+	}
+
+	privateKeys := make([]*solana.PrivateKey, len(signers))
+	var signing []solana.PublicKey
+	for idx, key := range signers {
+		if privateKeys[idx] = getter(key); privateKeys[idx] != nil {
+			signing = append(signing, key)
+		}
+	}
+
+	if err := enforceSpendingPolicies(trx, signing); err != nil {
+		return nil, err
+	}
+
+	for idx, key := range signers {
+		privateKey := privateKeys[idx]
+		if privateKey == nil {
+			continue
+		}
+
+		signature, err := signWithKey(*privateKey, message)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign with key %q: %w", key.String(), err)
+		}
+
+		trx.Signatures[idx] = signature
+		signed = append(signed, key)
+	}
+
+	return signed, nil
+}
+
+// missingSigners returns the required signers of `trx` that have not signed
+// the transaction yet.
+func missingSigners(trx *solana.Transaction) (out []solana.PublicKey) {
+	for idx, key := range trx.Message.AccountKeys[0:trx.Message.Header.NumRequiredSignatures] {
+		if idx >= len(trx.Signatures) || trx.Signatures[idx] == (solana.Signature{}) {
+			out = append(out, key)
+		}
+	}
+	return
+}
+
+// checkSignatureCounts ensures that the message header of `trx` names no
+// more signers than it has accounts, and that `trx` has no more signatures
+// than signers, those being indexed by signer.
+func checkSignatureCounts(trx *solana.Transaction) error {
+	required := int(trx.Message.Header.NumRequiredSignatures)
+	if required > len(trx.Message.AccountKeys) {
+		return fmt.Errorf("message requires %d signatures but has only %d accounts", required, len(trx.Message.AccountKeys))
+	}
+
+	if len(trx.Signatures) > required {
+		return fmt.Errorf("transaction has %d signatures but its message requires %d", len(trx.Signatures), required)
+	}
+
+	return nil
+}
+
+// verifySignatures ensures that every signature present in `trx` is valid for
+// its message, catching an envelope that was altered after being signed.
+func verifySignatures(trx *solana.Transaction) error {
+	message, err := encodeMessage(trx)
+	if err != nil {
+		return err
+	}
+
+	for idx, signature := range trx.Signatures {
+		if signature == (solana.Signature{}) {
+			continue
+		}
+
+		key := trx.Message.AccountKeys[idx]
+		if !signature.Verify(key, message) {
+			return fmt.Errorf("signature of %q does not match the transaction message", key.String())
+		}
+	}
+	return nil
+}
+
+func encodeMessage(trx *solana.Transaction) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if err := bin.NewEncoder(buf).Encode(trx.Message); err != nil {
+		return nil, fmt.Errorf("unable to encode message for signing: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// transactionEnvelope is the file format used to move a transaction between
+// the machine building it, the ones signing it and the one broadcasting it.
+type transactionEnvelope struct {
+	Version         int               `json:"version"`
+	Transaction     string            `json:"transaction"`
+	RecentBlockhash solana.PublicKey  `json:"recent_blockhash"`
+	Signers         []*envelopeSigner `json:"signers"`
+}
+
+type envelopeSigner struct {
+	PublicKey solana.PublicKey `json:"public_key"`
+	Signed    bool             `json:"signed"`
+}
+
+func newTransactionEnvelope(trx *solana.Transaction) (*transactionEnvelope, error) {
+	buf := new(bytes.Buffer)
+	if err := bin.NewEncoder(buf).Encode(trx); err != nil {
+		return nil, fmt.Errorf("unable to encode transaction: %w", err)
+	}
+
+	envelope := &transactionEnvelope{
+		Version:         1,
+		Transaction:     base64.StdEncoding.EncodeToString(buf.Bytes()),
+		RecentBlockhash: trx.Message.RecentBlockhash,
+	}
+
+	for idx, key := range trx.Message.AccountKeys[0:trx.Message.Header.NumRequiredSignatures] {
+		envelope.Signers = append(envelope.Signers, &envelopeSigner{
+			PublicKey: key,
+			Signed:    idx < len(trx.Signatures) && trx.Signatures[idx] != (solana.Signature{}),
+		})
+	}
+
+	return envelope, nil
+}
+
+func (e *transactionEnvelope) decodeTransaction() (*solana.Transaction, error) {
+	data, err := base64.StdEncoding.DecodeString(e.Transaction)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode base64 transaction: %w", err)
+	}
+
+	trx, err := solana.TransactionFromData(data)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode transaction: %w", err)
+	}
+
+	return trx, nil
+}
+
+func writeTransactionEnvelope(trx *solana.Transaction) error {
+	return writeTransactionEnvelopeTo(viper.GetString("global-transaction-file"), trx)
+}
+
+func writeTransactionEnvelopeTo(filename string, trx *solana.Transaction) error {
+	envelope, err := newTransactionEnvelope(trx)
+	if err != nil {
+		return err
+	}
+
+	cnt, err := json.MarshalIndent(envelope, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to marshal transaction envelope: %w", err)
+	}
+
+	if filename == "" || filename == "-" {
+		fmt.Println(string(cnt))
+		return nil
+	}
+
+	// Written to a temporary file first so a partially written envelope never
+	// replaces a good one.
+	tmpFile, err := ioutil.TempFile(filepath.Dir(filename), ".slnc-trx-*")
+	if err != nil {
+		return fmt.Errorf("unable to create temporary file: %w", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.Write(append(cnt, '\n')); err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("unable to write transaction envelope: %w", err)
+	}
+
+	if err := tmpFile.Close(); err != nil {
+		return fmt.Errorf("unable to write transaction envelope: %w", err)
+	}
+
+	if err := os.Rename(tmpFile.Name(), filename); err != nil {
+		return fmt.Errorf("unable to write transaction envelope: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Transaction envelope written to %q\n", filename)
+	return nil
+}
+
+func readTransactionEnvelope(filename string) (*solana.Transaction, error) {
+	cnt, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read transaction envelope: %w", err)
+	}
+
+	envelope := &transactionEnvelope{}
+	if err := json.Unmarshal(cnt, envelope); err != nil {
+		return nil, fmt.Errorf("unable to decode transaction envelope: %w", err)
+	}
+
+	if envelope.Version != 1 {
+		return nil, fmt.Errorf("unsupported transaction envelope version %d", envelope.Version)
+	}
+
+	trx, err := envelope.decodeTransaction()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkSignatureCounts(trx); err != nil {
+		return nil, fmt.Errorf("invalid transaction envelope: %w", err)
+	}
+
+	if err := verifySignatures(trx); err != nil {
+		return nil, err
+	}
+
+	return trx, nil
+}
@@ -0,0 +1,37 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import "github.com/spf13/cobra"
+
+var txCmd = &cobra.Command{
+	Use:   "tx",
+	Short: "Offline transaction commands",
+	Long: `Offline transaction commands.
+
+Any command sending a transaction accepts --sign-only or --dump-unsigned.
+Instead of sending the transaction, it is then written as a transaction
+envelope (see --transaction-file), a JSON document containing the
+base64 encoded transaction and the list of its required signers.
+
+The envelope can then be moved to the machines holding the signer keys
+and signed with 'slnc tx sign', and finally sent to the cluster with
+'slnc tx broadcast'.
+`,
+}
+
+func init() {
+	RootCmd.AddCommand(txCmd)
+}
@@ -0,0 +1,65 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var txBroadcastCmd = &cobra.Command{
+	Use:   "broadcast {envelope_file}",
+	Short: "Send a fully signed transaction envelope to the cluster and wait for its confirmation",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		trx, err := readTransactionEnvelope(args[0])
+		if err != nil {
+			return err
+		}
+
+		rpcClient := getClient()
+		if viper.GetBool("global-dry-run") {
+			sim, err := simulateTransaction(rpcClient, trx, false)
+			if err != nil {
+				return err
+			}
+
+			return printSimulation(trx, sim)
+		}
+
+		if missing := missingSigners(trx); len(missing) > 0 {
+			return fmt.Errorf("transaction is missing %d signature(s), first one is from %q", len(missing), missing[0].String())
+		}
+
+		wsClient, err := getWsClient(ctx)
+		if err != nil {
+			return fmt.Errorf("unable to retrieve ws client: %w", err)
+		}
+
+		trxHash, err := broadcastTransaction(ctx, rpcClient, wsClient, trx)
+		if err != nil {
+			return fmt.Errorf("unable to send transaction: %w", err)
+		}
+
+		return printOutput(newTransactionOutput(trxHash, "Transaction broadcasted successfully, with transaction hash: %s\n", trxHash))
+	},
+}
+
+func init() {
+	txCmd.AddCommand(txBroadcastCmd)
+}
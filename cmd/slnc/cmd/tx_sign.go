@@ -0,0 +1,108 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	"github.com/streamingfast/solana-go"
+)
+
+var txSignCmd = &cobra.Command{
+	Use:   "sign {envelope_file}",
+	Short: "Add the signatures of the vault keys to a transaction envelope",
+	Long: `Add the signatures of the vault keys to a transaction envelope.
+
+Every required signer of the transaction that is present in the vault
+signs it, the envelope file is then updated in place.
+`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		envelopeFile := args[0]
+		trx, err := readTransactionEnvelope(envelopeFile)
+		if err != nil {
+			return err
+		}
+
+		vault := mustGetWallet()
+		signed, err := signTransaction(trx, func(key solana.PublicKey) *solana.PrivateKey {
+			for _, k := range vault.KeyBag {
+				if k.PublicKey() == key {
+					return &k
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("unable to sign transaction: %w", err)
+		}
+
+		if len(signed) == 0 {
+			return fmt.Errorf("none of the required signers of the transaction are present in the vault")
+		}
+
+		if err := recordSignatures(trx, signed); err != nil {
+			return err
+		}
+
+		if err := writeTransactionEnvelopeTo(envelopeFile, trx); err != nil {
+			return err
+		}
+
+		out := &txSignOutput{
+			TransactionFile: envelopeFile,
+			SignedBy:        []string{},
+			MissingSigners:  []string{},
+		}
+		for _, key := range signed {
+			out.SignedBy = append(out.SignedBy, key.String())
+		}
+		for _, key := range missingSigners(trx) {
+			out.MissingSigners = append(out.MissingSigners, key.String())
+		}
+
+		return printOutput(out)
+	},
+}
+
+type txSignOutput struct {
+	TransactionFile string   `json:"transaction_file"`
+	SignedBy        []string `json:"signed_by"`
+	MissingSigners  []string `json:"missing_signers"`
+}
+
+func (o *txSignOutput) Text(w io.Writer) error {
+	fmt.Fprintln(w, "Signed transaction with:")
+	for _, key := range o.SignedBy {
+		fmt.Fprintf(w, "- %s\n", key)
+	}
+
+	if len(o.MissingSigners) == 0 {
+		fmt.Fprintf(w, "Transaction is fully signed, run `slnc tx broadcast %s` to send it\n", o.TransactionFile)
+		return nil
+	}
+
+	fmt.Fprintln(w, "Signatures still missing from:")
+	for _, key := range o.MissingSigners {
+		fmt.Fprintf(w, "- %s\n", key)
+	}
+	return nil
+}
+
+func init() {
+	txCmd.AddCommand(txSignCmd)
+}
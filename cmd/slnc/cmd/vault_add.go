@@ -16,43 +16,36 @@ package cmd
 
 import (
 	"fmt"
+	"io"
 
 	"github.com/streamingfast/solana-go"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"github.com/streamingfast/slnc/cli"
-	"github.com/streamingfast/slnc/vault"
 )
 
 var vaultAddCmd = &cobra.Command{
 	Use:   "add",
 	Short: "Add private keys to an existing vault taking input from the shell",
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 
 		walletFile := viper.GetString("global-vault-file")
 
-		fmt.Println("Loading existing vault from file:", walletFile)
-		v, err := vault.NewVaultFromWalletFile(walletFile)
+		printInfo("Loading existing vault from file: %s\n", walletFile)
+		v, err := getWallet()
 		if err != nil {
-			fmt.Errorf("unable to load vault file: %w", err)
+			return err
 		}
 
-		boxer, err := vault.SecretBoxerForType(v.SecretBoxWrap, viper.GetString("global-kms-gcp-keypath"))
-		if err != nil {
-			fmt.Errorf("unable to intiate boxer: %w", err)
-		}
-
-		err = v.Open(boxer)
-		if err != nil {
-			fmt.Errorf("unable to open vault: %w", err)
+		printInfo("Public keys contained within (%d in total):\n", len(v.KeyBag))
+		for _, key := range v.KeyBag {
+			printInfo("- %s\n", key.PublicKey())
 		}
 
-		v.PrintPublicKeys()
-
 		privateKeys, err := capturePrivateKeys()
 		if err != nil {
-			fmt.Errorf("failed to enter private keys: %w", err)
+			return fmt.Errorf("failed to enter private keys: %w", err)
 		}
 
 		var newKeys []solana.PublicKey
@@ -61,17 +54,11 @@ var vaultAddCmd = &cobra.Command{
 			newKeys = append(newKeys, privateKey.PublicKey())
 		}
 
-		err = v.Seal(boxer)
-		if err != nil {
-			fmt.Errorf("failed to seal vault: %w", err)
+		if err := writeOpenedWallet(true); err != nil {
+			return err
 		}
 
-		err = v.WriteToFile(walletFile)
-		if err != nil {
-			fmt.Errorf("failed to write vault file: %w", err)
-		}
-
-		vaultWrittenReport(walletFile, newKeys, len(v.KeyBag))
+		return printOutput(newVaultWrittenOutput(walletFile, newKeys, len(v.KeyBag)))
 	},
 }
 
@@ -80,12 +67,12 @@ func init() {
 }
 
 func capturePrivateKeys() (out []solana.PrivateKey, err error) {
-	fmt.Println("")
-	fmt.Println("PLEASE READ:")
-	fmt.Println("We are now going to ask you to paste your private keys, one at a time.")
-	fmt.Println("They will not be shown on screen.")
-	fmt.Println("Please verify that the public keys printed on screen correspond to what you have noted")
-	fmt.Println("")
+	printInfo("\n")
+	printInfo("PLEASE READ:\n")
+	printInfo("We are now going to ask you to paste your private keys, one at a time.\n")
+	printInfo("They will not be shown on screen.\n")
+	printInfo("Please verify that the public keys printed on screen correspond to what you have noted\n")
+	printInfo("\n")
 
 	first := true
 	for {
@@ -122,18 +109,54 @@ func capturePrivateKey(isFirst bool) (privateKey solana.PrivateKey, err error) {
 		return nil, fmt.Errorf("import private key: %s", err)
 	}
 
-	fmt.Printf("- Scanned private key corresponding to %s\n", key.PublicKey().String())
+	printInfo("- Scanned private key corresponding to %s\n", key.PublicKey().String())
 
 	return key, nil
 }
 
-func vaultWrittenReport(walletFile string, newKeys []solana.PublicKey, totalKeys int) {
-	fmt.Println("")
-	fmt.Printf("Wallet file %q written to disk.\n", walletFile)
-	fmt.Println("Here are the keys that were ADDED during this operation (use `list` to see them all):")
+type vaultWrittenOutput struct {
+	VaultFile   string   `json:"vault_file"`
+	ShareFiles  []string `json:"share_files,omitempty"`
+	AddedKeys   []string `json:"added_keys"`
+	SkippedKeys []string `json:"skipped_keys,omitempty"`
+	TotalKeys   int      `json:"total_keys"`
+
+	// untouched is set when there was nothing to add, the vault file not
+	// being written
+	untouched bool
+}
+
+func newVaultWrittenOutput(walletFile string, newKeys []solana.PublicKey, totalKeys int) *vaultWrittenOutput {
+	out := &vaultWrittenOutput{VaultFile: walletFile, AddedKeys: []string{}, TotalKeys: totalKeys}
 	for _, pub := range newKeys {
-		fmt.Printf("- %s\n", pub.String())
+		out.AddedKeys = append(out.AddedKeys, pub.String())
+	}
+
+	return out
+}
+
+func (o *vaultWrittenOutput) Columns() []string { return []string{"Added Key"} }
+func (o *vaultWrittenOutput) Rows() [][]string {
+	rows := make([][]string, len(o.AddedKeys))
+	for i, key := range o.AddedKeys {
+		rows[i] = []string{key}
+	}
+	return rows
+}
+
+func (o *vaultWrittenOutput) Text(w io.Writer) error {
+	if o.untouched {
+		_, err := fmt.Fprintf(w, "No keys added, wallet file %q left untouched. Total keys stored: %d\n", o.VaultFile, o.TotalKeys)
+		return err
+	}
+
+	fmt.Fprintln(w, "")
+	fmt.Fprintf(w, "Wallet file %q written to disk.\n", o.VaultFile)
+	fmt.Fprintln(w, "Here are the keys that were ADDED during this operation (use `list` to see them all):")
+	for _, key := range o.AddedKeys {
+		fmt.Fprintf(w, "- %s\n", key)
 	}
 
-	fmt.Printf("Total keys stored: %d\n", totalKeys)
+	_, err := fmt.Fprintf(w, "Total keys stored: %d\n", o.TotalKeys)
+	return err
 }
@@ -0,0 +1,155 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/streamingfast/slnc/audit"
+	"github.com/streamingfast/slnc/policy"
+	"github.com/streamingfast/solana-go"
+)
+
+var vaultAuditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Inspect the audit log of the signatures made with vault keys",
+	Long: `Inspect the audit log of the signatures made with vault keys.
+
+Every time a signature made by vault keys leaves slnc, a transaction being
+broadcast, written to an envelope or returned by the signer API, or a
+message signed by 'slnc sign', slnc appends an entry to the audit log (see
+--audit-log) recording the command, the transaction signature, the signing
+keys, a summary of the instructions, the RPC endpoint and the time. The
+signing agent records each message it signs, by hash, since signatures
+always leave it: a transaction signed through the agent has both its entry
+and the agent's one. Simulations are never signed, so never recorded, and
+each broadcast attempt of a retried command is. Each entry holds the hash of
+the previous one, 'slnc vault audit verify' detects an entry altered,
+removed or reordered. Keep the head hash it prints somewhere else to also
+detect the removal of the last entries.
+`,
+}
+
+// runningCommand is the path of the command being run, like 'slnc token
+// transfer', recorded in the audit log.
+var runningCommand string
+
+func init() {
+	vaultCmd.AddCommand(vaultAuditCmd)
+}
+
+func getAuditLog() *audit.Log {
+	filename := viper.GetString("global-audit-log")
+	if filename == "" {
+		filename = filepath.Join(filepath.Dir(getConfigFile()), "audit.log")
+	}
+	return audit.NewLog(filename)
+}
+
+// recordSignatures appends the signature of `trx` by the vault keys
+// `signers` to the audit log, it's called right before the signatures
+// leave slnc.
+func recordSignatures(trx *solana.Transaction, signers []solana.PublicKey) error {
+	if len(signers) == 0 {
+		return nil
+	}
+
+	entry := &audit.Entry{
+		Command:      runningCommand,
+		VaultFile:    viper.GetString("global-vault-file"),
+		Signers:      []string{},
+		Instructions: auditInstructions(trx),
+		RPCEndpoint:  redactURL(getRPCURL()),
+	}
+
+	if len(trx.Signatures) > 0 && trx.Signatures[0] != (solana.Signature{}) {
+		entry.TransactionSignature = trx.Signatures[0].String()
+	}
+
+	for _, signer := range signers {
+		entry.Signers = append(entry.Signers, signer.String())
+	}
+
+	if err := getAuditLog().Append(entry); err != nil {
+		return fmt.Errorf("unable to record signature in audit log: %w", err)
+	}
+	return nil
+}
+
+// recordMessageSignature appends the signature of `message` by the vault
+// key `signer` to the audit log, the message itself is not recorded.
+func recordMessageSignature(signer solana.PublicKey, message []byte, signature solana.Signature) error {
+	sum := sha256.Sum256(message)
+	entry := &audit.Entry{
+		Command:          runningCommand,
+		VaultFile:        viper.GetString("global-vault-file"),
+		Signers:          []string{signer.String()},
+		Instructions:     []string{},
+		MessageHash:      hex.EncodeToString(sum[:]),
+		MessageSignature: signature.String(),
+	}
+
+	if err := getAuditLog().Append(entry); err != nil {
+		return fmt.Errorf("unable to record signature in audit log: %w", err)
+	}
+	return nil
+}
+
+// auditInstructions summarizes each instruction as its program, followed by
+// the funds or authority it moves when known.
+func auditInstructions(trx *solana.Transaction) []string {
+	movements := map[int]*policy.Movement{}
+	if summary, err := policy.Analyze(trx); err == nil {
+		for _, movement := range summary.Movements {
+			movements[movement.Index] = movement
+		}
+	}
+
+	out := []string{}
+	keys := trx.Message.AccountKeys
+	for idx, instruction := range trx.Message.Instructions {
+		program := "unknown program"
+		if int(instruction.ProgramIDIndex) < len(keys) {
+			program = keys[instruction.ProgramIDIndex].String()
+		}
+
+		line := fmt.Sprintf("#%d %s", idx+1, program)
+		if movement := movements[idx]; movement != nil {
+			line += ": " + movement.String()
+		}
+		out = append(out, line)
+	}
+	return out
+}
+
+// redactURL strips the credentials and query of an endpoint, RPC providers
+// commonly carry API keys there.
+func redactURL(in string) string {
+	u, err := url.Parse(in)
+	if err != nil {
+		return ""
+	}
+
+	u.User = nil
+	u.RawQuery = ""
+	u.Fragment = ""
+	return u.String()
+}
@@ -0,0 +1,106 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/streamingfast/slnc/audit"
+)
+
+var vaultAuditShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show the entries of the audit log",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		log := getAuditLog()
+		entries, err := log.Entries()
+		if err != nil {
+			return err
+		}
+
+		var signer string
+		if in := viper.GetString("vault-audit-show-cmd-signer"); in != "" {
+			key, err := resolveAddress(in)
+			if err != nil {
+				return fmt.Errorf("invalid --signer: %w", err)
+			}
+			signer = key.String()
+		}
+
+		out := &vaultAuditShowOutput{Entries: []*audit.Entry{}}
+		for _, entry := range entries {
+			if signer != "" && !containsString(entry.Signers, signer) {
+				continue
+			}
+			out.Entries = append(out.Entries, entry)
+		}
+
+		if limit := viper.GetInt("vault-audit-show-cmd-limit"); limit > 0 && len(out.Entries) > limit {
+			out.Entries = out.Entries[len(out.Entries)-limit:]
+		}
+
+		if len(out.Entries) == 0 {
+			printInfo("No entries in audit log %q\n", log.Filename())
+		}
+
+		return printOutput(out)
+	},
+}
+
+type vaultAuditShowOutput struct {
+	Entries []*audit.Entry `json:"entries"`
+}
+
+func (o *vaultAuditShowOutput) Text(w io.Writer) error {
+	for _, entry := range o.Entries {
+		fmt.Fprintf(w, "#%d %s %s\n", entry.Sequence, entry.Timestamp.Format(time.RFC3339), entry.Command)
+		if entry.TransactionSignature != "" {
+			fmt.Fprintf(w, "  Transaction: %s\n", entry.TransactionSignature)
+		}
+		if entry.MessageHash != "" {
+			fmt.Fprintf(w, "  Message: sha256 %s, signature %s\n", entry.MessageHash, entry.MessageSignature)
+		}
+		fmt.Fprintf(w, "  Signers: %s\n", strings.Join(entry.Signers, ", "))
+		for _, instruction := range entry.Instructions {
+			fmt.Fprintf(w, "  %s\n", instruction)
+		}
+		if entry.RPCEndpoint != "" {
+			fmt.Fprintf(w, "  RPC endpoint: %s\n", entry.RPCEndpoint)
+		}
+	}
+	return nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
+
+func init() {
+	vaultAuditCmd.AddCommand(vaultAuditShowCmd)
+
+	vaultAuditShowCmd.Flags().Int("limit", 0, "Only show the last entries, 0 means all of them")
+	vaultAuditShowCmd.Flags().String("signer", "", "Only show the entries signed by this key")
+}
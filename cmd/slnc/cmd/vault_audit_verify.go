@@ -0,0 +1,90 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+)
+
+var vaultAuditVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Check the hash chain of the audit log",
+	Long: `Check the hash chain of the audit log.
+
+Each entry holds the hash of the previous one, so an entry altered, removed
+or reordered breaks the chain of every entry after it. Entries cut off the
+end of the log leave a valid chain though, it's only detected by comparing
+the head hash printed here with one recorded earlier: keep it somewhere
+else than the audit log, after each verification for example.
+`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		log := getAuditLog()
+		out := &vaultAuditVerifyOutput{AuditLog: log.Filename()}
+
+		last, err := log.Verify()
+		if err != nil {
+			// Still reported on standard output so tooling parsing it sees
+			// the failure, the exit code being non-zero.
+			out.Error = err.Error()
+			if isMachineOutput() {
+				if err := printOutput(out); err != nil {
+					return err
+				}
+			}
+			return fmt.Errorf("audit log %q does not verify: %w", log.Filename(), err)
+		}
+
+		out.Verified = true
+		if last != nil {
+			out.Entries = last.Sequence
+			out.HeadHash = last.Hash
+		}
+
+		return printOutput(out)
+	},
+}
+
+func init() {
+	vaultAuditCmd.AddCommand(vaultAuditVerifyCmd)
+}
+
+type vaultAuditVerifyOutput struct {
+	AuditLog string `json:"audit_log"`
+	Verified bool   `json:"verified"`
+	Entries  uint64 `json:"entries"`
+	HeadHash string `json:"head_hash,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+func (o *vaultAuditVerifyOutput) Columns() []string {
+	return []string{"Audit Log", "Verified", "Entries", "Head Hash", "Error"}
+}
+func (o *vaultAuditVerifyOutput) Rows() [][]string {
+	return [][]string{{o.AuditLog, fmt.Sprintf("%t", o.Verified), fmt.Sprintf("%d", o.Entries), o.HeadHash, o.Error}}
+}
+
+func (o *vaultAuditVerifyOutput) Text(w io.Writer) error {
+	if o.Entries == 0 {
+		_, err := fmt.Fprintf(w, "Audit log %q is empty.\n", o.AuditLog)
+		return err
+	}
+
+	_, err := fmt.Fprintf(w, "Audit log %q verified, %d entries, head hash %s.\n", o.AuditLog, o.Entries, o.HeadHash)
+	return err
+}
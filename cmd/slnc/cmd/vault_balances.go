@@ -0,0 +1,297 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/streamingfast/slnc/vault"
+	"github.com/streamingfast/solana-go"
+	"github.com/streamingfast/solana-go/programs/metaplex"
+	"github.com/streamingfast/solana-go/programs/token"
+	"github.com/streamingfast/solana-go/rpc"
+	"go.uber.org/zap"
+)
+
+var vaultBalancesCmd = &cobra.Command{
+	Use:   "balances",
+	Short: "Report the SOL, token and NFT balances of the vault addresses",
+	Long: `Report the SOL, token and NFT balances of the vault addresses.
+
+Every key and watch-only address of the vault is reported with its SOL
+balance followed by one row per SPL token account it owns. A token whose
+mint has no decimals and a supply of one is reported as an NFT. The name
+comes from the Metaplex metadata of the mint, when it has some.
+
+The addresses come from the plaintext public key index of the vault file,
+the vault is not opened. Accounts are fetched in batches with
+'getMultipleAccounts', token accounts are listed with one
+'getTokenAccountsByOwner' request per address.
+`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var addresses []*vaultAddress
+		if isAgentMode() {
+			wallet, err := getWallet()
+			if err != nil {
+				return err
+			}
+			addresses = vaultAddresses(wallet)
+		} else {
+			v, err := loadPublicKeyIndex(viper.GetString("global-vault-file"))
+			if err != nil {
+				return err
+			}
+			addresses = indexAddresses(v)
+		}
+
+		metaplexMetaProgramId := viper.GetString("metaplex-global-meta-program-id")
+		metaProgramID, err := solana.PublicKeyFromBase58(metaplexMetaProgramId)
+		if err != nil {
+			return fmt.Errorf("unable to decode metaplex metadata programId %q: %w", metaplexMetaProgramId, err)
+		}
+
+		out, err := fetchVaultBalances(getClient(), metaProgramID, addresses, viper.GetBool("vault-balances-cmd-skip-empty"))
+		if err != nil {
+			return err
+		}
+
+		return printOutput(out)
+	},
+}
+
+func init() {
+	vaultCmd.AddCommand(vaultBalancesCmd)
+
+	vaultBalancesCmd.Flags().Bool("skip-empty", false, "Do not report empty token accounts")
+}
+
+const (
+	assetSOL   = "SOL"
+	assetToken = "token"
+	assetNFT   = "NFT"
+)
+
+type vaultAddress struct {
+	PublicKey solana.PublicKey
+	Label     string
+	WatchOnly bool
+}
+
+// vaultAddresses lists the keys of `v` followed by its watch-only addresses.
+func vaultAddresses(v *vault.Vault) (out []*vaultAddress) {
+	for _, key := range v.KeyBag {
+		address := &vaultAddress{PublicKey: key.PublicKey()}
+		if metadata := v.Metadata[address.PublicKey.String()]; metadata != nil {
+			address.Label = metadata.Label
+		}
+		out = append(out, address)
+	}
+
+	for _, watched := range v.WatchOnly {
+		out = append(out, &vaultAddress{PublicKey: watched.PublicKey, Label: watched.Label, WatchOnly: true})
+	}
+	return
+}
+
+// indexAddresses lists the keys of the public key index of `v`, which needs
+// not be opened, followed by its watch-only addresses.
+func indexAddresses(v *vault.Vault) (out []*vaultAddress) {
+	for _, entry := range v.PublicKeys {
+		out = append(out, &vaultAddress{PublicKey: entry.PublicKey, Label: entry.Label})
+	}
+
+	for _, watched := range v.WatchOnly {
+		out = append(out, &vaultAddress{PublicKey: watched.PublicKey, Label: watched.Label, WatchOnly: true})
+	}
+	return
+}
+
+type mintInfo struct {
+	Decimals uint8
+	Supply   uint64
+	Name     string
+}
+
+func (m *mintInfo) isNFT() bool {
+	return m.Decimals == 0 && m.Supply == 1
+}
+
+func fetchVaultBalances(rpcClient *rpc.Client, metaProgramID solana.PublicKey, addresses []*vaultAddress, skipEmpty bool) (vaultBalancesOutput, error) {
+	keys := make([]solana.PublicKey, len(addresses))
+	for i, address := range addresses {
+		keys[i] = address.PublicKey
+	}
+
+	accounts, err := fetchMultipleAccounts(rpcClient, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenAccounts := make([][]*token.Account, len(addresses))
+	var mints []solana.PublicKey
+	seenMints := map[solana.PublicKey]bool{}
+	for i, address := range addresses {
+		if tokenAccounts[i], err = fetchTokenAccountsByOwner(rpcClient, address.PublicKey); err != nil {
+			return nil, err
+		}
+
+		for _, account := range tokenAccounts[i] {
+			if !seenMints[account.Mint] {
+				seenMints[account.Mint] = true
+				mints = append(mints, account.Mint)
+			}
+		}
+	}
+
+	infos, err := fetchMintInfos(rpcClient, metaProgramID, mints)
+	if err != nil {
+		return nil, err
+	}
+
+	out := vaultBalancesOutput{}
+	for i, address := range addresses {
+		var lamports uint64
+		if accounts[i] != nil {
+			lamports = accounts[i].Lamports
+		}
+
+		out = append(out, &vaultBalanceOutput{
+			Address:   address.PublicKey.String(),
+			Label:     address.Label,
+			WatchOnly: address.WatchOnly,
+			Asset:     assetSOL,
+			Amount:    formatTokenAmount(lamports, 9),
+		})
+
+		for _, account := range tokenAccounts[i] {
+			if skipEmpty && account.Amount == 0 {
+				continue
+			}
+
+			info := infos[account.Mint]
+			balance := &vaultBalanceOutput{
+				Address:      address.PublicKey.String(),
+				Label:        address.Label,
+				WatchOnly:    address.WatchOnly,
+				Asset:        assetToken,
+				Mint:         account.Mint.String(),
+				TokenAccount: account.Key.String(),
+				Amount:       fmt.Sprintf("%d", account.Amount),
+			}
+			if info != nil {
+				if info.isNFT() {
+					balance.Asset = assetNFT
+				}
+				balance.Name = info.Name
+				balance.Amount = formatTokenAmount(uint64(account.Amount), info.Decimals)
+			}
+
+			out = append(out, balance)
+		}
+	}
+
+	return out, nil
+}
+
+// fetchMintInfos retrieves the decimals and supply of `mints` along with
+// the name from their Metaplex metadata. Mints that can't be retrieved are
+// left out of the returned map.
+func fetchMintInfos(rpcClient *rpc.Client, metaProgramID solana.PublicKey, mints []solana.PublicKey) (map[solana.PublicKey]*mintInfo, error) {
+	accounts, err := fetchMultipleAccounts(rpcClient, mints)
+	if err != nil {
+		return nil, err
+	}
+
+	metadataKeys := make([]solana.PublicKey, len(mints))
+	for i, mint := range mints {
+		if metadataKeys[i], err = metaplex.DeriveMetadataPublicKey(metaProgramID, mint); err != nil {
+			return nil, fmt.Errorf("unable to derive metadata address of mint %s: %w", mint, err)
+		}
+	}
+
+	metadataAccounts, err := fetchMultipleAccounts(rpcClient, metadataKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	out := map[solana.PublicKey]*mintInfo{}
+	for i, mint := range mints {
+		if accounts[i] == nil {
+			zlog.Debug("mint not found", zap.Stringer("mint", mint))
+			continue
+		}
+
+		decoded := &token.Mint{}
+		if err := decoded.Decode(accounts[i].Data); err != nil {
+			zlog.Debug("unable to decode mint", zap.Stringer("mint", mint), zap.Error(err))
+			continue
+		}
+
+		info := &mintInfo{Decimals: decoded.Decimals, Supply: uint64(decoded.Supply)}
+		if metadataAccount := metadataAccounts[i]; metadataAccount != nil && metadataAccount.Owner == metaProgramID {
+			metadata := &metaplex.Metadata{}
+			if err := metadata.Decode(metadataAccount.Data); err != nil {
+				zlog.Debug("unable to decode metadata", zap.Stringer("mint", mint), zap.Error(err))
+			} else {
+				info.Name = metadata.Data.Name
+			}
+		}
+
+		out[mint] = info
+	}
+
+	return out, nil
+}
+
+// formatTokenAmount formats `amount` base units of a token having
+// `decimals` decimals.
+func formatTokenAmount(amount uint64, decimals uint8) string {
+	if decimals == 0 {
+		return fmt.Sprintf("%d", amount)
+	}
+
+	digits := fmt.Sprintf("%0*d", int(decimals)+1, amount)
+	whole, fraction := digits[:len(digits)-int(decimals)], digits[len(digits)-int(decimals):]
+	return whole + "." + fraction
+}
+
+// vaultBalanceOutput is a SOL or token balance of a vault address, amounts
+// are strings since they can be over what a JSON number can hold precisely.
+type vaultBalanceOutput struct {
+	Address      string `json:"address"`
+	Label        string `json:"label,omitempty"`
+	WatchOnly    bool   `json:"watch_only,omitempty"`
+	Asset        string `json:"asset"`
+	Mint         string `json:"mint,omitempty"`
+	TokenAccount string `json:"token_account,omitempty"`
+	Name         string `json:"name,omitempty"`
+	Amount       string `json:"amount"`
+}
+
+type vaultBalancesOutput []*vaultBalanceOutput
+
+func (o vaultBalancesOutput) Columns() []string {
+	return []string{"Address", "Label", "Watch Only", "Asset", "Mint", "Name", "Amount"}
+}
+
+func (o vaultBalancesOutput) Rows() (out [][]string) {
+	for _, b := range o {
+		out = append(out, []string{b.Address, b.Label, fmt.Sprintf("%t", b.WatchOnly), b.Asset, b.Mint, b.Name, b.Amount})
+	}
+	return
+}
@@ -0,0 +1,66 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var vaultChangePassphraseCmd = &cobra.Command{
+	Use:   "change-passphrase",
+	Short: "Change the passphrase of a passphrase vault",
+	Long: `Change the passphrase of a passphrase vault.
+
+The vault is opened with its current passphrase then sealed with the new
+one. The vault file is replaced atomically, the original one is kept next
+to it with a '.bak-<timestamp>' suffix.
+`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		walletFile := viper.GetString("global-vault-file")
+		v, err := loadVaultFile(walletFile)
+		if err != nil {
+			return err
+		}
+
+		if v.SecretBoxWrap != "passphrase" {
+			return fmt.Errorf("vault %q is of type %q, only passphrase vaults have a passphrase, see `slnc vault rewrap`", walletFile, v.SecretBoxWrap)
+		}
+
+		if err := openVault(v); err != nil {
+			return err
+		}
+
+		boxer, err := newPassphraseBoxer()
+		if err != nil {
+			return err
+		}
+
+		out, err := rewrapVault(v, walletFile, boxer, "", false)
+		if err != nil {
+			return err
+		}
+
+		out.FromType = v.SecretBoxWrap
+		return printOutput(out)
+	},
+}
+
+func init() {
+	vaultCmd.AddCommand(vaultChangePassphraseCmd)
+}
@@ -17,6 +17,9 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"path"
+	"path/filepath"
+	"strings"
 
 	"github.com/streamingfast/solana-go"
 
@@ -43,32 +46,87 @@ This uses the default --vault-type=passphrase
 
 You can create a Google Cloud Platform KMS-wrapped vault with:
 
-    cmd vault create --keys=2 --vault-type=kms-gcp --kms-gcp-keypath projects/.../locations/.../keyRings/.../cryptoKeys/name
+    cmd vault create --keys=2 --vault-type=kms-gcp --kms-key projects/.../locations/.../keyRings/.../cryptoKeys/name
+
+an AWS KMS-wrapped vault with:
+
+    cmd vault create --keys=2 --vault-type=kms-aws --kms-key arn:aws:kms:us-east-1:111122223333:key/...
+
+or a HashiCorp Vault Transit-wrapped vault, the token being read from
+VAULT_TOKEN and the server from VAULT_ADDR (or --kms-endpoint), with:
+
+    cmd vault create --keys=2 --vault-type=hashicorp-transit --kms-key transit/slnc
+
+You can create a vault whose keys are derived from a BIP39 mnemonic with:
+
+    cmd vault create --keys=2 --mnemonic
+
+The keys are derived along Solana's m/44'/501'/n'/0' path, like Phantom and
+'solana-keygen --derivation-path' do, writing down the mnemonic shown is a
+paper backup of every key derived in the vault (see 'vault derive').
+--import-mnemonic derives the keys of an existing mnemonic instead.
+
+You can create a vault that no single person can open with:
+
+    cmd vault create --keys=2 --vault-type=shamir --shamir-parts=5 --shamir-threshold=3
+
+The vault key is split in 5 share files, written next to the vault (see
+--shamir-share-dir), any 3 of them are required to open the vault. Hand
+each share file to a different person, --shamir-protect-shares has each
+of them protect their share with their own passphrase.
 
 You can then use this vault for the different cmd operations.`,
 	RunE: func(cmd *cobra.Command, args []string) (err error) {
 		walletFile := viper.GetString("global-vault-file")
 
-		if _, err := os.Stat(walletFile); err == nil {
-			fmt.Printf("Wallet file %q already exists, rename it before running `cmd vault create`.\n", walletFile)
-			os.Exit(1)
+		if exists, err := vault.VaultExists(cmd.Context(), walletFile); err != nil {
+			return fmt.Errorf("unable to check vault file: %w", err)
+		} else if exists {
+			return fmt.Errorf("wallet file %q already exists, rename it before running `cmd vault create`", walletFile)
 		}
 
 		var wrapType = viper.GetString("vault-create-cmd-vault-type")
 		var boxer vault.SecretBoxer
 
-		kmsGCPKeypath := viper.GetString("global-kms-gcp-keypath")
-		if wrapType == "kms-gcp" && kmsGCPKeypath == "" {
-			return fmt.Errorf("missing parameter: --kms-gcp-keypath is required with --vault-type=kms-gcp")
+		kmsConfig := getKMSConfig()
+		if isKMSVaultType(wrapType) && kmsConfig.Key == "" {
+			return fmt.Errorf("missing parameter: --kms-key is required with --vault-type=%s", wrapType)
+		}
+
+		doImport := viper.GetBool("vault-create-cmd-import")
+		newMnemonic := viper.GetBool("vault-create-cmd-mnemonic")
+		importMnemonic := viper.GetBool("vault-create-cmd-import-mnemonic")
+		if (doImport && (newMnemonic || importMnemonic)) || (newMnemonic && importMnemonic) {
+			return fmt.Errorf("only one of --import, --mnemonic and --import-mnemonic can be used")
 		}
 
 		v := vault.NewVault()
+		if newMnemonic || importMnemonic {
+			if v, err = newMnemonicVault(newMnemonic); err != nil {
+				return err
+			}
+		}
 		v.Comment = viper.GetString("vault-create-cmd-comment")
 
 		var newKeys []solana.PublicKey
 
-		doImport := viper.GetBool("vault-create-cmd-import")
-		if doImport {
+		if v.HasSeed() {
+			numKeys := viper.GetInt("vault-create-cmd-keys")
+			if numKeys == 0 {
+				numKeys = 1
+			}
+
+			for i := 0; i < numKeys; i++ {
+				pubKey, err := v.DeriveKey(uint32(i))
+				if err != nil {
+					return fmt.Errorf("unable to derive key #%d: %w", i, err)
+				}
+
+				newKeys = append(newKeys, pubKey)
+			}
+			printInfo("Derived %d keys. They will be shown when encrypted and written to disk successfully.\n", len(newKeys))
+
+		} else if doImport {
 			privateKeys, err := capturePrivateKeys()
 			if err != nil {
 				return fmt.Errorf("failed enterign private key: %w", err)
@@ -79,7 +137,7 @@ You can then use this vault for the different cmd operations.`,
 				newKeys = append(newKeys, privateKey.PublicKey())
 			}
 
-			fmt.Printf("Imported %d keys.\n", len(newKeys))
+			printInfo("Imported %d keys.\n", len(newKeys))
 
 		} else {
 			numKeys := viper.GetInt("vault-create-cmd-keys")
@@ -96,19 +154,22 @@ You can then use this vault for the different cmd operations.`,
 
 				newKeys = append(newKeys, pubKey)
 			}
-			fmt.Printf("Created %d keys. They will be shown when encrypted and written to disk successfully.\n", len(newKeys))
+			printInfo("Created %d keys. They will be shown when encrypted and written to disk successfully.\n", len(newKeys))
 		}
 
 		switch wrapType {
-		case "kms-gcp":
-			fmt.Println("Doing the KMS GCP dance")
-			boxer = vault.NewKMSGCPBoxer(kmsGCPKeypath)
+		case "kms-gcp", "kms-aws", "hashicorp-transit":
+			printInfo("Sealing the vault with %s key %q\n", wrapType, kmsConfig.Key)
+			boxer = vault.NewKMSBoxer(wrapType, kmsConfig)
+
+		case "shamir":
+			boxer = vault.NewShamirBoxer(viper.GetInt("vault-create-cmd-shamir-parts"), viper.GetInt("vault-create-cmd-shamir-threshold"))
 
 		case "passphrase":
-			fmt.Println("")
-			fmt.Println("You will be asked to provide a passphrase to secure your newly created vault.")
-			fmt.Println("Make sure you make it long and strong.")
-			fmt.Println("")
+			printInfo("\n")
+			printInfo("You will be asked to provide a passphrase to secure your newly created vault.\n")
+			printInfo("Make sure you make it long and strong.\n")
+			printInfo("\n")
 			if envVal := os.Getenv("SLNC_GLOBAL_INSECURE_VAULT_PASSPHRASE"); envVal != "" {
 				boxer = vault.NewPassphraseBoxer(envVal)
 			} else {
@@ -121,20 +182,31 @@ You can then use this vault for the different cmd operations.`,
 			}
 
 		default:
-			fmt.Printf(`Invalid vault type: %q, please use one of: "passphrase", "kms-gcp"\n`, wrapType)
-			os.Exit(1)
+			return fmt.Errorf(`invalid vault type: %q, please use one of: "passphrase", "kms-gcp", "kms-aws", "hashicorp-transit", "shamir"`, wrapType)
 		}
 
 		if err = v.Seal(boxer); err != nil {
 			return fmt.Errorf("failed to seal the vault: %w", err)
 		}
 
-		if err = v.WriteToFile(walletFile); err != nil {
+		// Shares are written first, a vault without its shares could never
+		// be opened
+		var shareFiles []string
+		if shamirBoxer, ok := boxer.(*vault.ShamirBoxer); ok {
+			shareDir := viper.GetString("vault-create-cmd-shamir-share-dir")
+			protect := viper.GetBool("vault-create-cmd-shamir-protect-shares")
+			if shareFiles, err = writeShamirShares(walletFile, shamirBoxer, shareDir, protect); err != nil {
+				return err
+			}
+		}
+
+		if err = v.Save(cmd.Context(), walletFile); err != nil {
 			return fmt.Errorf("failed to write vault file: %w", err)
 		}
 
-		vaultWrittenReport(walletFile, newKeys, len(v.KeyBag))
-		return nil
+		out := newVaultWrittenOutput(walletFile, newKeys, len(v.KeyBag))
+		out.ShareFiles = shareFiles
+		return printOutput(out)
 	},
 }
 
@@ -144,5 +216,138 @@ func init() {
 	vaultCreateCmd.Flags().IntP("keys", "k", 0, "Number of keypairs to create")
 	vaultCreateCmd.Flags().BoolP("import", "i", false, "Whether to import keys instead of creating them. This takes precedence over --keys, and private keys will be inputted on the command line.")
 	vaultCreateCmd.Flags().StringP("comment", "", "", "Comment field in the vault's json file.")
-	vaultCreateCmd.Flags().StringP("vault-type", "t", "passphrase", "Vault type. One of: passphrase, kms-gcp")
+	vaultCreateCmd.Flags().Bool("mnemonic", false, "Derive the keys from a new BIP39 mnemonic, shown once for a paper backup of every key of the vault")
+	vaultCreateCmd.Flags().Bool("import-mnemonic", false, "Derive the keys from an existing BIP39 mnemonic inputted on the command line, like the one of a Phantom wallet")
+	vaultCreateCmd.Flags().Int("mnemonic-words", 24, "Number of words of the new mnemonic, 12 or 24, with --mnemonic")
+	vaultCreateCmd.Flags().Bool("mnemonic-passphrase", false, "Ask for the optional BIP39 passphrase protecting the mnemonic, with --mnemonic or --import-mnemonic")
+	vaultCreateCmd.Flags().StringP("vault-type", "t", "passphrase", "Vault type. One of: passphrase, kms-gcp, kms-aws, hashicorp-transit, shamir")
+	vaultCreateCmd.Flags().Int("shamir-parts", 3, "Number of shares the vault key is split in, with --vault-type=shamir")
+	vaultCreateCmd.Flags().Int("shamir-threshold", 2, "Number of shares required to open the vault, with --vault-type=shamir")
+	vaultCreateCmd.Flags().String("shamir-share-dir", "", "Directory where the share files are written, with --vault-type=shamir (defaults to the vault file directory)")
+	vaultCreateCmd.Flags().Bool("shamir-protect-shares", false, "Ask a passphrase for each share file, with --vault-type=shamir")
+}
+
+// writeShamirShares writes the share files of a freshly sealed vault in
+// `dir`, the vault file directory when empty, and returns their paths.
+func writeShamirShares(walletFile string, boxer *vault.ShamirBoxer, dir string, protect bool) ([]string, error) {
+	shares := boxer.Shares()
+	parts := boxer.Parts()
+	threshold := boxer.Threshold()
+
+	name := path.Base(walletFile)
+	localPath, local := vault.LocalPath(walletFile)
+	if local {
+		name = filepath.Base(localPath)
+	}
+
+	if dir == "" {
+		if !local {
+			return nil, fmt.Errorf("the vault file is in object storage, set the local directory of the share files with --shamir-share-dir")
+		}
+		dir = filepath.Dir(localPath)
+	}
+	base := strings.TrimSuffix(name, filepath.Ext(name))
+
+	if protect {
+		printInfo("\n")
+		printInfo("You will be asked to provide a passphrase for each share, have each share holder type their own.\n")
+		printInfo("\n")
+	}
+
+	printInfo("\n")
+	printInfo("Vault key split in %d shares, %d of them are required to open the vault:\n", parts, threshold)
+	var filenames []string
+	for i, share := range shares {
+		var shareBoxer vault.SecretBoxer
+		if protect {
+			printInfo("Share #%d\n", i+1)
+			password, err := cli.GetEncryptPassphrase()
+			if err != nil {
+				return nil, fmt.Errorf("failed to get password input: %w", err)
+			}
+			shareBoxer = vault.NewPassphraseBoxer(password)
+		}
+
+		shareFile, err := vault.NewShamirShareFile(i+1, parts, threshold, share, shareBoxer)
+		if err != nil {
+			return nil, fmt.Errorf("unable to seal share #%d: %w", i+1, err)
+		}
+
+		filename := filepath.Join(dir, fmt.Sprintf("%s.share-%d-of-%d.json", base, i+1, parts))
+		if err := shareFile.WriteToFile(filename); err != nil {
+			return nil, fmt.Errorf("unable to write share #%d: %w", i+1, err)
+		}
+		printInfo("- %s\n", filename)
+		filenames = append(filenames, filename)
+	}
+
+	return filenames, nil
+}
+
+func isKMSVaultType(wrapType string) bool {
+	switch wrapType {
+	case "kms-gcp", "kms-aws", "hashicorp-transit":
+		return true
+	}
+	return false
+}
+
+// newMnemonicVault returns a vault whose keys are derived from a new
+// mnemonic, shown to be written down, or from one inputted by the user.
+func newMnemonicVault(generate bool) (*vault.Vault, error) {
+	var mnemonic string
+	var err error
+	if generate {
+		mnemonic, err = vault.NewMnemonic(viper.GetInt("vault-create-cmd-mnemonic-words"))
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		mnemonic, err = cli.GetPassword("Enter your BIP39 mnemonic (words separated by spaces): ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to get mnemonic input: %w", err)
+		}
+	}
+
+	var passphrase string
+	if viper.GetBool("vault-create-cmd-mnemonic-passphrase") {
+		passphrase, err = cli.GetPassword("Enter the BIP39 passphrase of the mnemonic: ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to get BIP39 passphrase input: %w", err)
+		}
+
+		if generate {
+			confirm, err := cli.GetPassword("Confirm the BIP39 passphrase: ")
+			if err != nil {
+				return nil, fmt.Errorf("failed to get BIP39 passphrase input: %w", err)
+			}
+			if confirm != passphrase {
+				return nil, fmt.Errorf("BIP39 passphrases do not match")
+			}
+		}
+	}
+
+	v, err := vault.NewVaultFromMnemonic(mnemonic, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	if generate {
+		printInfo("\n")
+		printInfo("PLEASE READ:\n")
+		printInfo("Here is the mnemonic the keys of the vault are derived from, write it down and\n")
+		printInfo("keep it somewhere safe, it's the only way to recover the keys without the vault.\n")
+		printInfo("It will NOT be shown again.\n")
+		printInfo("\n")
+		for i, word := range strings.Fields(mnemonic) {
+			printInfo("  %2d. %s\n", i+1, word)
+		}
+		printInfo("\n")
+		if viper.GetBool("vault-create-cmd-mnemonic-passphrase") {
+			printInfo("The BIP39 passphrase you entered is also required to recover the keys.\n")
+			printInfo("\n")
+		}
+	}
+
+	return v, nil
 }
@@ -0,0 +1,100 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/streamingfast/slnc/vault"
+)
+
+var vaultDeriveCmd = &cobra.Command{
+	Use:   "derive",
+	Short: "Derive a new key from the mnemonic of the vault",
+	Long: `Derive a new key from the mnemonic of the vault.
+
+The key at --index of Solana's m/44'/501'/index'/0' path is derived from the
+seed of the vault and added to it, the next unused index by default. Only
+vaults created with --mnemonic or --import-mnemonic have a seed.
+`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		wallet, err := getWallet()
+		if err != nil {
+			return err
+		}
+
+		if !wallet.HasSeed() {
+			return fmt.Errorf("vault has no seed, create it with --mnemonic or --import-mnemonic to derive keys")
+		}
+
+		index := wallet.NextDerivationIndex()
+		if cmd.Flags().Changed("index") {
+			index = viper.GetUint32("vault-derive-cmd-index")
+		}
+
+		pubKey, err := wallet.DeriveKey(index)
+		if err != nil {
+			return err
+		}
+
+		label := viper.GetString("vault-derive-cmd-label")
+		if label != "" {
+			if err := wallet.SetLabel(pubKey, label); err != nil {
+				return err
+			}
+		}
+
+		if err := writeOpenedWallet(false); err != nil {
+			return err
+		}
+
+		return printOutput(&vaultDeriveOutput{
+			PublicKey:      pubKey.String(),
+			Label:          label,
+			DerivationPath: vault.SolanaDerivationPath(index),
+			TotalKeys:      len(wallet.KeyBag),
+		})
+	},
+}
+
+type vaultDeriveOutput struct {
+	PublicKey      string `json:"public_key"`
+	Label          string `json:"label,omitempty"`
+	DerivationPath string `json:"derivation_path"`
+	TotalKeys      int    `json:"total_keys"`
+}
+
+func (o *vaultDeriveOutput) Columns() []string {
+	return []string{"Public Key", "Label", "Derivation Path"}
+}
+func (o *vaultDeriveOutput) Rows() [][]string {
+	return [][]string{{o.PublicKey, o.Label, o.DerivationPath}}
+}
+
+func (o *vaultDeriveOutput) Text(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "Derived key %s at %s, %d keys stored.\n", o.PublicKey, o.DerivationPath, o.TotalKeys)
+	return err
+}
+
+func init() {
+	vaultCmd.AddCommand(vaultDeriveCmd)
+
+	vaultDeriveCmd.Flags().Uint32("index", 0, "Index of the key to derive (defaults to the next unused index)")
+	vaultDeriveCmd.Flags().String("label", "", "Label of the derived key")
+}
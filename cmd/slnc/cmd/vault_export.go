@@ -15,20 +15,75 @@
 package cmd
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/streamingfast/slnc/vault"
 )
 
 // vaultExportCommand represents the export command
 var vaultExportCommand = &cobra.Command{
 	Use:   "export",
 	Short: "Export private keys (and corresponding public keys) inside a Solana vault.",
-	Run: func(cmd *cobra.Command, args []string) {
+	Long: `Export private keys (and corresponding public keys) inside a Solana vault.
+
+With --keypair-dir, each key is written instead as a solana-keygen keypair
+file named after its public key, '{public_key}.json', readable only by the
+current user. The files can be handed to the 'solana' or 'anchor' tooling:
+
+    slnc vault export --keypair-dir ./keys
+    solana balance --keypair ./keys/9N54GQg2URnpThxHzV2curbYFN11afGDjodV3Qy7yHPQ.json
+
+Existing files are never overwritten.
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if isAgentMode() {
+			return fmt.Errorf("private keys never leave the signing agent, run the command without --agent")
+		}
+
 		vault := mustGetWallet()
 
-		vault.PrintPrivateKeys()
+		if dir := viper.GetString("vault-export-cmd-keypair-dir"); dir != "" {
+			return exportKeypairFiles(vault, dir)
+		}
+
+		return printOutput(newVaultKeysOutput(vault, true))
 	},
 }
 
 func init() {
 	vaultCmd.AddCommand(vaultExportCommand)
+
+	vaultExportCommand.Flags().String("keypair-dir", "", "Directory where a solana-keygen keypair file is written for each key, instead of printing the keys")
+}
+
+func exportKeypairFiles(v *vault.Vault, dir string) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("unable to create keypair directory: %w", err)
+	}
+
+	for _, privateKey := range v.KeyBag {
+		filename := filepath.Join(dir, privateKey.PublicKey().String()+".json")
+
+		file, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err != nil {
+			return fmt.Errorf("unable to create keypair file: %w", err)
+		}
+
+		if _, err := file.Write(encodeKeypair(privateKey)); err != nil {
+			file.Close()
+			return fmt.Errorf("unable to write keypair file %q: %w", filename, err)
+		}
+
+		if err := file.Close(); err != nil {
+			return fmt.Errorf("unable to write keypair file %q: %w", filename, err)
+		}
+
+		printInfo("Wrote %s%s to %q\n", privateKey.PublicKey(), labelSuffix(v.KeyMetadata(privateKey.PublicKey()).Label), filename)
+	}
+
+	return nil
 }
@@ -0,0 +1,313 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"os/signal"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/streamingfast/solana-go"
+)
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// grindProgressInterval is how often the progress of `vault grind` is
+// reported.
+const grindProgressInterval = 5 * time.Second
+
+var vaultGrindCmd = &cobra.Command{
+	Use:   "grind",
+	Short: "Search for keys whose address has a given prefix or suffix",
+	Long: `Search for keys whose address has a given prefix or suffix.
+
+Random keys are generated on --threads workers until --count of them match
+--prefix and --suffix, the matching keys being added to the vault as soon
+as the search is over. They are never written anywhere unencrypted.
+
+The search time grows 58 times with each character of the pattern, progress
+is reported along with an estimate of the time left based on the measured
+rate. Interrupting the search still adds the keys found so far.
+
+    slnc vault grind --prefix mint --ignore-case --label brand-mint
+`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		matcher, err := newAddressMatcher(
+			viper.GetString("vault-grind-cmd-prefix"),
+			viper.GetString("vault-grind-cmd-suffix"),
+			viper.GetBool("vault-grind-cmd-ignore-case"),
+		)
+		if err != nil {
+			return err
+		}
+
+		count := viper.GetInt("vault-grind-cmd-count")
+		if count < 1 {
+			return fmt.Errorf("--count must be at least 1")
+		}
+
+		label := viper.GetString("vault-grind-cmd-label")
+		if label != "" && count > 1 {
+			return fmt.Errorf("--label can only be used with a --count of 1")
+		}
+
+		threads := viper.GetInt("vault-grind-cmd-threads")
+		if threads < 1 {
+			threads = runtime.NumCPU()
+		}
+
+		if isAgentMode() {
+			return fmt.Errorf("the vault can't be modified through the signing agent, run the command without --agent")
+		}
+
+		// Opened first so the passphrase or KMS prompts happen before a
+		// search that can last hours.
+		wallet, err := getWallet()
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithCancel(cmd.Context())
+		defer cancel()
+
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		defer signal.Stop(signals)
+		go func() {
+			select {
+			case <-signals:
+				printInfo("Interrupted, stopping the search\n")
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+
+		printInfo("Searching for %d key(s) matching %s on %d threads, about %s attempts expected per key\n", count, matcher, threads, formatAttempts(matcher.expectedAttempts()))
+
+		keys := grindKeys(ctx, matcher, count, threads)
+		if len(keys) == 0 {
+			return fmt.Errorf("search interrupted before a matching key was found")
+		}
+
+		var newKeys []solana.PublicKey
+		for _, key := range keys {
+			pub := wallet.AddPrivateKey(key)
+			if label != "" {
+				if err := wallet.SetLabel(pub, label); err != nil {
+					return err
+				}
+			}
+			newKeys = append(newKeys, pub)
+		}
+
+		// The previous vault file is kept, it may predate a search of hours
+		if err := writeOpenedWallet(true); err != nil {
+			return err
+		}
+
+		return printOutput(newVaultWrittenOutput(viper.GetString("global-vault-file"), newKeys, len(wallet.KeyBag)))
+	},
+}
+
+func init() {
+	vaultCmd.AddCommand(vaultGrindCmd)
+
+	vaultGrindCmd.Flags().String("prefix", "", "Characters the address must start with")
+	vaultGrindCmd.Flags().String("suffix", "", "Characters the address must end with")
+	vaultGrindCmd.Flags().Bool("ignore-case", false, "Match the prefix and suffix regardless of case")
+	vaultGrindCmd.Flags().Int("threads", 0, "Number of search workers (defaults to the number of CPUs)")
+	vaultGrindCmd.Flags().Int("count", 1, "Number of matching keys to add to the vault")
+	vaultGrindCmd.Flags().String("label", "", "Label of the matching key")
+}
+
+type addressMatcher struct {
+	prefix     string
+	suffix     string
+	ignoreCase bool
+}
+
+// newAddressMatcher validates `prefix` and `suffix` against the base58
+// alphabet, refusing patterns no address can match.
+func newAddressMatcher(prefix, suffix string, ignoreCase bool) (*addressMatcher, error) {
+	if prefix == "" && suffix == "" {
+		return nil, fmt.Errorf("at least one of --prefix or --suffix is required")
+	}
+
+	if len(prefix)+len(suffix) > 44 {
+		return nil, fmt.Errorf("prefix and suffix are longer than an address")
+	}
+
+	for _, pattern := range []string{prefix, suffix} {
+		for _, c := range pattern {
+			if len(base58Variants(c, ignoreCase)) == 0 {
+				return nil, fmt.Errorf("invalid character %q in %q, addresses only use the base58 alphabet %s", c, pattern, base58Alphabet)
+			}
+		}
+	}
+
+	m := &addressMatcher{prefix: prefix, suffix: suffix, ignoreCase: ignoreCase}
+	if ignoreCase {
+		m.prefix = strings.ToLower(prefix)
+		m.suffix = strings.ToLower(suffix)
+	}
+	return m, nil
+}
+
+// base58Variants returns the characters of the base58 alphabet matching
+// `c`, both cases when `ignoreCase` is set.
+func base58Variants(c rune, ignoreCase bool) (out []rune) {
+	candidates := []rune{c}
+	if ignoreCase {
+		candidates = []rune{[]rune(strings.ToLower(string(c)))[0], []rune(strings.ToUpper(string(c)))[0]}
+		if candidates[0] == candidates[1] {
+			candidates = candidates[:1]
+		}
+	}
+
+	for _, candidate := range candidates {
+		if strings.ContainsRune(base58Alphabet, candidate) {
+			out = append(out, candidate)
+		}
+	}
+	return
+}
+
+func (m *addressMatcher) matches(address string) bool {
+	if m.ignoreCase {
+		address = strings.ToLower(address)
+	}
+	return strings.HasPrefix(address, m.prefix) && strings.HasSuffix(address, m.suffix)
+}
+
+// expectedAttempts estimates the number of keys to generate to find a
+// match, each character of the pattern being one of 58.
+func (m *addressMatcher) expectedAttempts() float64 {
+	attempts := 1.0
+	for _, c := range m.prefix + m.suffix {
+		attempts *= 58 / float64(len(base58Variants(c, m.ignoreCase)))
+	}
+	return attempts
+}
+
+func (m *addressMatcher) String() string {
+	var parts []string
+	if m.prefix != "" {
+		parts = append(parts, fmt.Sprintf("prefix %q", m.prefix))
+	}
+	if m.suffix != "" {
+		parts = append(parts, fmt.Sprintf("suffix %q", m.suffix))
+	}
+	if m.ignoreCase {
+		parts = append(parts, "ignoring case")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// grindKeys generates random keys on `threads` workers until `count` of
+// them match `matcher` or `ctx` is done, it returns the matching keys found.
+func grindKeys(ctx context.Context, matcher *addressMatcher, count int, threads int) []solana.PrivateKey {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var attempts uint64
+	var lock sync.Mutex
+	var found []solana.PrivateKey
+
+	var wg sync.WaitGroup
+	for i := 0; i < threads; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				pub, key, err := solana.NewRandomPrivateKey()
+				if err != nil {
+					continue
+				}
+				atomic.AddUint64(&attempts, 1)
+
+				if !matcher.matches(pub.String()) {
+					continue
+				}
+
+				lock.Lock()
+				if len(found) < count {
+					found = append(found, key)
+					printInfo("Found %s (%d/%d)\n", pub, len(found), count)
+				}
+				if len(found) == count {
+					cancel()
+				}
+				lock.Unlock()
+			}
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	start := time.Now()
+	ticker := time.NewTicker(grindProgressInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return found
+		case <-ticker.C:
+			lock.Lock()
+			remaining := count - len(found)
+			lock.Unlock()
+
+			tried := atomic.LoadUint64(&attempts)
+			rate := float64(tried) / time.Since(start).Seconds()
+			eta := "unknown"
+			if rate > 0 {
+				eta = formatETA(float64(remaining) * matcher.expectedAttempts() / rate)
+			}
+			printInfo("Tried %d keys (%.0f keys/s), about %s left\n", tried, rate, eta)
+		}
+	}
+}
+
+// formatETA formats a duration of `seconds`, switching to years past what a
+// `time.Duration` holds comfortably.
+func formatETA(seconds float64) string {
+	const secondsPerYear = 365 * 24 * 3600
+	if seconds >= 100*secondsPerYear {
+		return fmt.Sprintf("%.3g years", seconds/secondsPerYear)
+	}
+	return time.Duration(seconds * float64(time.Second)).Round(time.Second).String()
+}
+
+func formatAttempts(attempts float64) string {
+	if attempts >= math.MaxInt64 {
+		return fmt.Sprintf("%.3g", attempts)
+	}
+	return fmt.Sprintf("%d", int64(attempts))
+}
@@ -0,0 +1,110 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/streamingfast/solana-go"
+)
+
+var vaultImportCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Add the keys of solana-keygen keypair files to the vault",
+	Long: `Add the keys of solana-keygen keypair files to the vault.
+
+Each --keypair-file is a JSON array of the 64 bytes of a key, as written by
+'solana-keygen new', read from a local path or a dstore URL:
+
+    slnc vault import --keypair-file ~/.config/solana/id.json
+    slnc vault import --keypair-file gs://bucket/keys/deployer.json,s3://bucket/keys/payer.json
+
+Keys already in the vault are skipped. The keypair files are left untouched,
+delete them once the vault is backed up.
+`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		keypairFiles := viper.GetStringSlice("vault-import-cmd-keypair-file")
+		if len(keypairFiles) == 0 {
+			return fmt.Errorf("specify at least one --keypair-file")
+		}
+
+		var privateKeys []solana.PrivateKey
+		for _, keypairFile := range keypairFiles {
+			privateKey, err := readKeypairPrivateKey(cmd, keypairFile)
+			if err != nil {
+				return fmt.Errorf("keypair file %q: %w", keypairFile, err)
+			}
+			privateKeys = append(privateKeys, privateKey)
+		}
+
+		wallet, err := getWallet()
+		if err != nil {
+			return err
+		}
+
+		var newKeys []solana.PublicKey
+		var skippedKeys []string
+		for idx, privateKey := range privateKeys {
+			if _, found := wallet.PrivateKey(privateKey.PublicKey()); found {
+				printInfo("Key %s of %q is already in the vault, skipping.\n", privateKey.PublicKey(), keypairFiles[idx])
+				skippedKeys = append(skippedKeys, privateKey.PublicKey().String())
+				continue
+			}
+			newKeys = append(newKeys, wallet.AddPrivateKey(privateKey))
+		}
+
+		if len(newKeys) != 0 {
+			if err := writeOpenedWallet(false); err != nil {
+				return err
+			}
+		}
+
+		out := newVaultWrittenOutput(viper.GetString("global-vault-file"), newKeys, len(wallet.KeyBag))
+		out.SkippedKeys = skippedKeys
+		out.untouched = len(newKeys) == 0
+		return printOutput(out)
+	},
+}
+
+func init() {
+	vaultCmd.AddCommand(vaultImportCmd)
+
+	vaultImportCmd.Flags().StringSlice("keypair-file", []string{}, "solana-keygen keypair file to import, a local path or a dstore URL (gs://, s3://, az://), repeatable")
+}
+
+// readKeypairPrivateKey reads a solana-keygen keypair file, checking that
+// its public half matches its secret one.
+func readKeypairPrivateKey(cmd *cobra.Command, keypairFile string) (solana.PrivateKey, error) {
+	keypair, err := readKeypair(cmd.Context(), keypairFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(keypair) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("expected %d bytes, got %d", ed25519.PrivateKeySize, len(keypair))
+	}
+
+	expected := ed25519.NewKeyFromSeed(keypair[:ed25519.SeedSize])
+	if !bytes.Equal(expected[ed25519.SeedSize:], keypair[ed25519.SeedSize:]) {
+		return nil, fmt.Errorf("public key does not match the private key, the file is corrupted")
+	}
+
+	return solana.PrivateKey(keypair), nil
+}
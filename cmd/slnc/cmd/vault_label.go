@@ -0,0 +1,111 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var vaultLabelCmd = &cobra.Command{
+	Use:   "label {address} [{label}]",
+	Short: "Label a key of the vault, and set its tags and notes",
+	Long: `Label a key of the vault, and set its tags and notes.
+
+A labeled key can be referenced as '@label' by every command accepting an
+address, for example:
+
+    slnc vault label 9N54GQg2URnpThxHzV2curbYFN11afGDjodV3Qy7yHPQ treasury --tags prod,cold
+    slnc get balance @treasury
+
+An empty label ('') removes the current one. Labels are unique within the
+vault, they start with a letter or a digit followed by letters, digits,
+'.', '_' or '-'.
+`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		address, err := resolveAddress(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid address %q: %w", args[0], err)
+		}
+
+		tagsChanged := cmd.Flags().Changed("tags")
+		notesChanged := cmd.Flags().Changed("notes")
+		if len(args) == 1 && !tagsChanged && !notesChanged {
+			return fmt.Errorf("nothing to change, specify a label, --tags or --notes")
+		}
+
+		wallet, err := getWallet()
+		if err != nil {
+			return err
+		}
+
+		metadata := wallet.KeyMetadata(address)
+		if metadata == nil {
+			return fmt.Errorf("key %s not found in vault", address)
+		}
+
+		if len(args) == 2 {
+			if err := wallet.SetLabel(address, args[1]); err != nil {
+				return err
+			}
+		}
+		if tagsChanged {
+			metadata.Tags = viper.GetStringSlice("vault-label-cmd-tags")
+		}
+		if notesChanged {
+			metadata.Notes = viper.GetString("vault-label-cmd-notes")
+		}
+
+		if err := writeOpenedWallet(false); err != nil {
+			return err
+		}
+
+		return printOutput(&vaultLabelOutput{
+			Address: address.String(),
+			Label:   metadata.Label,
+			Tags:    metadata.Tags,
+			Notes:   metadata.Notes,
+		})
+	},
+}
+
+func init() {
+	vaultCmd.AddCommand(vaultLabelCmd)
+
+	vaultLabelCmd.Flags().StringSlice("tags", []string{}, "Tags of the key, replacing the current ones")
+	vaultLabelCmd.Flags().String("notes", "", "Notes about the key, replacing the current ones")
+}
+
+type vaultLabelOutput struct {
+	Address string   `json:"address"`
+	Label   string   `json:"label,omitempty"`
+	Tags    []string `json:"tags,omitempty"`
+	Notes   string   `json:"notes,omitempty"`
+}
+
+func (o *vaultLabelOutput) Columns() []string { return []string{"Address", "Label", "Tags", "Notes"} }
+func (o *vaultLabelOutput) Rows() [][]string {
+	return [][]string{{o.Address, o.Label, strings.Join(o.Tags, ","), o.Notes}}
+}
+
+func (o *vaultLabelOutput) Text(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "Key %s updated.\n", o.Address)
+	return err
+}
@@ -15,7 +15,15 @@
 package cmd
 
 import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/streamingfast/slnc/vault"
 )
 
 // vaultListCmd represents the list command
@@ -24,17 +32,187 @@ var vaultListCmd = &cobra.Command{
 	Short: "List public keys inside a Solana vault.",
 	Long: `List public keys inside a Solana vault.
 
-The wallet file contains a lits of public keys for easy reference, but
-you cannot trust that these public keys have their counterpart in the
-wallet, unless you check with the "list" command.
+The wallet file contains a list of public keys for easy reference, listed
+along the watch-only addresses without opening the vault with --no-decrypt.
+Each key signs the whole list, the watch-only addresses and the encrypted
+vault: nothing can be added, removed or relabeled, nor the list copied to
+another vault file, without holding every listed key.
+
+It proves the vault writer held the listed keys, not that they're the keys
+of the vault: a vault file rewritten with a list of other keys, or holding
+no keys to vouch for its watch-only addresses, is only detected once the
+vault is opened. Every command opening the vault, "list" without
+--no-decrypt included, checks the list against the vault keys and refuses
+a vault failing it, the "verify" command only does that check.
 `,
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if viper.GetBool("vault-list-cmd-no-decrypt") {
+			return listPublicKeyIndex(viper.GetString("global-vault-file"))
+		}
+
 		vault := mustGetWallet()
 
-		vault.PrintPublicKeys()
+		return printOutput(newVaultKeysOutput(vault, false))
 	},
 }
 
+type vaultKeyOutput struct {
+	PublicKey      string     `json:"public_key"`
+	PrivateKey     string     `json:"private_key,omitempty"`
+	Label          string     `json:"label,omitempty"`
+	DerivationPath string     `json:"derivation_path,omitempty"`
+	CreatedAt      *time.Time `json:"created_at,omitempty"`
+	Tags           []string   `json:"tags,omitempty"`
+	Notes          string     `json:"notes,omitempty"`
+	WatchOnly      bool       `json:"watch_only,omitempty"`
+}
+
+type vaultKeysOutput []*vaultKeyOutput
+
+func newVaultKeysOutput(v *vault.Vault, withPrivateKeys bool) vaultKeysOutput {
+	out := vaultKeysOutput{}
+	for _, key := range v.KeyBag {
+		keyOut := &vaultKeyOutput{PublicKey: key.PublicKey().String()}
+		if withPrivateKeys {
+			keyOut.PrivateKey = key.String()
+		}
+
+		if metadata := v.Metadata[keyOut.PublicKey]; metadata != nil {
+			keyOut.Label = metadata.Label
+			keyOut.DerivationPath = metadata.DerivationPath
+			keyOut.Tags = metadata.Tags
+			keyOut.Notes = metadata.Notes
+			if !metadata.CreatedAt.IsZero() {
+				createdAt := metadata.CreatedAt
+				keyOut.CreatedAt = &createdAt
+			}
+		}
+
+		out = append(out, keyOut)
+	}
+
+	if withPrivateKeys {
+		return out
+	}
+
+	for _, address := range v.WatchOnly {
+		keyOut := &vaultKeyOutput{PublicKey: address.PublicKey.String(), Label: address.Label, Notes: address.Notes, WatchOnly: true}
+		if !address.CreatedAt.IsZero() {
+			createdAt := address.CreatedAt
+			keyOut.CreatedAt = &createdAt
+		}
+		out = append(out, keyOut)
+	}
+	return out
+}
+
+func (o vaultKeysOutput) withPrivateKeys() bool {
+	return len(o) > 0 && o[0].PrivateKey != ""
+}
+
+func (o vaultKeysOutput) Columns() []string {
+	if o.withPrivateKeys() {
+		return []string{"Public Key", "Label", "Private Key"}
+	}
+	return []string{"Public Key", "Label", "Derivation Path", "Created At", "Tags", "Notes", "Watch Only"}
+}
+
+func (o vaultKeysOutput) Rows() (out [][]string) {
+	for _, key := range o {
+		if o.withPrivateKeys() {
+			out = append(out, []string{key.PublicKey, key.Label, key.PrivateKey})
+			continue
+		}
+
+		createdAt := ""
+		if key.CreatedAt != nil {
+			createdAt = key.CreatedAt.Format(time.RFC3339)
+		}
+		out = append(out, []string{key.PublicKey, key.Label, key.DerivationPath, createdAt, strings.Join(key.Tags, ","), key.Notes, fmt.Sprintf("%t", key.WatchOnly)})
+	}
+	return
+}
+
+func (o vaultKeysOutput) Text(w io.Writer) error {
+	if o.withPrivateKeys() {
+		fmt.Fprintf(w, "Private keys contained within (%d in total):\n", len(o))
+		for _, key := range o {
+			fmt.Fprintf(w, "- %s (corresponds to %s%s)\n", key.PrivateKey, key.PublicKey, labelSuffix(key.Label))
+		}
+		return nil
+	}
+
+	fmt.Fprintf(w, "Public keys contained within (%d in total):\n", len(o))
+	for _, key := range o {
+		if key.WatchOnly {
+			fmt.Fprintf(w, "- %s%s (watch-only)\n", key.PublicKey, labelSuffix(key.Label))
+		} else {
+			fmt.Fprintf(w, "- %s%s\n", key.PublicKey, labelSuffix(key.Label))
+		}
+		if key.DerivationPath != "" {
+			fmt.Fprintf(w, "    Derivation Path: %s\n", key.DerivationPath)
+		}
+		if len(key.Tags) > 0 {
+			fmt.Fprintf(w, "    Tags: %s\n", strings.Join(key.Tags, ", "))
+		}
+		if key.Notes != "" {
+			fmt.Fprintf(w, "    Notes: %s\n", key.Notes)
+		}
+	}
+	return nil
+}
+
+func labelSuffix(label string) string {
+	if label == "" {
+		return ""
+	}
+	return " @" + label
+}
+
 func init() {
 	vaultCmd.AddCommand(vaultListCmd)
+
+	vaultListCmd.Flags().Bool("no-decrypt", false, "List the public keys from the plaintext index of the vault file without opening the vault, it proves key possession by the vault writer, not vault membership")
+}
+
+func listPublicKeyIndex(walletFile string) error {
+	v, err := loadPublicKeyIndex(walletFile)
+	if err != nil {
+		return err
+	}
+
+	out := vaultKeysOutput{}
+	for _, entry := range v.PublicKeys {
+		out = append(out, &vaultKeyOutput{PublicKey: entry.PublicKey.String(), Label: entry.Label})
+	}
+
+	for _, address := range v.WatchOnly {
+		keyOut := &vaultKeyOutput{PublicKey: address.PublicKey.String(), Label: address.Label, Notes: address.Notes, WatchOnly: true}
+		if !address.CreatedAt.IsZero() {
+			createdAt := address.CreatedAt
+			keyOut.CreatedAt = &createdAt
+		}
+		out = append(out, keyOut)
+	}
+
+	return printOutput(out)
+}
+
+// loadPublicKeyIndex loads the vault file without opening it, its public
+// key index being verified.
+func loadPublicKeyIndex(walletFile string) (*vault.Vault, error) {
+	v, err := vault.LoadVault(context.Background(), walletFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load vault file: %w", err)
+	}
+
+	if !v.HasPublicKeyIndex() {
+		return nil, fmt.Errorf("vault file %q has no public key index, upgrade it with `slnc vault migrate`", walletFile)
+	}
+
+	if err := v.VerifyPublicKeyIndex(); err != nil {
+		return nil, fmt.Errorf("vault file %q public key index: %w", walletFile, err)
+	}
+
+	return v, nil
 }
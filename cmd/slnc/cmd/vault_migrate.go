@@ -0,0 +1,79 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	"github.com/streamingfast/slnc/vault"
+)
+
+var vaultMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Upgrade the vault file to the current version of the vault format",
+	Long: `Upgrade the vault file to the current version of the vault format.
+
+Version 1 vaults only hold private keys, version 2 adds a label, the
+creation time, tags and notes to each key, and a plaintext index of the
+public keys (see 'slnc vault list --no-decrypt'), version 3 the BIP39 seed
+of vaults created from a mnemonic and version 4 the watch-only addresses
+(see 'slnc vault watch'), next to the plaintext index. Older vaults are read
+as is and upgraded by any command writing the vault, this command does it
+explicitly.
+The vault file is replaced atomically, the original one is kept next to it
+with a '.bak-<timestamp>' suffix.
+`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		wallet, err := getWallet()
+		if err != nil {
+			return err
+		}
+
+		out := &vaultMigrateOutput{PreviousVersion: wallet.Version, Version: vault.CurrentVersion}
+		if !wallet.NeedsUpgrade() {
+			return printOutput(out)
+		}
+
+		if err := writeOpenedWallet(true); err != nil {
+			return err
+		}
+
+		out.Migrated = true
+		return printOutput(out)
+	},
+}
+
+func init() {
+	vaultCmd.AddCommand(vaultMigrateCmd)
+}
+
+type vaultMigrateOutput struct {
+	PreviousVersion int  `json:"previous_version"`
+	Version         int  `json:"version"`
+	Migrated        bool `json:"migrated"`
+}
+
+func (o *vaultMigrateOutput) Text(w io.Writer) error {
+	if !o.Migrated {
+		_, err := fmt.Fprintf(w, "Vault is already at version %d.\n", o.Version)
+		return err
+	}
+
+	_, err := fmt.Fprintf(w, "Vault migrated from version %d to version %d.\n", o.PreviousVersion, o.Version)
+	return err
+}
@@ -0,0 +1,41 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import "github.com/spf13/cobra"
+
+var vaultPolicyCmd = &cobra.Command{
+	Use:   "policy",
+	Short: "Manage the spending policies of the vault keys",
+	Long: `Manage the spending policies of the vault keys.
+
+A spending policy restricts the transactions a key signs: the SOL moved
+from the key per transaction and per UTC day, the programs invoked, the
+token mints moved and the destinations of funds and authorities. It's
+sealed in the vault along the key, so it travels with the vault and
+loosening it requires opening the vault.
+
+Every command signing with the key evaluates the policy first, and refuses
+to sign with an explanation of every rule the transaction breaks. The SOL
+moved per day is tracked in 'spending.json' next to the configuration file,
+a transaction counts as soon as it's signed. Signing the same transaction
+again counts once, and --dry-run simulations, which are not signed, don't
+count.
+`,
+}
+
+func init() {
+	vaultCmd.AddCommand(vaultPolicyCmd)
+}
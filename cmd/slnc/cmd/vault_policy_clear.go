@@ -0,0 +1,58 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var vaultPolicyClearCmd = &cobra.Command{
+	Use:   "clear {address}",
+	Short: "Remove the spending policy of a key of the vault",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		address, err := resolveAddress(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid address %q: %w", args[0], err)
+		}
+
+		wallet, err := getWallet()
+		if err != nil {
+			return err
+		}
+
+		metadata := wallet.KeyMetadata(address)
+		if metadata == nil {
+			return fmt.Errorf("key %s not found in vault", address)
+		}
+
+		if metadata.Policy == nil {
+			return printOutput(newVaultPolicyChangeOutput(address, metadata.Label, nil, false, "Key %s has no spending policy.\n", address))
+		}
+
+		metadata.Policy = nil
+		if err := writeOpenedWallet(false); err != nil {
+			return err
+		}
+
+		return printOutput(newVaultPolicyChangeOutput(address, metadata.Label, nil, true, "Spending policy of key %s removed.\n", address))
+	},
+}
+
+func init() {
+	vaultPolicyCmd.AddCommand(vaultPolicyClearCmd)
+}
@@ -0,0 +1,163 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/streamingfast/slnc/policy"
+	"github.com/streamingfast/solana-go"
+)
+
+var vaultPolicySetCmd = &cobra.Command{
+	Use:   "set {address}",
+	Short: "Set the spending policy of a key of the vault",
+	Long: `Set the spending policy of a key of the vault.
+
+The policy is read from --file when set, a JSON document like:
+
+    {
+      "max_lamports_per_transaction": 1000000000,
+      "max_lamports_per_day": 5000000000,
+      "allowed_programs": ["11111111111111111111111111111111", "TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA"],
+      "allowed_mints": ["EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v"],
+      "allowed_destinations": ["9N54GQg2URnpThxHzV2curbYFN11afGDjodV3Qy7yHPQ"]
+    }
+
+and otherwise starts from the current policy of the key. The flags then
+replace the rules they name, an empty value removing the rule:
+
+    slnc vault policy set @hot --max-sol-per-transaction 1 --max-sol-per-day 5
+    slnc vault policy set @hot --allowed-destinations @treasury,@payroll
+
+Token mints are checked on every token transfer, approval and mint, the
+mint of a token account being fetched from the cluster when the
+instruction does not name it.
+`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		address, err := resolveAddress(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid address %q: %w", args[0], err)
+		}
+
+		wallet, err := getWallet()
+		if err != nil {
+			return err
+		}
+
+		metadata := wallet.KeyMetadata(address)
+		if metadata == nil {
+			return fmt.Errorf("key %s not found in vault", address)
+		}
+
+		keyPolicy := &policy.KeyPolicy{}
+		if file := viper.GetString("vault-policy-set-cmd-file"); file != "" {
+			cnt, err := ioutil.ReadFile(file)
+			if err != nil {
+				return fmt.Errorf("unable to read policy file: %w", err)
+			}
+			if err := json.Unmarshal(cnt, keyPolicy); err != nil {
+				return fmt.Errorf("unable to decode policy file %q: %w", file, err)
+			}
+		} else if metadata.Policy != nil {
+			*keyPolicy = *metadata.Policy
+		}
+
+		flags := cmd.Flags()
+		if flags.Changed("max-sol-per-transaction") {
+			if keyPolicy.MaxLamportsPerTransaction, err = parseOptionalSOLAmount(viper.GetString("vault-policy-set-cmd-max-sol-per-transaction")); err != nil {
+				return fmt.Errorf("invalid --max-sol-per-transaction: %w", err)
+			}
+		}
+		if flags.Changed("max-sol-per-day") {
+			if keyPolicy.MaxLamportsPerDay, err = parseOptionalSOLAmount(viper.GetString("vault-policy-set-cmd-max-sol-per-day")); err != nil {
+				return fmt.Errorf("invalid --max-sol-per-day: %w", err)
+			}
+		}
+		if flags.Changed("allowed-programs") {
+			if keyPolicy.AllowedPrograms, err = resolveAddresses(viper.GetStringSlice("vault-policy-set-cmd-allowed-programs")); err != nil {
+				return fmt.Errorf("invalid --allowed-programs: %w", err)
+			}
+		}
+		if flags.Changed("allowed-mints") {
+			if keyPolicy.AllowedMints, err = resolveAddresses(viper.GetStringSlice("vault-policy-set-cmd-allowed-mints")); err != nil {
+				return fmt.Errorf("invalid --allowed-mints: %w", err)
+			}
+		}
+		if flags.Changed("allowed-destinations") {
+			if keyPolicy.AllowedDestinations, err = resolveAddresses(viper.GetStringSlice("vault-policy-set-cmd-allowed-destinations")); err != nil {
+				return fmt.Errorf("invalid --allowed-destinations: %w", err)
+			}
+		}
+
+		metadata.Policy = keyPolicy
+		if keyPolicy.IsEmpty() {
+			metadata.Policy = nil
+		}
+
+		if err := writeOpenedWallet(false); err != nil {
+			return err
+		}
+
+		return printOutput(newVaultPolicyChangeOutput(address, metadata.Label, metadata.Policy, true, "Spending policy of key %s updated.\n", address))
+	},
+}
+
+func init() {
+	vaultPolicyCmd.AddCommand(vaultPolicySetCmd)
+
+	vaultPolicySetCmd.Flags().String("file", "", "JSON file holding the policy, replacing the current one")
+	vaultPolicySetCmd.Flags().String("max-sol-per-transaction", "", "Maximum SOL moved from the key by a transaction, like 1.5")
+	vaultPolicySetCmd.Flags().String("max-sol-per-day", "", "Maximum SOL moved from the key during a UTC day")
+	vaultPolicySetCmd.Flags().StringSlice("allowed-programs", []string{}, "Programs the transactions signed by the key may invoke")
+	vaultPolicySetCmd.Flags().StringSlice("allowed-mints", []string{}, "Token mints the key may transfer, approve or mint")
+	vaultPolicySetCmd.Flags().StringSlice("allowed-destinations", []string{}, "Accounts the key may move funds or authorities to")
+}
+
+// parseOptionalSOLAmount is `parseSOLAmount` where an empty amount is zero.
+func parseOptionalSOLAmount(in string) (uint64, error) {
+	if in == "" {
+		return 0, nil
+	}
+	return parseSOLAmount(in)
+}
+
+// vaultPolicyChangeOutput is the output of commands changing the spending
+// policy of a key, `changed` is false when there was nothing to change.
+type vaultPolicyChangeOutput struct {
+	keyPolicyOutput
+	Changed bool `json:"changed"`
+
+	message string
+}
+
+func newVaultPolicyChangeOutput(address solana.PublicKey, label string, keyPolicy *policy.KeyPolicy, changed bool, message string, args ...interface{}) *vaultPolicyChangeOutput {
+	return &vaultPolicyChangeOutput{
+		keyPolicyOutput: keyPolicyOutput{PublicKey: address.String(), Label: label, Policy: keyPolicy},
+		Changed:         changed,
+		message:         fmt.Sprintf(message, args...),
+	}
+}
+
+func (o *vaultPolicyChangeOutput) Text(w io.Writer) error {
+	_, err := fmt.Fprint(w, o.message)
+	return err
+}
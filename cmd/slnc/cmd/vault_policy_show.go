@@ -0,0 +1,108 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	"github.com/streamingfast/slnc/policy"
+	"github.com/streamingfast/solana-go"
+)
+
+var vaultPolicyShowCmd = &cobra.Command{
+	Use:   "show [{address}]",
+	Short: "Show the spending policies of the vault keys",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		wallet, err := getWallet()
+		if err != nil {
+			return err
+		}
+
+		var keys []solana.PublicKey
+		if len(args) == 1 {
+			address, err := resolveAddress(args[0])
+			if err != nil {
+				return fmt.Errorf("invalid address %q: %w", args[0], err)
+			}
+			if wallet.KeyMetadata(address) == nil {
+				return fmt.Errorf("key %s not found in vault", address)
+			}
+			keys = append(keys, address)
+		} else {
+			for _, privateKey := range wallet.KeyBag {
+				keys = append(keys, privateKey.PublicKey())
+			}
+		}
+
+		out := &vaultPolicyOutput{Keys: []*keyPolicyOutput{}}
+		for _, key := range keys {
+			metadata := wallet.KeyMetadata(key)
+			out.Keys = append(out.Keys, &keyPolicyOutput{PublicKey: key.String(), Label: metadata.Label, Policy: metadata.Policy})
+		}
+
+		return printOutput(out)
+	},
+}
+
+type vaultPolicyOutput struct {
+	Keys []*keyPolicyOutput `json:"keys"`
+}
+
+type keyPolicyOutput struct {
+	PublicKey string            `json:"public_key"`
+	Label     string            `json:"label,omitempty"`
+	Policy    *policy.KeyPolicy `json:"policy"`
+}
+
+func (o *vaultPolicyOutput) Text(w io.Writer) error {
+	for _, key := range o.Keys {
+		fmt.Fprintf(w, "%s%s\n", key.PublicKey, labelSuffix(key.Label))
+
+		p := key.Policy
+		if p.IsEmpty() {
+			fmt.Fprintln(w, "  No spending policy")
+			continue
+		}
+
+		if p.MaxLamportsPerTransaction > 0 {
+			fmt.Fprintf(w, "  Max per transaction: %s\n", formatLamports(p.MaxLamportsPerTransaction))
+		}
+		if p.MaxLamportsPerDay > 0 {
+			fmt.Fprintf(w, "  Max per day: %s\n", formatLamports(p.MaxLamportsPerDay))
+		}
+		printKeyList(w, "Allowed programs", p.AllowedPrograms)
+		printKeyList(w, "Allowed mints", p.AllowedMints)
+		printKeyList(w, "Allowed destinations", p.AllowedDestinations)
+	}
+	return nil
+}
+
+func printKeyList(w io.Writer, title string, keys []solana.PublicKey) {
+	if len(keys) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "  %s:\n", title)
+	for _, key := range keys {
+		fmt.Fprintf(w, "  - %s\n", key)
+	}
+}
+
+func init() {
+	vaultPolicyCmd.AddCommand(vaultPolicyShowCmd)
+}
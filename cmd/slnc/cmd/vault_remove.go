@@ -0,0 +1,118 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/streamingfast/slnc/cli"
+	"github.com/streamingfast/solana-go"
+)
+
+var vaultRemoveCmd = &cobra.Command{
+	Use:   "remove {address} [{address}...]",
+	Short: "Remove keys from the vault",
+	Long: `Remove keys from the vault.
+
+The private keys are gone for good once removed, unless they are held
+elsewhere: make sure the accounts they control are emptied or their
+authority transferred. The vault file is replaced atomically, the original
+one is kept next to it with a '.bak-<timestamp>' suffix.
+`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var addresses []solana.PublicKey
+		for _, arg := range args {
+			address, err := resolveAddress(arg)
+			if err != nil {
+				return fmt.Errorf("invalid address %q: %w", arg, err)
+			}
+			addresses = append(addresses, address)
+		}
+
+		wallet, err := getWallet()
+		if err != nil {
+			return err
+		}
+
+		removed := map[solana.PublicKey]bool{}
+		for _, address := range addresses {
+			if _, found := wallet.PrivateKey(address); !found {
+				return fmt.Errorf("key %s not found in vault", address)
+			}
+			removed[address] = true
+		}
+
+		if len(removed) == len(wallet.KeyBag) && len(wallet.WatchOnly) != 0 {
+			return fmt.Errorf("removing every key would leave no key to vouch for the %d watch-only addresses, remove them first with 'slnc vault unwatch'", len(wallet.WatchOnly))
+		}
+
+		if !viper.GetBool("vault-remove-cmd-yes") {
+			printInfo("The following keys will be removed from the vault:\n")
+			for _, address := range addresses {
+				printInfo("- %s%s\n", address, labelSuffix(wallet.KeyMetadata(address).Label))
+			}
+
+			answer, err := cli.GetInput("Type 'yes' to confirm: ")
+			if err != nil {
+				return err
+			}
+			if answer != "yes" {
+				return fmt.Errorf("removal aborted")
+			}
+		}
+
+		out := &vaultRemoveOutput{RemovedKeys: []string{}}
+		for _, address := range addresses {
+			wallet.RemoveKey(address)
+			out.RemovedKeys = append(out.RemovedKeys, address.String())
+		}
+
+		if err := writeOpenedWallet(true); err != nil {
+			return err
+		}
+
+		out.TotalKeys = len(wallet.KeyBag)
+		return printOutput(out)
+	},
+}
+
+func init() {
+	vaultCmd.AddCommand(vaultRemoveCmd)
+
+	vaultRemoveCmd.Flags().Bool("yes", false, "Do not ask for confirmation")
+}
+
+type vaultRemoveOutput struct {
+	RemovedKeys []string `json:"removed_keys"`
+	TotalKeys   int      `json:"total_keys"`
+}
+
+func (o *vaultRemoveOutput) Columns() []string { return []string{"Removed Key"} }
+func (o *vaultRemoveOutput) Rows() [][]string {
+	rows := make([][]string, len(o.RemovedKeys))
+	for i, key := range o.RemovedKeys {
+		rows[i] = []string{key}
+	}
+	return rows
+}
+
+func (o *vaultRemoveOutput) Text(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "Removed %d keys, %d keys left.\n", len(o.RemovedKeys), o.TotalKeys)
+	return err
+}
@@ -0,0 +1,209 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/streamingfast/slnc/cli"
+	"github.com/streamingfast/slnc/vault"
+)
+
+var vaultRewrapCmd = &cobra.Command{
+	Use:   "rewrap",
+	Short: "Seal an existing vault with another vault type, keeping its keys",
+	Long: `Seal an existing vault with another vault type, keeping its keys.
+
+The vault is opened with its current vault type then sealed with the one
+given by --to, for example to move a passphrase vault to Google Cloud KMS:
+
+    slnc vault rewrap --to kms-gcp --kms-key projects/.../locations/.../keyRings/.../cryptoKeys/name
+
+When both the current and the new vault types are KMS backed, --kms-key
+opens the vault and --to-kms-key seals it.
+
+The vault file is replaced atomically, the original one is kept next to it
+with a '.bak-<timestamp>' suffix.
+`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		toType := viper.GetString("vault-rewrap-cmd-to")
+		if toType == "" {
+			return fmt.Errorf("missing parameter: --to is required")
+		}
+
+		walletFile := viper.GetString("global-vault-file")
+		v, err := openVaultFile(walletFile)
+		if err != nil {
+			return err
+		}
+		fromType := v.SecretBoxWrap
+
+		var boxer vault.SecretBoxer
+		switch {
+		case isKMSVaultType(toType):
+			kmsConfig := vault.KMSConfig{
+				Key:      viper.GetString("vault-rewrap-cmd-to-kms-key"),
+				Endpoint: viper.GetString("vault-rewrap-cmd-to-kms-endpoint"),
+			}
+			if kmsConfig.Key == "" {
+				kmsConfig = getKMSConfig()
+			}
+			if kmsConfig.Key == "" {
+				return fmt.Errorf("missing parameter: --to-kms-key (or --kms-key) is required with --to=%s", toType)
+			}
+			boxer = vault.NewKMSBoxer(toType, kmsConfig)
+
+		case toType == "shamir":
+			boxer = vault.NewShamirBoxer(viper.GetInt("vault-rewrap-cmd-shamir-parts"), viper.GetInt("vault-rewrap-cmd-shamir-threshold"))
+
+		case toType == "passphrase":
+			boxer, err = newPassphraseBoxer()
+			if err != nil {
+				return err
+			}
+
+		default:
+			return fmt.Errorf(`invalid vault type: %q, please use one of: "passphrase", "kms-gcp", "kms-aws", "hashicorp-transit", "shamir"`, toType)
+		}
+
+		shareDir := viper.GetString("vault-rewrap-cmd-shamir-share-dir")
+		protectShares := viper.GetBool("vault-rewrap-cmd-shamir-protect-shares")
+		out, err := rewrapVault(v, walletFile, boxer, shareDir, protectShares)
+		if err != nil {
+			return err
+		}
+
+		out.FromType = fromType
+		return printOutput(out)
+	},
+}
+
+func init() {
+	vaultCmd.AddCommand(vaultRewrapCmd)
+
+	vaultRewrapCmd.Flags().String("to", "", "New vault type. One of: passphrase, kms-gcp, kms-aws, hashicorp-transit, shamir")
+	vaultRewrapCmd.Flags().String("to-kms-key", "", "Key sealing the vault with a KMS backed --to type (defaults to --kms-key)")
+	vaultRewrapCmd.Flags().String("to-kms-endpoint", "", "Address of the key management service sealing the vault, used with --to-kms-key")
+	vaultRewrapCmd.Flags().Int("shamir-parts", 3, "Number of shares the vault key is split in, with --to=shamir")
+	vaultRewrapCmd.Flags().Int("shamir-threshold", 2, "Number of shares required to open the vault, with --to=shamir")
+	vaultRewrapCmd.Flags().String("shamir-share-dir", "", "Directory where the share files are written, with --to=shamir (defaults to the vault file directory)")
+	vaultRewrapCmd.Flags().Bool("shamir-protect-shares", false, "Ask a passphrase for each share file, with --to=shamir")
+}
+
+// openVaultFile loads the vault file and opens it with its current vault
+// type.
+func openVaultFile(walletFile string) (*vault.Vault, error) {
+	v, err := loadVaultFile(walletFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return v, openVault(v)
+}
+
+func loadVaultFile(walletFile string) (*vault.Vault, error) {
+	printInfo("Loading existing vault from file: %s\n", walletFile)
+	v, err := vault.LoadVault(context.Background(), walletFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load vault file: %w", err)
+	}
+
+	return v, nil
+}
+
+func openVault(v *vault.Vault) error {
+	boxer, err := secretBoxerForType(v.SecretBoxWrap)
+	if err != nil {
+		return fmt.Errorf("unable to intiate boxer: %w", err)
+	}
+
+	if err := v.Open(boxer); err != nil {
+		return fmt.Errorf("unable to open vault: %w", err)
+	}
+
+	return nil
+}
+
+// rewrapVault seals the opened vault `v` with `boxer` and atomically
+// replaces `walletFile`, keeping a backup of the original.
+func rewrapVault(v *vault.Vault, walletFile string, boxer vault.SecretBoxer, shareDir string, protectShares bool) (*vaultRewrapOutput, error) {
+	if err := v.Seal(boxer); err != nil {
+		return nil, fmt.Errorf("failed to seal vault: %w", err)
+	}
+
+	// Shares are written first, a vault without its shares could never
+	// be opened
+	var shareFiles []string
+	if shamirBoxer, ok := boxer.(*vault.ShamirBoxer); ok {
+		var err error
+		if shareFiles, err = writeShamirShares(walletFile, shamirBoxer, shareDir, protectShares); err != nil {
+			return nil, err
+		}
+	}
+
+	backupFile, err := v.Replace(context.Background(), walletFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to replace vault file: %w", err)
+	}
+
+	printInfo("\n")
+	printInfo("Wallet file %q written to disk, the previous one was saved to %q.\n", walletFile, backupFile)
+	return &vaultRewrapOutput{
+		VaultFile:  walletFile,
+		BackupFile: backupFile,
+		ToType:     v.SecretBoxWrap,
+		ShareFiles: shareFiles,
+		TotalKeys:  len(v.KeyBag),
+	}, nil
+}
+
+type vaultRewrapOutput struct {
+	VaultFile  string   `json:"vault_file"`
+	BackupFile string   `json:"backup_file"`
+	FromType   string   `json:"from_vault_type"`
+	ToType     string   `json:"to_vault_type"`
+	ShareFiles []string `json:"share_files,omitempty"`
+	TotalKeys  int      `json:"total_keys"`
+}
+
+func (o *vaultRewrapOutput) Text(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "Vault re-wrapped from %q to %q, it holds %d keys.\n", o.FromType, o.ToType, o.TotalKeys)
+	return err
+}
+
+// newPassphraseBoxer asks for the new passphrase of a vault, it's read from
+// SLNC_GLOBAL_INSECURE_NEW_VAULT_PASSPHRASE when set.
+func newPassphraseBoxer() (vault.SecretBoxer, error) {
+	if envVal := os.Getenv("SLNC_GLOBAL_INSECURE_NEW_VAULT_PASSPHRASE"); envVal != "" {
+		return vault.NewPassphraseBoxer(envVal), nil
+	}
+
+	printInfo("\n")
+	printInfo("You will be asked to provide the new passphrase of your vault.\n")
+	printInfo("Make sure you make it long and strong.\n")
+	printInfo("\n")
+	password, err := cli.GetEncryptPassphrase()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get password input: %w", err)
+	}
+
+	return vault.NewPassphraseBoxer(password), nil
+}
@@ -0,0 +1,77 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+)
+
+var vaultUnwatchCmd = &cobra.Command{
+	Use:   "unwatch {address} [{address}...]",
+	Short: "Stop tracking watch-only addresses",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		wallet, err := getWallet()
+		if err != nil {
+			return err
+		}
+
+		out := &vaultUnwatchOutput{Unwatched: []string{}}
+		for _, arg := range args {
+			address, err := resolveAddress(arg)
+			if err != nil {
+				return fmt.Errorf("invalid address %q: %w", arg, err)
+			}
+
+			if !wallet.RemoveWatchOnly(address) {
+				return fmt.Errorf("address %s is not watched by the vault", address)
+			}
+			out.Unwatched = append(out.Unwatched, address.String())
+		}
+
+		if err := writeOpenedWallet(false); err != nil {
+			return err
+		}
+
+		out.TotalWatched = len(wallet.WatchOnly)
+		return printOutput(out)
+	},
+}
+
+func init() {
+	vaultCmd.AddCommand(vaultUnwatchCmd)
+}
+
+type vaultUnwatchOutput struct {
+	Unwatched    []string `json:"unwatched"`
+	TotalWatched int      `json:"total_watched"`
+}
+
+func (o *vaultUnwatchOutput) Columns() []string { return []string{"Unwatched Address"} }
+func (o *vaultUnwatchOutput) Rows() [][]string {
+	rows := make([][]string, len(o.Unwatched))
+	for i, address := range o.Unwatched {
+		rows[i] = []string{address}
+	}
+	return rows
+}
+
+func (o *vaultUnwatchOutput) Text(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "Stopped watching %d addresses, %d left.\n", len(o.Unwatched), o.TotalWatched)
+	return err
+}
@@ -0,0 +1,68 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var vaultVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Open the vault and check that its public key index matches its keys",
+	Long: `Open the vault and check that its public key index matches its keys.
+
+The plaintext public key index of the vault file, read by 'slnc vault list
+--no-decrypt', must list exactly the keys sealed in the vault, in the same
+order and with the same labels, and each of its keys must sign the whole
+index, the watch-only addresses and the encrypted vault.
+`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		walletFile := viper.GetString("global-vault-file")
+		wallet, err := getWallet()
+		if err != nil {
+			return err
+		}
+
+		if !wallet.HasPublicKeyIndex() {
+			return fmt.Errorf("vault file %q has no public key index, upgrade it with `slnc vault migrate`", walletFile)
+		}
+
+		if err := wallet.CheckPublicKeyIndex(); err != nil {
+			return fmt.Errorf("vault file %q does not verify: %w", walletFile, err)
+		}
+
+		return printOutput(&vaultVerifyOutput{VaultFile: walletFile, Verified: true, TotalKeys: len(wallet.KeyBag)})
+	},
+}
+
+type vaultVerifyOutput struct {
+	VaultFile string `json:"vault_file"`
+	Verified  bool   `json:"verified"`
+	TotalKeys int    `json:"total_keys"`
+}
+
+func (o *vaultVerifyOutput) Text(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "Vault file %q verified, its public key index matches its %d keys.\n", o.VaultFile, o.TotalKeys)
+	return err
+}
+
+func init() {
+	vaultCmd.AddCommand(vaultVerifyCmd)
+}
@@ -0,0 +1,94 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/streamingfast/slnc/vault"
+)
+
+var vaultWatchCmd = &cobra.Command{
+	Use:   "watch {address} [{label}]",
+	Short: "Track an address in the vault without its private key",
+	Long: `Track an address in the vault without its private key.
+
+Watch-only addresses are listed by 'vault list', reported by 'vault balances'
+and can be labeled to be referenced as '@label' like the keys of the vault,
+for example:
+
+    slnc vault watch 9N54GQg2URnpThxHzV2curbYFN11afGDjodV3Qy7yHPQ cold-storage
+    slnc get balance @cold-storage
+
+Watching an address already watched updates its label and notes. Adding the
+private key of a watched address to the vault replaces its watch-only entry.
+`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		address, err := resolveAddress(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid address %q: %w", args[0], err)
+		}
+
+		wallet, err := getWallet()
+		if err != nil {
+			return err
+		}
+
+		label := ""
+		if len(args) == 2 {
+			label = args[1]
+		}
+
+		watched := wallet.WatchOnlyAddress(address)
+		if watched == nil {
+			if watched, err = wallet.AddWatchOnly(address, label); err != nil {
+				return err
+			}
+		} else if len(args) == 2 {
+			if err := wallet.SetWatchOnlyLabel(address, label); err != nil {
+				return err
+			}
+		}
+
+		if cmd.Flags().Changed("notes") {
+			watched.Notes = viper.GetString("vault-watch-cmd-notes")
+		}
+
+		if err := writeOpenedWallet(false); err != nil {
+			return err
+		}
+
+		return printOutput(&vaultWatchOutput{watched})
+	},
+}
+
+func init() {
+	vaultCmd.AddCommand(vaultWatchCmd)
+
+	vaultWatchCmd.Flags().String("notes", "", "Free form notes about the address")
+}
+
+type vaultWatchOutput struct {
+	*vault.WatchOnlyAddress
+}
+
+func (o *vaultWatchOutput) Text(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "Watching %s%s.\n", o.PublicKey, labelSuffix(o.Label))
+	return err
+}
@@ -0,0 +1,77 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var verifyMessageCmd = &cobra.Command{
+	Use:   "verify {pubkey} {message_string} {signature}",
+	Short: "Verify the signature of a message",
+	Long: `Verify the signature of a message, as made by 'slnc sign'.
+
+The signature is decoded from hex, base58 or base64, whichever matches
+unless --encoding is set. Use --offchain for signatures of messages in the
+Solana off-chain message format. The command fails when the signature is
+not valid.
+`,
+	Args: cobra.ExactArgs(3),
+	RunE: func(_ *cobra.Command, args []string) error {
+		pub, err := resolveAddress(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid public key %q: %w", args[0], err)
+		}
+
+		signature, err := decodeSignature(args[2], viper.GetString("verify-cmd-encoding"))
+		if err != nil {
+			return err
+		}
+
+		message := []byte(args[1])
+		if viper.GetBool("verify-cmd-offchain") {
+			if message, err = encodeOffchainMessage(message); err != nil {
+				return err
+			}
+		}
+
+		if !signature.Verify(pub, message) {
+			return fmt.Errorf("signature is not valid for %s", pub)
+		}
+
+		return printOutput(&verifyOutput{Valid: true, PublicKey: pub.String()})
+	},
+}
+
+type verifyOutput struct {
+	Valid     bool   `json:"valid"`
+	PublicKey string `json:"public_key"`
+}
+
+func (o *verifyOutput) Text(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "Signature is valid for %s\n", o.PublicKey)
+	return err
+}
+
+func init() {
+	RootCmd.AddCommand(verifyMessageCmd)
+
+	verifyMessageCmd.Flags().String("encoding", "", "Encoding of the signature, one of hex, base58, base64 (detected by default)")
+	verifyMessageCmd.Flags().Bool("offchain", false, "Verify the signature of the message in the Solana off-chain message format")
+}
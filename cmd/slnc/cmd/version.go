@@ -16,6 +16,7 @@ package cmd
 
 import (
 	"fmt"
+	"io"
 
 	"github.com/spf13/cobra"
 )
@@ -24,14 +25,21 @@ var versionCmd = &cobra.Command{
 	Use:   "version",
 	Short: "Print version information about the binary",
 	RunE: func(_ *cobra.Command, _ []string) error {
-		fmt.Printf("Version: %s\n", Version)
-		fmt.Printf("Commit: %s\n", Commit)
-		fmt.Printf("Build: %s\n", Date)
-
-		return nil
+		return printOutput(&versionOutput{Version: Version, Commit: Commit, Date: Date})
 	},
 }
 
+type versionOutput struct {
+	Version string `json:"version"`
+	Commit  string `json:"commit"`
+	Date    string `json:"date"`
+}
+
+func (o *versionOutput) Text(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "Version: %s\nCommit: %s\nBuild: %s\n", o.Version, o.Commit, o.Date)
+	return err
+}
+
 func init() {
 	RootCmd.AddCommand(versionCmd)
 }
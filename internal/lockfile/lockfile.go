@@ -0,0 +1,141 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package lockfile implements advisory locks shared by slnc processes,
+// taken by exclusively creating a lock file next to the protected one.
+package lockfile
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// staleAfter is the age after which a lock file is considered abandoned by
+// a process that died holding it, the holder refreshing it every
+// `refreshInterval`.
+const (
+	staleAfter      = time.Minute
+	refreshInterval = 15 * time.Second
+)
+
+// Lock takes the lock file `filename`, waiting up to `timeout` for the
+// process holding it. The returned function releases the lock, it only
+// removes the lock file when it's still the one taken by this call.
+func Lock(filename string, timeout time.Duration) (unlock func(), err error) {
+	if err := os.MkdirAll(filepath.Dir(filename), 0700); err != nil {
+		return nil, err
+	}
+
+	owner, err := newOwner()
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		file, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err == nil {
+			_, err = file.Write(owner)
+			if closeErr := file.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				os.Remove(filename)
+				return nil, err
+			}
+
+			return hold(filename, owner), nil
+		}
+
+		if !os.IsExist(err) {
+			return nil, err
+		}
+
+		removeStale(filename)
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%q is held by another process", filename)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// newOwner returns the content of a lock file identifying the call taking
+// it: the process id, for humans, and a random nonce.
+func newOwner() ([]byte, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("generate lock nonce: %w", err)
+	}
+
+	return []byte(fmt.Sprintf("%d %s\n", os.Getpid(), hex.EncodeToString(nonce))), nil
+}
+
+// hold refreshes the modification time of the lock file until unlocked, so
+// a lock held longer than `staleAfter` is not taken for an abandoned one.
+func hold(filename string, owner []byte) (unlock func()) {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(refreshInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if isOwner(filename, owner) {
+					now := time.Now()
+					os.Chtimes(filename, now, now)
+				}
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		if isOwner(filename, owner) {
+			os.Remove(filename)
+		}
+	}
+}
+
+// removeStale removes the lock file when it was not refreshed for
+// `staleAfter`, and still has the same owner once checked.
+func removeStale(filename string) {
+	stat, err := os.Stat(filename)
+	if err != nil || time.Since(stat.ModTime()) <= staleAfter {
+		return
+	}
+
+	owner, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return
+	}
+
+	if stat, err := os.Stat(filename); err == nil && time.Since(stat.ModTime()) > staleAfter && isOwner(filename, owner) {
+		os.Remove(filename)
+	}
+}
+
+func isOwner(filename string, owner []byte) bool {
+	cnt, err := ioutil.ReadFile(filename)
+	return err == nil && bytes.Equal(cnt, owner)
+}
@@ -0,0 +1,72 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lockfile
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLock(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "ledger.json.lock")
+
+	unlock, err := Lock(filename, time.Second)
+	require.NoError(t, err)
+
+	_, err = Lock(filename, 100*time.Millisecond)
+	assert.Error(t, err)
+
+	unlock()
+	_, err = os.Stat(filename)
+	assert.True(t, os.IsNotExist(err))
+
+	unlock, err = Lock(filename, 100*time.Millisecond)
+	require.NoError(t, err)
+	unlock()
+}
+
+func TestLock_Stale(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "ledger.json.lock")
+	require.NoError(t, ioutil.WriteFile(filename, []byte("1 abandoned\n"), 0600))
+
+	old := time.Now().Add(-2 * staleAfter)
+	require.NoError(t, os.Chtimes(filename, old, old))
+
+	unlock, err := Lock(filename, time.Second)
+	require.NoError(t, err)
+	unlock()
+}
+
+func TestLock_UnlockKeepsAnotherOwner(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "ledger.json.lock")
+
+	unlock, err := Lock(filename, time.Second)
+	require.NoError(t, err)
+
+	// Another process took the lock over, believing it abandoned
+	require.NoError(t, os.Remove(filename))
+	require.NoError(t, ioutil.WriteFile(filename, []byte("2 other\n"), 0600))
+
+	unlock()
+	cnt, err := ioutil.ReadFile(filename)
+	require.NoError(t, err)
+	assert.Equal(t, "2 other\n", string(cnt))
+}
@@ -0,0 +1,299 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package policy decides whether a transaction may be signed: it extracts
+// from a transaction the programs it invokes and the funds and authorities
+// it moves, then checks them against a set of rules.
+package policy
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/streamingfast/solana-go"
+	"github.com/streamingfast/solana-go/programs/system"
+	"github.com/streamingfast/solana-go/programs/token"
+)
+
+// Movement kinds
+const (
+	// KindSOL moves `Amount` lamports
+	KindSOL = "sol"
+	// KindToken moves `Amount` base units of a token, or allows them to be
+	// moved by the destination for approvals
+	KindToken = "token"
+	// KindAuthority hands over an authority, or closes an account
+	KindAuthority = "authority"
+	// KindOwner hands an account over to the program `Destination`, which
+	// then controls it: it's checked against the allowed programs as well.
+	KindOwner = "owner"
+)
+
+// Movement is a transfer of funds, or of an authority, done by an
+// instruction of a transaction.
+type Movement struct {
+	// Index is the position of the instruction in the transaction
+	Index       int
+	Kind        string
+	Instruction string
+	Program     solana.PublicKey
+	// Authority is the key whose signature allows the movement, the owner
+	// of the funds.
+	Authority   solana.PublicKey
+	Source      solana.PublicKey
+	Destination solana.PublicKey
+	Amount      uint64
+	// Mint is the mint of the moved token, only known by the instructions
+	// carrying it, like `TransferChecked`.
+	Mint *solana.PublicKey
+}
+
+func (m *Movement) String() string {
+	switch m.Kind {
+	case KindSOL:
+		return fmt.Sprintf("%s of %s from %s to %s", m.Instruction, FormatSOL(m.Amount), m.Source, m.Destination)
+	case KindToken:
+		return fmt.Sprintf("%s of %d token units from %s to %s", m.Instruction, m.Amount, m.Source, m.Destination)
+	default:
+		return fmt.Sprintf("%s of %s to %s", m.Instruction, m.Source, m.Destination)
+	}
+}
+
+// Summary is what a transaction does, as far as policies are concerned.
+type Summary struct {
+	Programs  []solana.PublicKey
+	Movements []*Movement
+}
+
+// Lamports returns the lamports moved by the transaction, from any key when
+// `authority` is nil.
+func (s *Summary) Lamports(authority *solana.PublicKey) (total uint64) {
+	for _, movement := range s.Movements {
+		if movement.Kind == KindSOL && (authority == nil || movement.Authority.Equals(*authority)) {
+			total += movement.Amount
+		}
+	}
+	return
+}
+
+// Analyze extracts the summary of `trx`, the movements of the System and
+// SPL Token programs are decoded, the other programs are only listed. An
+// unknown System instruction is an error, it could move anything.
+func Analyze(trx *solana.Transaction) (*Summary, error) {
+	summary := &Summary{}
+	seen := map[solana.PublicKey]bool{}
+
+	keys := trx.Message.AccountKeys
+	for idx, instruction := range trx.Message.Instructions {
+		if int(instruction.ProgramIDIndex) >= len(keys) {
+			return nil, fmt.Errorf("instruction #%d: invalid program index", idx+1)
+		}
+		programID := keys[instruction.ProgramIDIndex]
+		if !seen[programID] {
+			seen[programID] = true
+			summary.Programs = append(summary.Programs, programID)
+		}
+
+		accounts := make([]solana.PublicKey, len(instruction.Accounts))
+		for i, accountIdx := range instruction.Accounts {
+			if int(accountIdx) >= len(keys) {
+				return nil, fmt.Errorf("instruction #%d: invalid account index", idx+1)
+			}
+			accounts[i] = keys[accountIdx]
+		}
+
+		var movement *Movement
+		var err error
+		switch {
+		case programID.Equals(system.PROGRAM_ID):
+			movement, err = analyzeSystemInstruction(accounts, instruction.Data)
+		case programID.Equals(token.PROGRAM_ID):
+			movement, err = analyzeTokenInstruction(accounts, instruction.Data)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("instruction #%d: %w", idx+1, err)
+		}
+
+		if movement != nil {
+			movement.Index = idx
+			movement.Program = programID
+			summary.Movements = append(summary.Movements, movement)
+		}
+	}
+
+	return summary, nil
+}
+
+func analyzeSystemInstruction(accounts []solana.PublicKey, data []byte) (*Movement, error) {
+	if len(data) < 4 {
+		return nil, fmt.Errorf("system instruction too short")
+	}
+
+	sol := func(instruction string, authority, source, destination int, amountOffset int) (*Movement, error) {
+		if len(accounts) <= authority || len(accounts) <= source || len(accounts) <= destination || len(data) < amountOffset+8 {
+			return nil, fmt.Errorf("system %s instruction is malformed", instruction)
+		}
+		return &Movement{
+			Kind:        KindSOL,
+			Instruction: instruction,
+			Authority:   accounts[authority],
+			Source:      accounts[source],
+			Destination: accounts[destination],
+			Amount:      binary.LittleEndian.Uint64(data[amountOffset:]),
+		}, nil
+	}
+
+	switch binary.LittleEndian.Uint32(data) {
+	case 0:
+		return sol("create_account", 0, 0, 1, 4)
+	case 2:
+		return sol("transfer", 0, 0, 1, 4)
+	case 3:
+		// base (32 bytes) and seed (u64 length prefixed) come before the lamports
+		if len(data) < 44 {
+			return nil, fmt.Errorf("system create_account_with_seed instruction is malformed")
+		}
+		seedLength := binary.LittleEndian.Uint64(data[36:])
+		if seedLength > uint64(len(data)) {
+			return nil, fmt.Errorf("system create_account_with_seed instruction is malformed")
+		}
+		return sol("create_account_with_seed", 0, 0, 1, 44+int(seedLength))
+	case 5:
+		// the nonce authority is the 5th account
+		return sol("withdraw_nonce_account", 4, 0, 1, 4)
+	case 7:
+		if len(accounts) < 2 || len(data) < 36 {
+			return nil, fmt.Errorf("system authorize_nonce_account instruction is malformed")
+		}
+		return &Movement{
+			Kind:        KindAuthority,
+			Instruction: "authorize_nonce_account",
+			Authority:   accounts[1],
+			Source:      accounts[0],
+			Destination: solana.PublicKeyFromBytes(data[4:36]),
+		}, nil
+	case 11:
+		return sol("transfer_with_seed", 1, 0, 2, 4)
+	case 1:
+		if len(accounts) < 1 || len(data) < 36 {
+			return nil, fmt.Errorf("system assign instruction is malformed")
+		}
+		return &Movement{
+			Kind:        KindOwner,
+			Instruction: "assign",
+			Authority:   accounts[0],
+			Source:      accounts[0],
+			Destination: solana.PublicKeyFromBytes(data[4:36]),
+		}, nil
+	case 9:
+		// base (32 bytes), seed (u64 length prefixed) and space (u64) come
+		// before the owner
+		return ownerWithSeed("allocate_with_seed", accounts, data, 8)
+	case 10:
+		return ownerWithSeed("assign_with_seed", accounts, data, 0)
+	case 4, 6, 8, 12:
+		// advance_nonce_account, initialize_nonce_account, allocate and
+		// upgrade_nonce_account move nothing
+		return nil, nil
+	}
+
+	return nil, fmt.Errorf("unsupported system instruction %d", binary.LittleEndian.Uint32(data))
+}
+
+// ownerWithSeed decodes the system instructions assigning an account
+// derived from the base key, the 2nd account, `skip` bytes after the seed
+// being followed by the owner.
+func ownerWithSeed(instruction string, accounts []solana.PublicKey, data []byte, skip int) (*Movement, error) {
+	if len(accounts) < 2 || len(data) < 44 {
+		return nil, fmt.Errorf("system %s instruction is malformed", instruction)
+	}
+
+	seedLength := binary.LittleEndian.Uint64(data[36:])
+	if seedLength > uint64(len(data)) || len(data) < 44+int(seedLength)+skip+32 {
+		return nil, fmt.Errorf("system %s instruction is malformed", instruction)
+	}
+
+	ownerOffset := 44 + int(seedLength) + skip
+	return &Movement{
+		Kind:        KindOwner,
+		Instruction: instruction,
+		Authority:   accounts[1],
+		Source:      accounts[0],
+		Destination: solana.PublicKeyFromBytes(data[ownerOffset : ownerOffset+32]),
+	}, nil
+}
+
+func analyzeTokenInstruction(accounts []solana.PublicKey, data []byte) (*Movement, error) {
+	if len(data) < 1 {
+		return nil, fmt.Errorf("token instruction too short")
+	}
+
+	tok := func(instruction string, authority, source, destination, mint int) (*Movement, error) {
+		if len(accounts) <= authority || len(accounts) <= source || len(accounts) <= destination || len(data) < 9 {
+			return nil, fmt.Errorf("token %s instruction is malformed", instruction)
+		}
+
+		movement := &Movement{
+			Kind:        KindToken,
+			Instruction: instruction,
+			Authority:   accounts[authority],
+			Source:      accounts[source],
+			Destination: accounts[destination],
+			Amount:      binary.LittleEndian.Uint64(data[1:]),
+		}
+		if mint >= 0 {
+			if len(accounts) <= mint {
+				return nil, fmt.Errorf("token %s instruction is malformed", instruction)
+			}
+			mintKey := accounts[mint]
+			movement.Mint = &mintKey
+		}
+		return movement, nil
+	}
+
+	switch data[0] {
+	case 3:
+		return tok("transfer", 2, 0, 1, -1)
+	case 4:
+		return tok("approve", 2, 0, 1, -1)
+	case 7:
+		return tok("mint_to", 2, 0, 1, 0)
+	case 12:
+		return tok("transfer_checked", 3, 0, 2, 1)
+	case 13:
+		return tok("approve_checked", 3, 0, 2, 1)
+	case 14:
+		return tok("mint_to_checked", 2, 0, 1, 0)
+	case 6:
+		// authority type then an optional new authority
+		if len(accounts) < 2 || len(data) < 3 {
+			return nil, fmt.Errorf("token set_authority instruction is malformed")
+		}
+		movement := &Movement{Kind: KindAuthority, Instruction: "set_authority", Authority: accounts[1], Source: accounts[0]}
+		if data[2] == 1 {
+			if len(data) < 35 {
+				return nil, fmt.Errorf("token set_authority instruction is malformed")
+			}
+			movement.Destination = solana.PublicKeyFromBytes(data[3:35])
+		}
+		return movement, nil
+	case 9:
+		if len(accounts) < 3 {
+			return nil, fmt.Errorf("token close_account instruction is malformed")
+		}
+		return &Movement{Kind: KindAuthority, Instruction: "close_account", Authority: accounts[2], Source: accounts[0], Destination: accounts[1]}, nil
+	}
+
+	return nil, nil
+}
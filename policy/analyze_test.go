@@ -0,0 +1,426 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	bin "github.com/streamingfast/binary"
+	"github.com/streamingfast/solana-go"
+	"github.com/streamingfast/solana-go/programs/system"
+	"github.com/streamingfast/solana-go/programs/token"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testKey returns a distinct key for each `seed`.
+func testKey(seed byte) solana.PublicKey {
+	return solana.PublicKeyFromBytes(bytes.Repeat([]byte{seed}, 32))
+}
+
+var (
+	keyA = testKey(1)
+	keyB = testKey(2)
+	keyC = testKey(3)
+	keyD = testKey(4)
+	keyE = testKey(5)
+)
+
+// testInstruction is an instruction of a hand-built transaction.
+type testInstruction struct {
+	program  solana.PublicKey
+	accounts []solana.PublicKey
+	data     []byte
+}
+
+// newTestTransaction compiles `instructions` in a transaction signed by the
+// first account of the first instruction.
+func newTestTransaction(instructions ...testInstruction) *solana.Transaction {
+	trx := &solana.Transaction{Message: solana.Message{Header: solana.MessageHeader{NumRequiredSignatures: 1}}}
+
+	index := func(key solana.PublicKey) uint8 {
+		for idx, candidate := range trx.Message.AccountKeys {
+			if candidate.Equals(key) {
+				return uint8(idx)
+			}
+		}
+		trx.Message.AccountKeys = append(trx.Message.AccountKeys, key)
+		return uint8(len(trx.Message.AccountKeys) - 1)
+	}
+
+	for _, instruction := range instructions {
+		compiled := solana.CompiledInstruction{Data: instruction.data, DataLength: bin.Varuint16(len(instruction.data))}
+		for _, account := range instruction.accounts {
+			compiled.Accounts = append(compiled.Accounts, index(account))
+		}
+		compiled.AccountCount = bin.Varuint16(len(compiled.Accounts))
+		compiled.ProgramIDIndex = index(instruction.program)
+		trx.Message.Instructions = append(trx.Message.Instructions, compiled)
+	}
+
+	return trx
+}
+
+// data concatenates its parts, integers being little endian encoded with
+// their own size.
+func data(parts ...interface{}) []byte {
+	buf := new(bytes.Buffer)
+	for _, part := range parts {
+		switch v := part.(type) {
+		case []byte:
+			buf.Write(v)
+		case solana.PublicKey:
+			buf.Write(v[:])
+		case string:
+			buf.WriteString(v)
+		default:
+			if err := binary.Write(buf, binary.LittleEndian, v); err != nil {
+				panic(err)
+			}
+		}
+	}
+	return buf.Bytes()
+}
+
+func TestAnalyze_SystemInstructions(t *testing.T) {
+	tests := []struct {
+		name        string
+		accounts    []solana.PublicKey
+		data        []byte
+		expected    *Movement
+		expectedErr string
+	}{
+		{
+			name:     "create_account",
+			accounts: []solana.PublicKey{keyA, keyB},
+			data:     data(uint32(0), uint64(1000), uint64(165), token.PROGRAM_ID),
+			expected: &Movement{Kind: KindSOL, Instruction: "create_account", Authority: keyA, Source: keyA, Destination: keyB, Amount: 1000},
+		},
+		{
+			name:     "transfer",
+			accounts: []solana.PublicKey{keyA, keyB},
+			data:     data(uint32(2), uint64(2000)),
+			expected: &Movement{Kind: KindSOL, Instruction: "transfer", Authority: keyA, Source: keyA, Destination: keyB, Amount: 2000},
+		},
+		{
+			name:     "create_account_with_seed",
+			accounts: []solana.PublicKey{keyA, keyB, keyC},
+			data:     data(uint32(3), keyC, uint64(4), "seed", uint64(3000), uint64(165), token.PROGRAM_ID),
+			expected: &Movement{Kind: KindSOL, Instruction: "create_account_with_seed", Authority: keyA, Source: keyA, Destination: keyB, Amount: 3000},
+		},
+		{
+			name:     "withdraw_nonce_account",
+			accounts: []solana.PublicKey{keyA, keyB, keyC, keyD, keyE},
+			data:     data(uint32(5), uint64(4000)),
+			expected: &Movement{Kind: KindSOL, Instruction: "withdraw_nonce_account", Authority: keyE, Source: keyA, Destination: keyB, Amount: 4000},
+		},
+		{
+			name:     "authorize_nonce_account",
+			accounts: []solana.PublicKey{keyA, keyB},
+			data:     data(uint32(7), keyC),
+			expected: &Movement{Kind: KindAuthority, Instruction: "authorize_nonce_account", Authority: keyB, Source: keyA, Destination: keyC},
+		},
+		{
+			name:     "transfer_with_seed",
+			accounts: []solana.PublicKey{keyA, keyB, keyC},
+			data:     data(uint32(11), uint64(5000), uint64(4), "seed", token.PROGRAM_ID),
+			expected: &Movement{Kind: KindSOL, Instruction: "transfer_with_seed", Authority: keyB, Source: keyA, Destination: keyC, Amount: 5000},
+		},
+		{
+			name:     "assign",
+			accounts: []solana.PublicKey{keyA},
+			data:     data(uint32(1), token.PROGRAM_ID),
+			expected: &Movement{Kind: KindOwner, Instruction: "assign", Authority: keyA, Source: keyA, Destination: token.PROGRAM_ID},
+		},
+		{
+			name:     "allocate_with_seed",
+			accounts: []solana.PublicKey{keyA, keyB},
+			data:     data(uint32(9), keyB, uint64(4), "seed", uint64(165), token.PROGRAM_ID),
+			expected: &Movement{Kind: KindOwner, Instruction: "allocate_with_seed", Authority: keyB, Source: keyA, Destination: token.PROGRAM_ID},
+		},
+		{
+			name:     "assign_with_seed",
+			accounts: []solana.PublicKey{keyA, keyB},
+			data:     data(uint32(10), keyB, uint64(4), "seed", token.PROGRAM_ID),
+			expected: &Movement{Kind: KindOwner, Instruction: "assign_with_seed", Authority: keyB, Source: keyA, Destination: token.PROGRAM_ID},
+		},
+		{
+			name:     "advance_nonce_account moves nothing",
+			accounts: []solana.PublicKey{keyA, keyB, keyC},
+			data:     data(uint32(4)),
+		},
+		{
+			name:     "allocate moves nothing",
+			accounts: []solana.PublicKey{keyA},
+			data:     data(uint32(8), uint64(165)),
+		},
+		{
+			name:        "unknown instruction",
+			accounts:    []solana.PublicKey{keyA, keyB},
+			data:        data(uint32(13), uint64(1000)),
+			expectedErr: "instruction #1: unsupported system instruction 13",
+		},
+		{
+			name:        "too short",
+			accounts:    []solana.PublicKey{keyA, keyB},
+			data:        data(uint16(2)),
+			expectedErr: "instruction #1: system instruction too short",
+		},
+		{
+			name:        "transfer without amount",
+			accounts:    []solana.PublicKey{keyA, keyB},
+			data:        data(uint32(2), uint32(1000)),
+			expectedErr: "instruction #1: system transfer instruction is malformed",
+		},
+		{
+			name:        "transfer without destination",
+			accounts:    []solana.PublicKey{keyA},
+			data:        data(uint32(2), uint64(1000)),
+			expectedErr: "instruction #1: system transfer instruction is malformed",
+		},
+		{
+			name:        "withdraw_nonce_account without authority",
+			accounts:    []solana.PublicKey{keyA, keyB, keyC, keyD},
+			data:        data(uint32(5), uint64(4000)),
+			expectedErr: "instruction #1: system withdraw_nonce_account instruction is malformed",
+		},
+		{
+			name:        "create_account_with_seed with a seed longer than the data",
+			accounts:    []solana.PublicKey{keyA, keyB, keyC},
+			data:        data(uint32(3), keyC, uint64(1000), "seed", uint64(3000)),
+			expectedErr: "instruction #1: system create_account_with_seed instruction is malformed",
+		},
+		{
+			name:        "create_account_with_seed without lamports",
+			accounts:    []solana.PublicKey{keyA, keyB, keyC},
+			data:        data(uint32(3), keyC, uint64(4), "seed"),
+			expectedErr: "instruction #1: system create_account_with_seed instruction is malformed",
+		},
+		{
+			name:        "authorize_nonce_account without new authority",
+			accounts:    []solana.PublicKey{keyA, keyB},
+			data:        data(uint32(7), keyC[:16]),
+			expectedErr: "instruction #1: system authorize_nonce_account instruction is malformed",
+		},
+		{
+			name:        "assign without owner",
+			accounts:    []solana.PublicKey{keyA},
+			data:        data(uint32(1)),
+			expectedErr: "instruction #1: system assign instruction is malformed",
+		},
+		{
+			name:        "assign_with_seed without owner",
+			accounts:    []solana.PublicKey{keyA, keyB},
+			data:        data(uint32(10), keyB, uint64(4), "seed"),
+			expectedErr: "instruction #1: system assign_with_seed instruction is malformed",
+		},
+		{
+			name:        "allocate_with_seed without space",
+			accounts:    []solana.PublicKey{keyA, keyB},
+			data:        data(uint32(9), keyB, uint64(4), "seed", token.PROGRAM_ID),
+			expectedErr: "instruction #1: system allocate_with_seed instruction is malformed",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			summary, err := Analyze(newTestTransaction(testInstruction{program: system.PROGRAM_ID, accounts: test.accounts, data: test.data}))
+			if test.expectedErr != "" {
+				require.EqualError(t, err, test.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+
+			assert.Equal(t, []solana.PublicKey{system.PROGRAM_ID}, summary.Programs)
+			if test.expected == nil {
+				assert.Empty(t, summary.Movements)
+				return
+			}
+
+			test.expected.Program = system.PROGRAM_ID
+			assert.Equal(t, []*Movement{test.expected}, summary.Movements)
+		})
+	}
+}
+
+func TestAnalyze_TokenInstructions(t *testing.T) {
+	mint := keyD
+
+	tests := []struct {
+		name        string
+		accounts    []solana.PublicKey
+		data        []byte
+		expected    *Movement
+		expectedErr string
+	}{
+		{
+			name:     "transfer",
+			accounts: []solana.PublicKey{keyA, keyB, keyC},
+			data:     data(uint8(3), uint64(100)),
+			expected: &Movement{Kind: KindToken, Instruction: "transfer", Authority: keyC, Source: keyA, Destination: keyB, Amount: 100},
+		},
+		{
+			name:     "approve",
+			accounts: []solana.PublicKey{keyA, keyB, keyC},
+			data:     data(uint8(4), uint64(200)),
+			expected: &Movement{Kind: KindToken, Instruction: "approve", Authority: keyC, Source: keyA, Destination: keyB, Amount: 200},
+		},
+		{
+			name:     "mint_to",
+			accounts: []solana.PublicKey{mint, keyB, keyC},
+			data:     data(uint8(7), uint64(300)),
+			expected: &Movement{Kind: KindToken, Instruction: "mint_to", Authority: keyC, Source: mint, Destination: keyB, Amount: 300, Mint: &mint},
+		},
+		{
+			name:     "transfer_checked",
+			accounts: []solana.PublicKey{keyA, mint, keyB, keyC},
+			data:     data(uint8(12), uint64(400), uint8(6)),
+			expected: &Movement{Kind: KindToken, Instruction: "transfer_checked", Authority: keyC, Source: keyA, Destination: keyB, Amount: 400, Mint: &mint},
+		},
+		{
+			name:     "approve_checked",
+			accounts: []solana.PublicKey{keyA, mint, keyB, keyC},
+			data:     data(uint8(13), uint64(500), uint8(6)),
+			expected: &Movement{Kind: KindToken, Instruction: "approve_checked", Authority: keyC, Source: keyA, Destination: keyB, Amount: 500, Mint: &mint},
+		},
+		{
+			name:     "mint_to_checked",
+			accounts: []solana.PublicKey{mint, keyB, keyC},
+			data:     data(uint8(14), uint64(600), uint8(6)),
+			expected: &Movement{Kind: KindToken, Instruction: "mint_to_checked", Authority: keyC, Source: mint, Destination: keyB, Amount: 600, Mint: &mint},
+		},
+		{
+			name:     "set_authority to a new authority",
+			accounts: []solana.PublicKey{keyA, keyB},
+			data:     data(uint8(6), uint8(2), uint8(1), keyC),
+			expected: &Movement{Kind: KindAuthority, Instruction: "set_authority", Authority: keyB, Source: keyA, Destination: keyC},
+		},
+		{
+			name:     "set_authority to none",
+			accounts: []solana.PublicKey{keyA, keyB},
+			data:     data(uint8(6), uint8(2), uint8(0)),
+			expected: &Movement{Kind: KindAuthority, Instruction: "set_authority", Authority: keyB, Source: keyA},
+		},
+		{
+			name:     "close_account",
+			accounts: []solana.PublicKey{keyA, keyB, keyC},
+			data:     data(uint8(9)),
+			expected: &Movement{Kind: KindAuthority, Instruction: "close_account", Authority: keyC, Source: keyA, Destination: keyB},
+		},
+		{
+			name:     "initialize_account moves nothing",
+			accounts: []solana.PublicKey{keyA, mint, keyC, keyE},
+			data:     data(uint8(1)),
+		},
+		{
+			name:        "empty data",
+			accounts:    []solana.PublicKey{keyA},
+			data:        []byte{},
+			expectedErr: "instruction #1: token instruction too short",
+		},
+		{
+			name:        "transfer without amount",
+			accounts:    []solana.PublicKey{keyA, keyB, keyC},
+			data:        data(uint8(3), uint32(100)),
+			expectedErr: "instruction #1: token transfer instruction is malformed",
+		},
+		{
+			name:        "transfer without owner",
+			accounts:    []solana.PublicKey{keyA, keyB},
+			data:        data(uint8(3), uint64(100)),
+			expectedErr: "instruction #1: token transfer instruction is malformed",
+		},
+		{
+			name:        "transfer_checked without owner",
+			accounts:    []solana.PublicKey{keyA, mint, keyB},
+			data:        data(uint8(12), uint64(400), uint8(6)),
+			expectedErr: "instruction #1: token transfer_checked instruction is malformed",
+		},
+		{
+			name:        "set_authority without new authority",
+			accounts:    []solana.PublicKey{keyA, keyB},
+			data:        data(uint8(6), uint8(2), uint8(1), keyC[:16]),
+			expectedErr: "instruction #1: token set_authority instruction is malformed",
+		},
+		{
+			name:        "set_authority without authority type",
+			accounts:    []solana.PublicKey{keyA, keyB},
+			data:        data(uint8(6), uint8(2)),
+			expectedErr: "instruction #1: token set_authority instruction is malformed",
+		},
+		{
+			name:        "close_account without owner",
+			accounts:    []solana.PublicKey{keyA, keyB},
+			data:        data(uint8(9)),
+			expectedErr: "instruction #1: token close_account instruction is malformed",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			summary, err := Analyze(newTestTransaction(testInstruction{program: token.PROGRAM_ID, accounts: test.accounts, data: test.data}))
+			if test.expectedErr != "" {
+				require.EqualError(t, err, test.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+
+			assert.Equal(t, []solana.PublicKey{token.PROGRAM_ID}, summary.Programs)
+			if test.expected == nil {
+				assert.Empty(t, summary.Movements)
+				return
+			}
+
+			test.expected.Program = token.PROGRAM_ID
+			assert.Equal(t, []*Movement{test.expected}, summary.Movements)
+		})
+	}
+}
+
+func TestAnalyze_Transaction(t *testing.T) {
+	other := testKey(42)
+	trx := newTestTransaction(
+		testInstruction{program: system.PROGRAM_ID, accounts: []solana.PublicKey{keyA, keyB}, data: data(uint32(2), uint64(1000))},
+		testInstruction{program: other, accounts: []solana.PublicKey{keyA}, data: data(uint8(1))},
+		testInstruction{program: system.PROGRAM_ID, accounts: []solana.PublicKey{keyA, keyC}, data: data(uint32(2), uint64(2000))},
+	)
+
+	summary, err := Analyze(trx)
+	require.NoError(t, err)
+
+	assert.Equal(t, []solana.PublicKey{system.PROGRAM_ID, other}, summary.Programs)
+	require.Len(t, summary.Movements, 2)
+	assert.Equal(t, 0, summary.Movements[0].Index)
+	assert.Equal(t, 2, summary.Movements[1].Index)
+	assert.Equal(t, uint64(3000), summary.Lamports(&keyA))
+	assert.Equal(t, uint64(0), summary.Lamports(&keyB))
+	assert.Equal(t, uint64(3000), summary.Lamports(nil))
+}
+
+func TestAnalyze_InvalidIndices(t *testing.T) {
+	trx := newTestTransaction(testInstruction{program: system.PROGRAM_ID, accounts: []solana.PublicKey{keyA, keyB}, data: data(uint32(2), uint64(1000))})
+	trx.Message.Instructions[0].ProgramIDIndex = uint8(len(trx.Message.AccountKeys))
+
+	_, err := Analyze(trx)
+	assert.EqualError(t, err, "instruction #1: invalid program index")
+
+	trx = newTestTransaction(testInstruction{program: system.PROGRAM_ID, accounts: []solana.PublicKey{keyA, keyB}, data: data(uint32(2), uint64(1000))})
+	trx.Message.Instructions[0].Accounts[1] = uint8(len(trx.Message.AccountKeys))
+
+	_, err = Analyze(trx)
+	assert.EqualError(t, err, "instruction #1: invalid account index")
+}
@@ -0,0 +1,128 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"fmt"
+
+	"github.com/streamingfast/solana-go"
+)
+
+const lamportsPerSOL = 1_000_000_000
+
+// KeyPolicy restricts the transactions a vault key signs, the zero value
+// allows everything. The amounts only account for the SOL moved from the
+// key, the funds moved from other signers are bound by their own policy.
+type KeyPolicy struct {
+	MaxLamportsPerTransaction uint64 `json:"max_lamports_per_transaction,omitempty"`
+	// MaxLamportsPerDay bounds the SOL moved from the key during a UTC day.
+	MaxLamportsPerDay uint64 `json:"max_lamports_per_day,omitempty"`
+
+	AllowedPrograms     []solana.PublicKey `json:"allowed_programs,omitempty"`
+	AllowedMints        []solana.PublicKey `json:"allowed_mints,omitempty"`
+	AllowedDestinations []solana.PublicKey `json:"allowed_destinations,omitempty"`
+}
+
+// IsEmpty returns whether the policy allows everything.
+func (p *KeyPolicy) IsEmpty() bool {
+	return p == nil || (p.MaxLamportsPerTransaction == 0 && p.MaxLamportsPerDay == 0 &&
+		len(p.AllowedPrograms) == 0 && len(p.AllowedMints) == 0 && len(p.AllowedDestinations) == 0)
+}
+
+// Check returns a `*Violation` when signing the transaction summarized by
+// `summary` with `key` breaks the policy, `spentToday` being the lamports
+// already moved from `key` today.
+func (p *KeyPolicy) Check(key solana.PublicKey, summary *Summary, spentToday uint64) error {
+	if p.IsEmpty() {
+		return nil
+	}
+
+	violation := &Violation{}
+	refuse := func(format string, args ...interface{}) {
+		violation.Reasons = append(violation.Reasons, fmt.Sprintf("key %s: ", key)+fmt.Sprintf(format, args...))
+	}
+
+	if len(p.AllowedPrograms) > 0 {
+		for _, program := range summary.Programs {
+			if !containsKey(p.AllowedPrograms, program) {
+				refuse("program %s is not allowed", program)
+			}
+		}
+	}
+
+	lamports := summary.Lamports(&key)
+	if p.MaxLamportsPerTransaction > 0 && lamports > p.MaxLamportsPerTransaction {
+		refuse("transaction moves %s but at most %s are allowed per transaction", FormatSOL(lamports), FormatSOL(p.MaxLamportsPerTransaction))
+	}
+
+	if p.MaxLamportsPerDay > 0 && spentToday+lamports > p.MaxLamportsPerDay {
+		refuse("transaction moves %s, %s were already moved today and at most %s are allowed per day", FormatSOL(lamports), FormatSOL(spentToday), FormatSOL(p.MaxLamportsPerDay))
+	}
+
+	for _, movement := range summary.Movements {
+		if !movement.Authority.Equals(key) {
+			continue
+		}
+
+		if len(p.AllowedMints) > 0 && movement.Kind == KindToken {
+			if movement.Mint == nil {
+				refuse("%s: the mint of the token account is unknown", movement)
+			} else if !containsKey(p.AllowedMints, *movement.Mint) {
+				refuse("%s: mint %s is not allowed", movement, *movement.Mint)
+			}
+		}
+
+		if len(p.AllowedPrograms) > 0 && movement.Kind == KindOwner && !containsKey(p.AllowedPrograms, movement.Destination) {
+			refuse("%s: program %s is not allowed", movement, movement.Destination)
+		}
+
+		if len(p.AllowedDestinations) > 0 && !containsKey(p.AllowedDestinations, movement.Destination) {
+			refuse("%s: destination %s is not allowed", movement, movement.Destination)
+		}
+	}
+
+	if len(violation.Reasons) > 0 {
+		return violation
+	}
+	return nil
+}
+
+// NeedsMints returns whether checking the policy needs the mint of the token
+// movements, see `Summary.ResolveMints`.
+func (p *KeyPolicy) NeedsMints() bool {
+	return p != nil && len(p.AllowedMints) > 0
+}
+
+// ResolveMints sets the mint of the token movements decoded without one,
+// `resolve` returning the mint of a token account.
+func (s *Summary) ResolveMints(resolve func(tokenAccount solana.PublicKey) (solana.PublicKey, error)) error {
+	for _, movement := range s.Movements {
+		if movement.Kind != KindToken || movement.Mint != nil {
+			continue
+		}
+
+		mint, err := resolve(movement.Source)
+		if err != nil {
+			return fmt.Errorf("unable to resolve the mint of token account %s: %w", movement.Source, err)
+		}
+		movement.Mint = &mint
+	}
+	return nil
+}
+
+// FormatSOL formats `lamports` as a SOL amount.
+func FormatSOL(lamports uint64) string {
+	return fmt.Sprintf("%d.%09d SOL", lamports/lamportsPerSOL, lamports%lamportsPerSOL)
+}
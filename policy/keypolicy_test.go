@@ -0,0 +1,192 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/streamingfast/solana-go"
+	"github.com/streamingfast/solana-go/programs/system"
+	"github.com/streamingfast/solana-go/programs/token"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func transferSummary(t *testing.T, from, to solana.PublicKey, lamports uint64) *Summary {
+	summary, err := Analyze(newTestTransaction(testInstruction{program: system.PROGRAM_ID, accounts: []solana.PublicKey{from, to}, data: data(uint32(2), lamports)}))
+	require.NoError(t, err)
+	return summary
+}
+
+func tokenTransferSummary(t *testing.T, owner solana.PublicKey) *Summary {
+	summary, err := Analyze(newTestTransaction(testInstruction{program: token.PROGRAM_ID, accounts: []solana.PublicKey{keyB, keyC, owner}, data: data(uint8(3), uint64(100))}))
+	require.NoError(t, err)
+	return summary
+}
+
+func TestKeyPolicy_Check(t *testing.T) {
+	mint := keyD
+	otherMint := keyE
+
+	tests := []struct {
+		name            string
+		policy          *KeyPolicy
+		summary         func(t *testing.T) *Summary
+		spentToday      uint64
+		expectedReasons []string
+	}{
+		{
+			name:    "no policy",
+			policy:  nil,
+			summary: func(t *testing.T) *Summary { return transferSummary(t, keyA, keyB, 10*lamportsPerSOL) },
+		},
+		{
+			name:    "under the transaction limit",
+			policy:  &KeyPolicy{MaxLamportsPerTransaction: lamportsPerSOL},
+			summary: func(t *testing.T) *Summary { return transferSummary(t, keyA, keyB, lamportsPerSOL) },
+		},
+		{
+			name:    "over the transaction limit",
+			policy:  &KeyPolicy{MaxLamportsPerTransaction: lamportsPerSOL},
+			summary: func(t *testing.T) *Summary { return transferSummary(t, keyA, keyB, lamportsPerSOL+1) },
+			expectedReasons: []string{
+				fmt.Sprintf("key %s: transaction moves 1.000000001 SOL but at most 1.000000000 SOL are allowed per transaction", keyA),
+			},
+		},
+		{
+			name:       "reaching the daily limit",
+			policy:     &KeyPolicy{MaxLamportsPerDay: 5 * lamportsPerSOL},
+			summary:    func(t *testing.T) *Summary { return transferSummary(t, keyA, keyB, 2*lamportsPerSOL) },
+			spentToday: 3 * lamportsPerSOL,
+		},
+		{
+			name:       "over the daily limit",
+			policy:     &KeyPolicy{MaxLamportsPerDay: 5 * lamportsPerSOL},
+			summary:    func(t *testing.T) *Summary { return transferSummary(t, keyA, keyB, 2*lamportsPerSOL) },
+			spentToday: 3*lamportsPerSOL + 1,
+			expectedReasons: []string{
+				fmt.Sprintf("key %s: transaction moves 2.000000000 SOL, 3.000000001 SOL were already moved today and at most 5.000000000 SOL are allowed per day", keyA),
+			},
+		},
+		{
+			name:       "other keys do not count toward the limits",
+			policy:     &KeyPolicy{MaxLamportsPerTransaction: 1, MaxLamportsPerDay: 1},
+			summary:    func(t *testing.T) *Summary { return transferSummary(t, keyC, keyB, lamportsPerSOL) },
+			spentToday: 1,
+		},
+		{
+			name:    "program not allowed",
+			policy:  &KeyPolicy{AllowedPrograms: []solana.PublicKey{token.PROGRAM_ID}},
+			summary: func(t *testing.T) *Summary { return transferSummary(t, keyA, keyB, 1) },
+			expectedReasons: []string{
+				fmt.Sprintf("key %s: program %s is not allowed", keyA, system.PROGRAM_ID),
+			},
+		},
+		{
+			name:   "account assigned to a program not allowed",
+			policy: &KeyPolicy{AllowedPrograms: []solana.PublicKey{system.PROGRAM_ID}},
+			summary: func(t *testing.T) *Summary {
+				summary, err := Analyze(newTestTransaction(testInstruction{program: system.PROGRAM_ID, accounts: []solana.PublicKey{keyA}, data: data(uint32(1), token.PROGRAM_ID)}))
+				require.NoError(t, err)
+				return summary
+			},
+			expectedReasons: []string{
+				fmt.Sprintf("key %s: assign of %s to %s: program %s is not allowed", keyA, keyA, token.PROGRAM_ID, token.PROGRAM_ID),
+			},
+		},
+		{
+			name:    "destination allowed",
+			policy:  &KeyPolicy{AllowedDestinations: []solana.PublicKey{keyB}},
+			summary: func(t *testing.T) *Summary { return transferSummary(t, keyA, keyB, 1) },
+		},
+		{
+			name:    "destination not allowed",
+			policy:  &KeyPolicy{AllowedDestinations: []solana.PublicKey{keyC}},
+			summary: func(t *testing.T) *Summary { return transferSummary(t, keyA, keyB, 1) },
+			expectedReasons: []string{
+				fmt.Sprintf("key %s: transfer of 0.000000001 SOL from %s to %s: destination %s is not allowed", keyA, keyA, keyB, keyB),
+			},
+		},
+		{
+			name:   "mint allowed",
+			policy: &KeyPolicy{AllowedMints: []solana.PublicKey{mint}},
+			summary: func(t *testing.T) *Summary {
+				summary := tokenTransferSummary(t, keyA)
+				summary.Movements[0].Mint = &mint
+				return summary
+			},
+		},
+		{
+			name:   "mint not allowed",
+			policy: &KeyPolicy{AllowedMints: []solana.PublicKey{mint}},
+			summary: func(t *testing.T) *Summary {
+				summary := tokenTransferSummary(t, keyA)
+				summary.Movements[0].Mint = &otherMint
+				return summary
+			},
+			expectedReasons: []string{
+				fmt.Sprintf("key %s: transfer of 100 token units from %s to %s: mint %s is not allowed", keyA, keyB, keyC, otherMint),
+			},
+		},
+		{
+			name:    "unresolved mint",
+			policy:  &KeyPolicy{AllowedMints: []solana.PublicKey{mint}},
+			summary: func(t *testing.T) *Summary { return tokenTransferSummary(t, keyA) },
+			expectedReasons: []string{
+				fmt.Sprintf("key %s: transfer of 100 token units from %s to %s: the mint of the token account is unknown", keyA, keyB, keyC),
+			},
+		},
+		{
+			name:    "token moved by another owner",
+			policy:  &KeyPolicy{AllowedMints: []solana.PublicKey{mint}},
+			summary: func(t *testing.T) *Summary { return tokenTransferSummary(t, keyE) },
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.policy.Check(keyA, test.summary(t), test.spentToday)
+			if len(test.expectedReasons) == 0 {
+				require.NoError(t, err)
+				return
+			}
+
+			var violation *Violation
+			require.True(t, errors.As(err, &violation), "expected a violation, got %v", err)
+			assert.Equal(t, test.expectedReasons, violation.Reasons)
+		})
+	}
+}
+
+func TestSummary_ResolveMints(t *testing.T) {
+	mint := keyD
+	summary := tokenTransferSummary(t, keyA)
+
+	require.NoError(t, summary.ResolveMints(func(tokenAccount solana.PublicKey) (solana.PublicKey, error) {
+		assert.Equal(t, keyB, tokenAccount)
+		return mint, nil
+	}))
+	require.NotNil(t, summary.Movements[0].Mint)
+	assert.Equal(t, mint, *summary.Movements[0].Mint)
+
+	summary = tokenTransferSummary(t, keyA)
+	err := summary.ResolveMints(func(tokenAccount solana.PublicKey) (solana.PublicKey, error) {
+		return solana.PublicKey{}, errors.New("account not found")
+	})
+	assert.Error(t, err)
+	assert.Nil(t, summary.Movements[0].Mint)
+}
@@ -0,0 +1,94 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/streamingfast/solana-go"
+)
+
+// Rules restrict the transactions that may be signed, the zero value
+// allows everything.
+type Rules struct {
+	// AllowedPrograms lists the only programs the transaction may invoke, or
+	// hand accounts over to.
+	AllowedPrograms []solana.PublicKey
+	// MaxLamports bounds the lamports moved by the transaction, 0 means no
+	// bound.
+	MaxLamports uint64
+	// AllowedDestinations lists the only accounts funds and authorities may
+	// be moved to.
+	AllowedDestinations []solana.PublicKey
+}
+
+// Violation is the refusal of a transaction by a policy, it lists every
+// rule the transaction breaks.
+type Violation struct {
+	Reasons []string
+}
+
+func (v *Violation) Error() string {
+	return "transaction refused by policy: " + strings.Join(v.Reasons, "; ")
+}
+
+// Check returns a `*Violation` when the transaction summarized by `summary`
+// breaks the rules.
+func (r *Rules) Check(summary *Summary) error {
+	violation := &Violation{}
+
+	if len(r.AllowedPrograms) > 0 {
+		for _, program := range summary.Programs {
+			if !containsKey(r.AllowedPrograms, program) {
+				violation.Reasons = append(violation.Reasons, fmt.Sprintf("program %s is not allowed", program))
+			}
+		}
+
+		for _, movement := range summary.Movements {
+			if movement.Kind == KindOwner && !containsKey(r.AllowedPrograms, movement.Destination) {
+				violation.Reasons = append(violation.Reasons, fmt.Sprintf("%s: program %s is not allowed", movement, movement.Destination))
+			}
+		}
+	}
+
+	if r.MaxLamports > 0 {
+		if lamports := summary.Lamports(nil); lamports > r.MaxLamports {
+			violation.Reasons = append(violation.Reasons, fmt.Sprintf("%d lamports moved but at most %d are allowed per transaction", lamports, r.MaxLamports))
+		}
+	}
+
+	if len(r.AllowedDestinations) > 0 {
+		for _, movement := range summary.Movements {
+			if !containsKey(r.AllowedDestinations, movement.Destination) {
+				violation.Reasons = append(violation.Reasons, fmt.Sprintf("%s: destination %s is not allowed", movement, movement.Destination))
+			}
+		}
+	}
+
+	if len(violation.Reasons) > 0 {
+		return violation
+	}
+	return nil
+}
+
+func containsKey(keys []solana.PublicKey, key solana.PublicKey) bool {
+	for _, candidate := range keys {
+		if candidate.Equals(key) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,341 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package signer implements an authenticated HTTP API signing serialized
+// transactions with the keys of a vault, once they pass a policy.
+package signer
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	bin "github.com/streamingfast/binary"
+	"github.com/streamingfast/derr"
+	"github.com/streamingfast/dhttp"
+	"github.com/streamingfast/slnc/policy"
+	"github.com/streamingfast/solana-go"
+	"go.uber.org/zap"
+)
+
+// maxRequestSize bounds the body of requests, a transaction is at most 1232
+// bytes once serialized.
+const maxRequestSize = 64 * 1024
+
+// SignFunc signs `message` with the private key of `pub`.
+type SignFunc func(pub solana.PublicKey, message []byte) (solana.Signature, error)
+
+// SignersCheckFunc refuses, returning an error, the transaction `trx`,
+// summarized by `summary`, to be signed by `signers`.
+type SignersCheckFunc func(trx *solana.Transaction, summary *policy.Summary, signers []solana.PublicKey) error
+
+// SignedFunc is called with the transaction once signed by `signers`,
+// the signatures are not returned when it fails.
+type SignedFunc func(trx *solana.Transaction, signers []solana.PublicKey) error
+
+// Key is a key the server signs with.
+type Key struct {
+	PublicKey solana.PublicKey `json:"public_key"`
+	Label     string           `json:"label,omitempty"`
+}
+
+// Server is the signing HTTP API.
+type Server struct {
+	keys   []*Key
+	sign   SignFunc
+	rules  *policy.Rules
+	check  SignersCheckFunc
+	signed SignedFunc
+	tokens [][sha256.Size]byte
+	logger *zap.Logger
+
+	httpServer *http.Server
+}
+
+// NewServer returns a server signing with `keys` through `sign`, accepting
+// only the transactions passing `rules` and only the requests bearing one
+// of `tokens`.
+func NewServer(keys []*Key, sign SignFunc, rules *policy.Rules, tokens []string, logger *zap.Logger) *Server {
+	s := &Server{
+		keys:   keys,
+		sign:   sign,
+		rules:  rules,
+		logger: logger,
+	}
+
+	// Hashed so comparisons take the same time whatever the token length
+	for _, token := range tokens {
+		s.tokens = append(s.tokens, sha256.Sum256([]byte(token)))
+	}
+
+	return s
+}
+
+// SetSignersCheck adds a check of the transactions against the keys about
+// to sign them, evaluated after the rules of the server.
+func (s *Server) SetSignersCheck(check SignersCheckFunc) {
+	s.check = check
+}
+
+// SetSignedHook sets the function called with every signed transaction.
+func (s *Server) SetSignedHook(signed SignedFunc) {
+	s.signed = signed
+}
+
+// Handler returns the routes of the API:
+//
+//	GET  /healthz   liveness, not authenticated
+//	GET  /v1/keys   the keys the server signs with
+//	POST /v1/sign   signs a transaction
+func (s *Server) Handler() http.Handler {
+	router := mux.NewRouter()
+	router.Use(dhttp.NewAddLoggerToContextMiddleware(s.logger))
+
+	router.Methods("GET").Path("/healthz").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		dhttp.WriteText(r.Context(), w, "ok")
+	})
+
+	api := router.PathPrefix("/v1").Subrouter()
+	api.Use(s.authenticate)
+	api.Methods("GET").Path("/keys").Handler(dhttp.JSONHandler(s.listKeys))
+	api.Methods("POST").Path("/sign").Handler(dhttp.JSONHandler(s.signTransaction))
+
+	return router
+}
+
+// ListenAndServe serves the API on `addr` until `Shutdown` is called, over
+// TLS when `certFile` and `keyFile` are set.
+func (s *Server) ListenAndServe(addr, certFile, keyFile string) error {
+	s.httpServer = &http.Server{
+		Addr:              addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		WriteTimeout:      30 * time.Second,
+	}
+
+	var err error
+	if certFile != "" || keyFile != "" {
+		err = s.httpServer.ListenAndServeTLS(certFile, keyFile)
+	} else {
+		err = s.httpServer.ListenAndServe()
+	}
+	if err == http.ErrServerClosed {
+		return nil
+	}
+	return err
+}
+
+// Shutdown stops the server, waiting for the requests in flight.
+func (s *Server) Shutdown(ctx context.Context) error {
+	if s.httpServer == nil {
+		return nil
+	}
+	return s.httpServer.Shutdown(ctx)
+}
+
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		if !strings.HasPrefix(header, "Bearer ") || !s.validToken(strings.TrimPrefix(header, "Bearer ")) {
+			dhttp.WriteError(r.Context(), w, derr.HTTPUnauthorizedError(r.Context(), nil, derr.C("invalid_token_error"), "a valid bearer token is required"))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) validToken(token string) bool {
+	hash := sha256.Sum256([]byte(token))
+
+	valid := 0
+	for _, candidate := range s.tokens {
+		valid |= subtle.ConstantTimeCompare(hash[:], candidate[:])
+	}
+	return valid == 1
+}
+
+type keysResponse struct {
+	Keys []*Key `json:"keys"`
+}
+
+func (s *Server) listKeys(r *http.Request) (interface{}, error) {
+	return &keysResponse{Keys: s.keys}, nil
+}
+
+type signRequest struct {
+	// Transaction is the base64 encoded serialized transaction
+	Transaction string `json:"transaction"`
+	// Signers restricts the keys signing, all the required signers held by
+	// the server sign when empty.
+	Signers []string `json:"signers"`
+}
+
+type signResponse struct {
+	Signatures []*signature `json:"signatures"`
+	// Transaction is the base64 encoded transaction with the signatures added
+	Transaction string `json:"transaction"`
+}
+
+type signature struct {
+	PublicKey solana.PublicKey `json:"public_key"`
+	Signature string           `json:"signature"`
+}
+
+func (s *Server) signTransaction(r *http.Request) (interface{}, error) {
+	ctx := r.Context()
+	r.Body = http.MaxBytesReader(nil, r.Body, maxRequestSize)
+
+	req := &signRequest{}
+	if err := dhttp.ExtractJSONRequest(ctx, r, req, dhttp.NoValidation); err != nil {
+		return nil, err
+	}
+
+	data, err := base64.StdEncoding.DecodeString(req.Transaction)
+	if err != nil {
+		return nil, derr.HTTPBadRequestError(ctx, err, derr.C("invalid_transaction_error"), "transaction must be base64 encoded")
+	}
+
+	trx, err := solana.TransactionFromData(data)
+	if err != nil {
+		return nil, derr.HTTPBadRequestError(ctx, err, derr.C("invalid_transaction_error"), "unable to decode transaction")
+	}
+
+	// Signatures are indexed by signer and signers are the first accounts,
+	// the header is checked before relying on it.
+	numSigners := int(trx.Message.Header.NumRequiredSignatures)
+	if numSigners > len(trx.Message.AccountKeys) {
+		err := fmt.Errorf("message requires %d signatures but has only %d accounts", numSigners, len(trx.Message.AccountKeys))
+		return nil, derr.HTTPBadRequestError(ctx, err, derr.C("invalid_transaction_error"), err.Error())
+	}
+	if len(trx.Signatures) > numSigners {
+		err := fmt.Errorf("transaction has %d signatures but its message requires %d", len(trx.Signatures), numSigners)
+		return nil, derr.HTTPBadRequestError(ctx, err, derr.C("invalid_transaction_error"), err.Error())
+	}
+
+	allowed, err := s.allowedSigners(req.Signers)
+	if err != nil {
+		return nil, derr.HTTPBadRequestError(ctx, err, derr.C("invalid_signers_error"), err.Error())
+	}
+
+	summary, err := policy.Analyze(trx)
+	if err != nil {
+		return nil, derr.HTTPBadRequestError(ctx, err, derr.C("invalid_transaction_error"), err.Error())
+	}
+
+	if err := s.rules.Check(summary); err != nil {
+		s.logger.Info("transaction refused by policy", zap.Error(err))
+		return nil, derr.HTTPForbiddenError(ctx, err, derr.C("policy_violation_error"), err.Error())
+	}
+
+	buf := new(bytes.Buffer)
+	if err := bin.NewEncoder(buf).Encode(trx.Message); err != nil {
+		return nil, derr.HTTPBadRequestError(ctx, err, derr.C("invalid_transaction_error"), "unable to encode transaction message")
+	}
+	message := buf.Bytes()
+
+	signers := trx.Message.AccountKeys[0:trx.Message.Header.NumRequiredSignatures]
+	if len(trx.Signatures) != len(signers) {
+		signatures := make([]solana.Signature, len(signers))
+		copy(signatures, trx.Signatures)
+		trx.Signatures = signatures
+	}
+
+	var signing []solana.PublicKey
+	for _, key := range signers {
+		if allowed[key] {
+			signing = append(signing, key)
+		}
+	}
+
+	if len(signing) == 0 {
+		return nil, derr.HTTPBadRequestError(ctx, nil, derr.C("no_signer_error"), "none of the required signers of the transaction are held by this signer")
+	}
+
+	if s.check != nil {
+		if err := s.check(trx, summary, signing); err != nil {
+			s.logger.Info("transaction refused by signers check", zap.Error(err))
+			return nil, derr.HTTPForbiddenError(ctx, err, derr.C("policy_violation_error"), err.Error())
+		}
+	}
+
+	resp := &signResponse{}
+	for idx, key := range signers {
+		if !allowed[key] {
+			continue
+		}
+
+		sig, err := s.sign(key, message)
+		if err != nil {
+			return nil, derr.HTTPInternalServerError(ctx, err, derr.C("signing_error"), fmt.Sprintf("unable to sign with key %s", key))
+		}
+
+		trx.Signatures[idx] = sig
+		resp.Signatures = append(resp.Signatures, &signature{PublicKey: key, Signature: sig.String()})
+	}
+
+	if s.signed != nil {
+		if err := s.signed(trx, signing); err != nil {
+			return nil, derr.HTTPInternalServerError(ctx, err, derr.C("signed_hook_error"), "unable to record signed transaction")
+		}
+	}
+
+	out := new(bytes.Buffer)
+	if err := bin.NewEncoder(out).Encode(trx); err != nil {
+		return nil, derr.HTTPInternalServerError(ctx, err, derr.C("encoding_error"), "unable to encode signed transaction")
+	}
+	resp.Transaction = base64.StdEncoding.EncodeToString(out.Bytes())
+
+	for _, sig := range resp.Signatures {
+		s.logger.Info("signed transaction", zap.Stringer("public_key", sig.PublicKey), zap.String("signature", sig.Signature))
+	}
+
+	return resp, nil
+}
+
+// allowedSigners returns the held keys among `requested`, all the held keys
+// when empty.
+func (s *Server) allowedSigners(requested []string) (map[solana.PublicKey]bool, error) {
+	held := map[solana.PublicKey]bool{}
+	for _, key := range s.keys {
+		held[key.PublicKey] = true
+	}
+
+	if len(requested) == 0 {
+		return held, nil
+	}
+
+	allowed := map[solana.PublicKey]bool{}
+	for _, in := range requested {
+		pub, err := solana.PublicKeyFromBase58(in)
+		if err != nil {
+			return nil, fmt.Errorf("invalid signer %q: %w", in, err)
+		}
+
+		if !held[pub] {
+			return nil, fmt.Errorf("signer %s is not held by this signer", pub)
+		}
+		allowed[pub] = true
+	}
+
+	return allowed, nil
+}
@@ -0,0 +1,149 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signer
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	bin "github.com/streamingfast/binary"
+	"github.com/streamingfast/slnc/policy"
+	"github.com/streamingfast/solana-go"
+	"github.com/streamingfast/solana-go/programs/system"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+const testToken = "slnc-test-token"
+
+func newTestServer(t *testing.T, privateKey solana.PrivateKey) *httptest.Server {
+	sign := func(pub solana.PublicKey, message []byte) (solana.Signature, error) {
+		require.Equal(t, privateKey.PublicKey(), pub)
+		return privateKey.Sign(message)
+	}
+
+	s := NewServer([]*Key{{PublicKey: privateKey.PublicKey()}}, sign, &policy.Rules{}, []string{testToken}, zap.NewNop())
+	server := httptest.NewServer(s.Handler())
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+// newTestTransfer returns a System transfer of 1000 lamports from `from`
+// to a random address.
+func newTestTransfer(t *testing.T, from solana.PublicKey) *solana.Transaction {
+	to, _, err := solana.NewRandomPrivateKey()
+	require.NoError(t, err)
+
+	data := make([]byte, 12)
+	binary.LittleEndian.PutUint32(data, 2)
+	binary.LittleEndian.PutUint64(data[4:], 1000)
+
+	return &solana.Transaction{
+		Message: solana.Message{
+			Header:      solana.MessageHeader{NumRequiredSignatures: 1, NumReadonlyUnsignedAccounts: 1},
+			AccountKeys: []solana.PublicKey{from, to, system.PROGRAM_ID},
+			Instructions: []solana.CompiledInstruction{{
+				ProgramIDIndex: 2,
+				AccountCount:   2,
+				Accounts:       []uint8{0, 1},
+				DataLength:     bin.Varuint16(len(data)),
+				Data:           data,
+			}},
+		},
+	}
+}
+
+func postSign(t *testing.T, server *httptest.Server, trx *solana.Transaction) (*http.Response, *signResponse) {
+	buf := new(bytes.Buffer)
+	require.NoError(t, bin.NewEncoder(buf).Encode(trx))
+
+	body, err := json.Marshal(&signRequest{Transaction: base64.StdEncoding.EncodeToString(buf.Bytes())})
+	require.NoError(t, err)
+
+	req, err := http.NewRequest("POST", server.URL+"/v1/sign", bytes.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+testToken)
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	out := &signResponse{}
+	if resp.StatusCode == http.StatusOK {
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(out))
+	}
+
+	return resp, out
+}
+
+func TestServer_SignTransaction(t *testing.T) {
+	pub, privateKey, err := solana.NewRandomPrivateKey()
+	require.NoError(t, err)
+	server := newTestServer(t, privateKey)
+
+	trx := newTestTransfer(t, pub)
+	resp, out := postSign(t, server, trx)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Len(t, out.Signatures, 1)
+	assert.Equal(t, pub, out.Signatures[0].PublicKey)
+
+	message := new(bytes.Buffer)
+	require.NoError(t, bin.NewEncoder(message).Encode(trx.Message))
+
+	signature, err := solana.NewSignatureFromBase58(out.Signatures[0].Signature)
+	require.NoError(t, err)
+	assert.True(t, signature.Verify(pub, message.Bytes()))
+}
+
+func TestServer_SignTransaction_MalformedHeader(t *testing.T) {
+	pub, privateKey, err := solana.NewRandomPrivateKey()
+	require.NoError(t, err)
+	server := newTestServer(t, privateKey)
+
+	tests := []struct {
+		name   string
+		mutate func(trx *solana.Transaction)
+	}{
+		{
+			name: "more signers than accounts",
+			mutate: func(trx *solana.Transaction) {
+				trx.Message.Header.NumRequiredSignatures = uint8(len(trx.Message.AccountKeys) + 1)
+			},
+		},
+		{
+			name: "more signatures than signers",
+			mutate: func(trx *solana.Transaction) {
+				trx.Signatures = make([]solana.Signature, trx.Message.Header.NumRequiredSignatures+1)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			trx := newTestTransfer(t, pub)
+			test.mutate(trx)
+
+			resp, _ := postSign(t, server, trx)
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		})
+	}
+}
@@ -0,0 +1,178 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vault
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+// DEKWrapper wraps and unwraps data encryption keys with a key held by a
+// key management service, which never discloses it.
+type DEKWrapper interface {
+	WrapDEK(plainDEK []byte) (string, error)
+	UnwrapDEK(wrappedDEK string) ([]byte, error)
+}
+
+// EnvelopeManager does envelope encryption: the data is encrypted locally
+// with a random data encryption key (DEK), stored next to the data once
+// wrapped by the key management service.
+type EnvelopeManager struct {
+	wrapper DEKWrapper
+
+	dekCache        map[string][32]byte
+	dekCacheLock    sync.Mutex
+	localDEK        [32]byte
+	localWrappedDEK string
+}
+
+func NewEnvelopeManager(wrapper DEKWrapper) *EnvelopeManager {
+	return &EnvelopeManager{wrapper: wrapper}
+}
+
+func (k *EnvelopeManager) setupEncryption() error {
+	if k.dekCache != nil {
+		return nil
+	}
+
+	_, err := rand.Read(k.localDEK[:])
+	if err != nil {
+		return err
+	}
+
+	wrappedDEK, err := k.wrapper.WrapDEK(k.localDEK[:])
+	if err != nil {
+		return err
+	}
+
+	k.localWrappedDEK = wrappedDEK
+	k.dekCache = map[string][32]byte{wrappedDEK: k.localDEK}
+
+	return nil
+}
+
+func (k *EnvelopeManager) fetchPlainDEK(wrappedDEK string) (out [32]byte, err error) {
+	k.dekCacheLock.Lock()
+	defer k.dekCacheLock.Unlock()
+
+	if cachedKey, found := k.dekCache[wrappedDEK]; found {
+		return cachedKey, nil
+	}
+
+	plainKey, err := k.wrapper.UnwrapDEK(wrappedDEK)
+	if err != nil {
+		return
+	}
+
+	copy(out[:], plainKey)
+
+	if k.dekCache == nil {
+		k.dekCache = map[string][32]byte{}
+	}
+	if k.localWrappedDEK == "" {
+		k.localWrappedDEK = wrappedDEK
+	}
+	k.dekCache[wrappedDEK] = out
+
+	return
+}
+
+type BlobV1 struct {
+	Version       int      `bson:"version"`
+	WrappedDEK    string   `bson:"wrapped_dek"`
+	Nonce         [24]byte `bson:"nonce"`
+	EncryptedData []byte   `bson:"data"`
+}
+
+func (k *EnvelopeManager) Encrypt(in []byte) ([]byte, error) {
+	if err := k.setupEncryption(); err != nil {
+		return nil, err
+	}
+
+	var nonce [24]byte
+	if _, err := io.ReadFull(rand.Reader, nonce[:]); err != nil {
+		return nil, err
+	}
+
+	var sealedMsg []byte
+	sealedMsg = secretbox.Seal(sealedMsg, in, &nonce, &k.localDEK)
+
+	blob := &BlobV1{
+		Version:       1,
+		WrappedDEK:    k.localWrappedDEK,
+		Nonce:         nonce,
+		EncryptedData: sealedMsg,
+	}
+
+	cereal, err := json.Marshal(blob)
+	if err != nil {
+		return nil, err
+	}
+
+	return cereal, nil
+}
+
+func (k *EnvelopeManager) Decrypt(in []byte) ([]byte, error) {
+	var blob BlobV1
+	err := json.Unmarshal(in, &blob)
+	if err != nil {
+		return nil, err
+	}
+
+	// No need to check `blob.Version` == 1, we did it already with
+	// the `magicFound` comparison.
+
+	plainDEK, err := k.fetchPlainDEK(blob.WrappedDEK)
+	if err != nil {
+		return nil, err
+	}
+
+	plainData, ok := secretbox.Open(nil, blob.EncryptedData, &blob.Nonce, &plainDEK)
+	if !ok {
+		return nil, fmt.Errorf("failed decrypting data, that's all we know")
+	}
+
+	return plainData, nil
+}
+
+// sealWithManager and openWithManager are the `SecretBoxer` side of the
+// KMS backed boxers, the ciphertext is the base64 encoded `BlobV1`.
+func sealWithManager(mgr KMSManager, in []byte) (string, error) {
+	encrypted, err := mgr.Encrypt(in)
+	if err != nil {
+		return "", fmt.Errorf("kms encryption, %s", err)
+	}
+
+	return base64.RawStdEncoding.EncodeToString(encrypted), nil
+}
+
+func openWithManager(mgr KMSManager, in string) ([]byte, error) {
+	data, err := base64.RawStdEncoding.DecodeString(in)
+	if err != nil {
+		return []byte{}, fmt.Errorf("base 64 decode, %s", err)
+	}
+
+	out, err := mgr.Decrypt(data)
+	if err != nil {
+		return []byte{}, fmt.Errorf("kms decryption, %s", err)
+	}
+	return out, nil
+}
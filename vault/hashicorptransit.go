@@ -0,0 +1,185 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vault
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+type HashicorpTransitBoxer struct {
+	key     string
+	address string
+}
+
+// NewHashicorpTransitBoxer returns a boxer wrapping its data encryption keys
+// with the HashiCorp Vault Transit key `key`, either `{mount}/{name}` or
+// `{name}` on the default `transit` mount. The server is `address`, or
+// `VAULT_ADDR` when empty, and the token is read from `VAULT_TOKEN`.
+func NewHashicorpTransitBoxer(key, address string) *HashicorpTransitBoxer {
+	return &HashicorpTransitBoxer{
+		key:     key,
+		address: address,
+	}
+}
+
+func (b *HashicorpTransitBoxer) Seal(in []byte) (string, error) {
+	mgr, err := NewHashicorpTransitManager(b.key, b.address)
+	if err != nil {
+		return "", fmt.Errorf("new hashicorp transit manager, %s", err)
+	}
+
+	return sealWithManager(mgr, in)
+}
+
+func (b *HashicorpTransitBoxer) Open(in string) ([]byte, error) {
+	mgr, err := NewHashicorpTransitManager(b.key, b.address)
+	if err != nil {
+		return []byte{}, fmt.Errorf("new hashicorp transit manager, %s", err)
+	}
+
+	return openWithManager(mgr, in)
+}
+
+func (b *HashicorpTransitBoxer) WrapType() string {
+	return "hashicorp-transit"
+}
+
+// HashicorpTransitManager wraps the data encryption keys of an
+// `EnvelopeManager` with a HashiCorp Vault Transit key.
+type HashicorpTransitManager struct {
+	*EnvelopeManager
+
+	client  *http.Client
+	address string
+	token   string
+	mount   string
+	keyName string
+}
+
+func NewHashicorpTransitManager(key, address string) (*HashicorpTransitManager, error) {
+	if address == "" {
+		address = os.Getenv("VAULT_ADDR")
+	}
+	if address == "" {
+		return nil, fmt.Errorf("no HashiCorp Vault address, set it through the kms endpoint or VAULT_ADDR")
+	}
+
+	token := os.Getenv("VAULT_TOKEN")
+	if token == "" {
+		return nil, fmt.Errorf("no HashiCorp Vault token, set it through VAULT_TOKEN")
+	}
+
+	mount, keyName := "transit", strings.Trim(key, "/")
+	if idx := strings.LastIndex(keyName, "/"); idx >= 0 {
+		mount, keyName = keyName[:idx], keyName[idx+1:]
+	}
+	if keyName == "" {
+		return nil, fmt.Errorf("invalid transit key %q", key)
+	}
+
+	manager := &HashicorpTransitManager{
+		client:  &http.Client{Timeout: 30 * time.Second},
+		address: strings.TrimRight(address, "/"),
+		token:   token,
+		mount:   mount,
+		keyName: keyName,
+	}
+	manager.EnvelopeManager = NewEnvelopeManager(manager)
+
+	return manager, nil
+}
+
+type transitResponse struct {
+	Data struct {
+		Ciphertext string `json:"ciphertext"`
+		Plaintext  string `json:"plaintext"`
+	} `json:"data"`
+	Errors []string `json:"errors"`
+}
+
+func (k *HashicorpTransitManager) WrapDEK(plainDEK []byte) (string, error) {
+	resp, err := k.call("encrypt", map[string]string{"plaintext": base64.StdEncoding.EncodeToString(plainDEK)})
+	if err != nil {
+		return "", err
+	}
+
+	if resp.Data.Ciphertext == "" {
+		return "", fmt.Errorf("transit encrypt returned no ciphertext")
+	}
+
+	return resp.Data.Ciphertext, nil
+}
+
+func (k *HashicorpTransitManager) UnwrapDEK(wrappedDEK string) ([]byte, error) {
+	resp, err := k.call("decrypt", map[string]string{"ciphertext": wrappedDEK})
+	if err != nil {
+		return nil, err
+	}
+
+	return base64.StdEncoding.DecodeString(resp.Data.Plaintext)
+}
+
+func (k *HashicorpTransitManager) call(operation string, payload interface{}) (*transitResponse, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s/v1/%s/%s/%s", k.address, k.mount, operation, k.keyName)
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Vault-Token", k.token)
+	if namespace := os.Getenv("VAULT_NAMESPACE"); namespace != "" {
+		req.Header.Set("X-Vault-Namespace", namespace)
+	}
+
+	httpResp, err := k.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("transit %s: %w", operation, err)
+	}
+	defer httpResp.Body.Close()
+
+	data, err := ioutil.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("transit %s: read response: %w", operation, err)
+	}
+
+	resp := &transitResponse{}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, resp); err != nil && httpResp.StatusCode == http.StatusOK {
+			return nil, fmt.Errorf("transit %s: decode response: %w", operation, err)
+		}
+	}
+
+	if httpResp.StatusCode != http.StatusOK {
+		if len(resp.Errors) > 0 {
+			return nil, fmt.Errorf("transit %s: %s (status %d)", operation, strings.Join(resp.Errors, ", "), httpResp.StatusCode)
+		}
+		return nil, fmt.Errorf("transit %s: unexpected status %d", operation, httpResp.StatusCode)
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,223 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vault
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testVaultToken = "s.slnc-test-token"
+
+// transitStandIn answers the Transit `encrypt` and `decrypt` calls of the
+// key `mount`/`name`, wrapping a DEK by prefixing it with the key name.
+type transitStandIn struct {
+	t     *testing.T
+	mount string
+	name  string
+
+	lock      sync.Mutex
+	paths     []string
+	namespace string
+	// status and body, when set, are answered to every call
+	status int
+	body   string
+}
+
+func newTransitStandIn(t *testing.T, mount, name string) (*transitStandIn, *httptest.Server) {
+	t.Setenv("VAULT_ADDR", "")
+	t.Setenv("VAULT_TOKEN", testVaultToken)
+	t.Setenv("VAULT_NAMESPACE", "")
+
+	standIn := &transitStandIn{t: t, mount: mount, name: name}
+	server := httptest.NewServer(standIn)
+	t.Cleanup(server.Close)
+
+	return standIn, server
+}
+
+func (s *transitStandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.lock.Lock()
+	s.paths = append(s.paths, r.URL.Path)
+	s.namespace = r.Header.Get("X-Vault-Namespace")
+	status, body := s.status, s.body
+	s.lock.Unlock()
+
+	assert.Equal(s.t, http.MethodPost, r.Method)
+	assert.Equal(s.t, "application/json", r.Header.Get("Content-Type"))
+
+	if status != 0 {
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+		return
+	}
+
+	if r.Header.Get("X-Vault-Token") != testVaultToken {
+		s.reply(w, http.StatusForbidden, map[string]interface{}{"errors": []string{"permission denied"}})
+		return
+	}
+
+	var in struct {
+		Plaintext  string `json:"plaintext"`
+		Ciphertext string `json:"ciphertext"`
+	}
+	if !assert.NoError(s.t, json.NewDecoder(r.Body).Decode(&in)) {
+		s.reply(w, http.StatusBadRequest, map[string]interface{}{"errors": []string{"invalid request"}})
+		return
+	}
+
+	prefix := "vault:v1:" + s.name + ":"
+	switch r.URL.Path {
+	case "/v1/" + s.mount + "/encrypt/" + s.name:
+		s.reply(w, http.StatusOK, map[string]interface{}{"data": map[string]string{"ciphertext": prefix + in.Plaintext}})
+	case "/v1/" + s.mount + "/decrypt/" + s.name:
+		if !strings.HasPrefix(in.Ciphertext, prefix) {
+			s.reply(w, http.StatusBadRequest, map[string]interface{}{"errors": []string{"cipher: message authentication failed"}})
+			return
+		}
+		s.reply(w, http.StatusOK, map[string]interface{}{"data": map[string]string{"plaintext": strings.TrimPrefix(in.Ciphertext, prefix)}})
+	default:
+		s.reply(w, http.StatusNotFound, map[string]interface{}{"errors": []string{}})
+	}
+}
+
+func (s *transitStandIn) fail(status int, body string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.status, s.body = status, body
+}
+
+func (s *transitStandIn) reply(w http.ResponseWriter, status int, out interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	assert.NoError(s.t, json.NewEncoder(w).Encode(out))
+}
+
+func TestHashicorpTransitBoxer_SealOpen(t *testing.T) {
+	tests := []struct {
+		key   string
+		mount string
+		name  string
+	}{
+		{"slnc", "transit", "slnc"},
+		{"/slnc/", "transit", "slnc"},
+		{"secrets/team/slnc", "secrets/team", "slnc"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.key, func(t *testing.T) {
+			standIn, server := newTransitStandIn(t, test.mount, test.name)
+			boxer := NewHashicorpTransitBoxer(test.key, server.URL+"/")
+			assert.Equal(t, "hashicorp-transit", boxer.WrapType())
+
+			sealed, err := boxer.Seal([]byte("vault content"))
+			require.NoError(t, err)
+			assert.NotContains(t, sealed, "vault content")
+
+			opened, err := boxer.Open(sealed)
+			require.NoError(t, err)
+			assert.Equal(t, []byte("vault content"), opened)
+
+			assert.Equal(t, []string{
+				"/v1/" + test.mount + "/encrypt/" + test.name,
+				"/v1/" + test.mount + "/decrypt/" + test.name,
+			}, standIn.paths)
+		})
+	}
+}
+
+func TestHashicorpTransitBoxer_Environment(t *testing.T) {
+	standIn, server := newTransitStandIn(t, "transit", "slnc")
+	t.Setenv("VAULT_ADDR", server.URL)
+	t.Setenv("VAULT_NAMESPACE", "team")
+
+	sealed, err := NewHashicorpTransitBoxer("slnc", "").Seal([]byte("vault content"))
+	require.NoError(t, err)
+	assert.Equal(t, "team", standIn.namespace)
+
+	t.Setenv("VAULT_TOKEN", "s.other")
+	_, err = NewHashicorpTransitBoxer("slnc", "").Open(sealed)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "transit decrypt: permission denied (status 403)")
+}
+
+func TestNewHashicorpTransitManager_Errors(t *testing.T) {
+	t.Setenv("VAULT_ADDR", "")
+	t.Setenv("VAULT_TOKEN", testVaultToken)
+
+	_, err := NewHashicorpTransitManager("slnc", "")
+	assert.EqualError(t, err, "no HashiCorp Vault address, set it through the kms endpoint or VAULT_ADDR")
+
+	_, err = NewHashicorpTransitManager("/", "http://127.0.0.1:8200")
+	assert.EqualError(t, err, `invalid transit key "/"`)
+
+	t.Setenv("VAULT_TOKEN", "")
+	_, err = NewHashicorpTransitManager("slnc", "http://127.0.0.1:8200")
+	assert.EqualError(t, err, "no HashiCorp Vault token, set it through VAULT_TOKEN")
+}
+
+func TestHashicorpTransitManager_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		wrap   bool
+		expect string
+	}{
+		{"errors listed", http.StatusBadRequest, `{"errors":["encryption key not found"]}`, true, "transit encrypt: encryption key not found (status 400)"},
+		{"no errors listed", http.StatusInternalServerError, ``, true, "transit encrypt: unexpected status 500"},
+		{"html error page", http.StatusBadGateway, `<html>bad gateway</html>`, false, "transit decrypt: unexpected status 502"},
+		{"invalid response", http.StatusOK, `{"data":`, false, "transit decrypt: decode response: unexpected end of JSON input"},
+		{"no ciphertext", http.StatusOK, `{"data":{}}`, true, "transit encrypt returned no ciphertext"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			standIn, server := newTransitStandIn(t, "transit", "slnc")
+			manager, err := NewHashicorpTransitManager("slnc", server.URL)
+			require.NoError(t, err)
+
+			wrapped, err := manager.WrapDEK(make([]byte, 32))
+			require.NoError(t, err)
+
+			standIn.fail(test.status, test.body)
+			if test.wrap {
+				_, err = manager.WrapDEK(make([]byte, 32))
+			} else {
+				_, err = manager.UnwrapDEK(wrapped)
+			}
+			assert.EqualError(t, err, test.expect)
+		})
+	}
+}
+
+func TestHashicorpTransitBoxer_WrongKey(t *testing.T) {
+	_, server := newTransitStandIn(t, "transit", "slnc")
+
+	dek := base64.StdEncoding.EncodeToString(make([]byte, 32))
+	manager, err := NewHashicorpTransitManager("slnc", server.URL)
+	require.NoError(t, err)
+
+	_, err = manager.UnwrapDEK("vault:v1:other:" + dek)
+	assert.EqualError(t, err, "transit decrypt: cipher: message authentication failed (status 400)")
+}
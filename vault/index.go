@@ -0,0 +1,173 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vault
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/streamingfast/solana-go"
+)
+
+// PublicKeyIndexEntry is an entry of the plaintext public key index of the
+// vault, it lets the keys held by a vault be listed without opening it.
+//
+// Each entry is signed by its own private key over the digest of the whole
+// index, see `publicKeyIndexDigest`: the entries, their labels, the
+// watch-only addresses and the ciphertext of the vault. An entry can't be
+// added, removed, relabeled or copied to another vault file, nor a
+// watch-only address altered, without holding every listed key. It proves
+// the vault writer held the listed keys, not that the vault holds them: a
+// vault file rewritten with an index of other keys is only detected once
+// the vault is opened, see `CheckPublicKeyIndex`.
+type PublicKeyIndexEntry struct {
+	PublicKey solana.PublicKey `json:"public_key"`
+	Label     string           `json:"label,omitempty"`
+	// Signature is base58 encoded, `solana.Signature` can't be decoded
+	// from JSON.
+	Signature string `json:"signature"`
+}
+
+// publicKeyIndexVersion is the first vault version whose index is signed
+// over `publicKeyIndexDigest`, the index of older vaults is ignored until
+// they're upgraded.
+const publicKeyIndexVersion = 4
+
+// publicKeyIndexDigest hashes everything the index vouches for, in order.
+func (v *Vault) publicKeyIndexDigest() []byte {
+	h := sha256.New()
+	fmt.Fprintf(h, "keys:%d\n", len(v.PublicKeys))
+	for _, entry := range v.PublicKeys {
+		fmt.Fprintf(h, "%s:%q\n", entry.PublicKey, entry.Label)
+	}
+
+	fmt.Fprintf(h, "watch_only:%d\n", len(v.WatchOnly))
+	for _, address := range v.WatchOnly {
+		fmt.Fprintf(h, "%s:%q:%q:%s\n", address.PublicKey, address.Label, address.Notes, address.CreatedAt.UTC().Format(time.RFC3339Nano))
+	}
+
+	fmt.Fprintf(h, "ciphertext:%s:", v.SecretBoxWrap)
+	io.WriteString(h, v.SecretBoxCiphertext)
+	return h.Sum(nil)
+}
+
+func publicKeyIndexMessage(digest []byte) []byte {
+	return []byte(fmt.Sprintf("solana-vault-public-key-index:v4:%x", digest))
+}
+
+func (e *PublicKeyIndexEntry) verify(message []byte) error {
+	signature, err := solana.SignatureFromBase58(e.Signature)
+	if err != nil {
+		return fmt.Errorf("invalid signature for index entry of key %s: %w", e.PublicKey, err)
+	}
+
+	if !signature.Verify(e.PublicKey, message) {
+		return fmt.Errorf("invalid signature for index entry of key %s", e.PublicKey)
+	}
+	return nil
+}
+
+// buildPublicKeyIndex signs an index entry for each key of the `KeyBag`,
+// once the vault is sealed as the index covers its ciphertext.
+func (v *Vault) buildPublicKeyIndex() error {
+	index := make([]*PublicKeyIndexEntry, len(v.KeyBag))
+	for i, key := range v.KeyBag {
+		entry := &PublicKeyIndexEntry{PublicKey: key.PublicKey()}
+		if metadata := v.Metadata[entry.PublicKey.String()]; metadata != nil {
+			entry.Label = metadata.Label
+		}
+		index[i] = entry
+	}
+	v.PublicKeys = index
+
+	message := publicKeyIndexMessage(v.publicKeyIndexDigest())
+	for i, key := range v.KeyBag {
+		signature, err := key.Sign(message)
+		if err != nil {
+			return fmt.Errorf("sign index entry of key %s: %w", index[i].PublicKey, err)
+		}
+		index[i].Signature = signature.String()
+	}
+
+	return nil
+}
+
+// HasPublicKeyIndex returns whether the vault file carries a public key
+// index, vaults written before version 4 of the format don't.
+func (v *Vault) HasPublicKeyIndex() bool {
+	return v.PublicKeys != nil && v.Version >= publicKeyIndexVersion
+}
+
+// VerifyPublicKeyIndex checks the signature of each entry of the public
+// key index, it does not require the vault to be opened. An empty index
+// signs nothing, it fails as soon as there's something to vouch for.
+func (v *Vault) VerifyPublicKeyIndex() error {
+	if !v.HasPublicKeyIndex() {
+		return fmt.Errorf("vault has no public key index")
+	}
+
+	if len(v.PublicKeys) == 0 && (len(v.WatchOnly) != 0 || v.SecretBoxCiphertext != "") {
+		return fmt.Errorf("public key index is empty, no key vouches for the watch-only addresses and the vault ciphertext")
+	}
+
+	message := publicKeyIndexMessage(v.publicKeyIndexDigest())
+	for _, entry := range v.PublicKeys {
+		if err := entry.verify(message); err != nil {
+			return fmt.Errorf("%w, the index, the watch-only addresses or the vault ciphertext were altered", err)
+		}
+	}
+	return nil
+}
+
+// CheckPublicKeyIndex checks, once the vault is opened, that the public key
+// index lists exactly the keys of the `KeyBag` with their labels. Opening
+// the vault authenticated its ciphertext, so a vault without an index, one
+// written before version 4, or without keys passes as long as it has no
+// watch-only address left unsigned.
+func (v *Vault) CheckPublicKeyIndex() error {
+	if !v.HasPublicKeyIndex() || (len(v.PublicKeys) == 0 && len(v.KeyBag) == 0) {
+		if len(v.WatchOnly) != 0 {
+			return fmt.Errorf("no key of the vault vouches for its %d watch-only addresses", len(v.WatchOnly))
+		}
+		return nil
+	}
+
+	if err := v.VerifyPublicKeyIndex(); err != nil {
+		return err
+	}
+
+	if len(v.PublicKeys) != len(v.KeyBag) {
+		return fmt.Errorf("public key index lists %d keys but the vault holds %d keys", len(v.PublicKeys), len(v.KeyBag))
+	}
+
+	for i, key := range v.KeyBag {
+		entry := v.PublicKeys[i]
+		if !entry.PublicKey.Equals(key.PublicKey()) {
+			return fmt.Errorf("public key index entry #%d is %s but the vault holds %s", i+1, entry.PublicKey, key.PublicKey())
+		}
+
+		label := ""
+		if metadata := v.Metadata[entry.PublicKey.String()]; metadata != nil {
+			label = metadata.Label
+		}
+		if entry.Label != label {
+			return fmt.Errorf("public key index labels key %s %q but the vault labels it %q", entry.PublicKey, entry.Label, label)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,111 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vault
+
+import (
+	"testing"
+
+	"github.com/streamingfast/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestPublicKey(t *testing.T) solana.PublicKey {
+	pub, _, err := solana.NewRandomPrivateKey()
+	require.NoError(t, err)
+	return pub
+}
+
+func newTestSealedVault(t *testing.T, keys int, watched ...solana.PublicKey) *Vault {
+	v := NewVault()
+	for i := 0; i < keys; i++ {
+		_, priv, err := solana.NewRandomPrivateKey()
+		require.NoError(t, err)
+		v.AddPrivateKey(priv)
+	}
+	for _, pub := range watched {
+		_, err := v.AddWatchOnly(pub, "")
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, v.Seal(NewPassphraseBoxer("secret")))
+	return v
+}
+
+func TestVerifyPublicKeyIndex(t *testing.T) {
+	v := newTestSealedVault(t, 2, newTestPublicKey(t))
+	require.True(t, v.HasPublicKeyIndex())
+	require.NoError(t, v.VerifyPublicKeyIndex())
+	require.NoError(t, v.CheckPublicKeyIndex())
+
+	v.WatchOnly[0].Label = "cold-storage"
+	assert.Error(t, v.VerifyPublicKeyIndex(), "relabeled watch-only address")
+	v.WatchOnly[0].Label = ""
+
+	v.WatchOnly = append(v.WatchOnly, &WatchOnlyAddress{PublicKey: newTestPublicKey(t)})
+	assert.Error(t, v.VerifyPublicKeyIndex(), "added watch-only address")
+	v.WatchOnly = v.WatchOnly[:1]
+
+	v.PublicKeys[0].Label = "payroll"
+	assert.Error(t, v.VerifyPublicKeyIndex(), "relabeled key")
+	v.PublicKeys[0].Label = ""
+
+	other := newTestSealedVault(t, 2)
+	ciphertext := v.SecretBoxCiphertext
+	v.SecretBoxCiphertext = other.SecretBoxCiphertext
+	assert.Error(t, v.VerifyPublicKeyIndex(), "swapped ciphertext")
+	v.SecretBoxCiphertext = ciphertext
+
+	v.PublicKeys = v.PublicKeys[:1]
+	assert.Error(t, v.VerifyPublicKeyIndex(), "removed key")
+}
+
+func TestVerifyPublicKeyIndex_Empty(t *testing.T) {
+	v := newTestSealedVault(t, 0)
+	assert.EqualError(t, v.VerifyPublicKeyIndex(), "public key index is empty, no key vouches for the watch-only addresses and the vault ciphertext")
+
+	v = newTestSealedVault(t, 1, newTestPublicKey(t))
+	v.PublicKeys = []*PublicKeyIndexEntry{}
+	assert.Error(t, v.VerifyPublicKeyIndex(), "stripped index")
+}
+
+func TestVerifyPublicKeyIndex_OlderVersion(t *testing.T) {
+	v := newTestSealedVault(t, 1)
+	v.Version = publicKeyIndexVersion - 1
+
+	assert.False(t, v.HasPublicKeyIndex())
+	assert.True(t, v.NeedsUpgrade())
+	assert.EqualError(t, v.VerifyPublicKeyIndex(), "vault has no public key index")
+}
+
+func TestCheckPublicKeyIndex_WatchOnly(t *testing.T) {
+	v := newTestSealedVault(t, 0)
+	require.NoError(t, v.CheckPublicKeyIndex(), "empty vault")
+
+	_, err := v.AddWatchOnly(newTestPublicKey(t), "")
+	assert.Error(t, err, "no key to vouch for it")
+
+	v = newTestSealedVault(t, 1, newTestPublicKey(t))
+	require.NoError(t, v.CheckPublicKeyIndex())
+
+	v.WatchOnly[0].PublicKey = newTestPublicKey(t)
+	assert.Error(t, v.CheckPublicKeyIndex(), "swapped watch-only address")
+
+	v.Version = publicKeyIndexVersion - 1
+	assert.EqualError(t, v.CheckPublicKeyIndex(), "no key of the vault vouches for its 1 watch-only addresses")
+
+	v.WatchOnly = nil
+	assert.NoError(t, v.CheckPublicKeyIndex(), "vault written before the index")
+}
@@ -0,0 +1,129 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vault
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/kms"
+)
+
+type KMSAWSBoxer struct {
+	keyID    string
+	endpoint string
+}
+
+// NewKMSAWSBoxer returns a boxer wrapping its data encryption keys with the
+// AWS KMS key `keyID` (an ARN, an alias or a key id). Credentials and region
+// come from the usual AWS environment, a non-empty `endpoint` overrides the
+// KMS one.
+func NewKMSAWSBoxer(keyID, endpoint string) *KMSAWSBoxer {
+	return &KMSAWSBoxer{
+		keyID:    keyID,
+		endpoint: endpoint,
+	}
+}
+
+func (b *KMSAWSBoxer) Seal(in []byte) (string, error) {
+	mgr, err := NewKMSAWSManager(b.keyID, b.endpoint)
+	if err != nil {
+		return "", fmt.Errorf("new kms aws manager, %s", err)
+	}
+
+	return sealWithManager(mgr, in)
+}
+
+func (b *KMSAWSBoxer) Open(in string) ([]byte, error) {
+	mgr, err := NewKMSAWSManager(b.keyID, b.endpoint)
+	if err != nil {
+		return []byte{}, fmt.Errorf("new kms aws manager, %s", err)
+	}
+
+	return openWithManager(mgr, in)
+}
+
+func (b *KMSAWSBoxer) WrapType() string {
+	return "kms-aws"
+}
+
+// KMSAWSManager wraps the data encryption keys of an `EnvelopeManager`
+// with an AWS KMS key.
+type KMSAWSManager struct {
+	*EnvelopeManager
+
+	service *kms.KMS
+	keyID   string
+}
+
+func NewKMSAWSManager(keyID, endpoint string) (*KMSAWSManager, error) {
+	config := aws.Config{}
+	if endpoint != "" {
+		config.Endpoint = aws.String(endpoint)
+	}
+
+	// An ARN carries the region of the key, `arn:aws:kms:{region}:{account}:key/{id}`
+	if parts := strings.Split(keyID, ":"); len(parts) >= 6 && parts[0] == "arn" {
+		config.Region = aws.String(parts[3])
+	}
+
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:            config,
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	manager := &KMSAWSManager{
+		service: kms.New(sess),
+		keyID:   keyID,
+	}
+	manager.EnvelopeManager = NewEnvelopeManager(manager)
+
+	return manager, nil
+}
+
+func (k *KMSAWSManager) WrapDEK(plainDEK []byte) (string, error) {
+	resp, err := k.service.Encrypt(&kms.EncryptInput{
+		KeyId:     aws.String(k.keyID),
+		Plaintext: plainDEK,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(resp.CiphertextBlob), nil
+}
+
+func (k *KMSAWSManager) UnwrapDEK(wrappedDEK string) ([]byte, error) {
+	ciphertext, err := base64.StdEncoding.DecodeString(wrappedDEK)
+	if err != nil {
+		return nil, fmt.Errorf("base 64 decode, %s", err)
+	}
+
+	resp, err := k.service.Decrypt(&kms.DecryptInput{
+		KeyId:          aws.String(k.keyID),
+		CiphertextBlob: ciphertext,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Plaintext, nil
+}
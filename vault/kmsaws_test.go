@@ -0,0 +1,263 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vault
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testAWSAccessKeyID     = "AKIDSLNCTEST"
+	testAWSSecretAccessKey = "slnc-test-secret"
+)
+
+// kmsAWSStandIn answers the KMS `Encrypt` and `Decrypt` calls, wrapping a
+// DEK by prefixing it with the key id, after checking the request signature.
+type kmsAWSStandIn struct {
+	t *testing.T
+
+	lock    sync.Mutex
+	targets []string
+	regions []string
+	// failure, when set, is the KMS exception answered to every call
+	failure string
+}
+
+func newKMSAWSStandIn(t *testing.T) (*kmsAWSStandIn, *httptest.Server) {
+	t.Setenv("AWS_ACCESS_KEY_ID", testAWSAccessKeyID)
+	t.Setenv("AWS_SECRET_ACCESS_KEY", testAWSSecretAccessKey)
+	t.Setenv("AWS_SESSION_TOKEN", "")
+	t.Setenv("AWS_REGION", "us-east-1")
+	t.Setenv("AWS_PROFILE", "")
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(t.TempDir(), "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(t.TempDir(), "credentials"))
+
+	standIn := &kmsAWSStandIn{t: t}
+	server := httptest.NewServer(standIn)
+	t.Cleanup(server.Close)
+
+	return standIn, server
+}
+
+func (s *kmsAWSStandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if !assert.NoError(s.t, err) {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	target := r.Header.Get("X-Amz-Target")
+	region := s.checkSignature(r, body)
+
+	s.lock.Lock()
+	s.targets = append(s.targets, target)
+	s.regions = append(s.regions, region)
+	failure := s.failure
+	s.lock.Unlock()
+
+	assert.Equal(s.t, http.MethodPost, r.Method)
+	assert.Equal(s.t, "application/x-amz-json-1.1", r.Header.Get("Content-Type"))
+
+	if failure != "" {
+		s.reply(w, http.StatusBadRequest, map[string]string{"__type": failure, "message": "stand-in " + failure})
+		return
+	}
+
+	var in struct {
+		KeyId          string
+		Plaintext      []byte
+		CiphertextBlob []byte
+	}
+	if !assert.NoError(s.t, json.Unmarshal(body, &in)) {
+		s.reply(w, http.StatusBadRequest, map[string]string{"__type": "ValidationException"})
+		return
+	}
+
+	prefix := []byte(in.KeyId + ":")
+	switch target {
+	case "TrentService.Encrypt":
+		s.reply(w, http.StatusOK, map[string]interface{}{"KeyId": in.KeyId, "CiphertextBlob": append(prefix, in.Plaintext...)})
+	case "TrentService.Decrypt":
+		if !bytes.HasPrefix(in.CiphertextBlob, prefix) {
+			s.reply(w, http.StatusBadRequest, map[string]string{"__type": "InvalidCiphertextException", "message": "not wrapped by this key"})
+			return
+		}
+		s.reply(w, http.StatusOK, map[string]interface{}{"KeyId": in.KeyId, "Plaintext": in.CiphertextBlob[len(prefix):]})
+	default:
+		s.t.Errorf("unexpected KMS operation %q", target)
+		s.reply(w, http.StatusBadRequest, map[string]string{"__type": "UnknownOperationException"})
+	}
+}
+
+// checkSignature signs the request again with the test credentials, the
+// signature must match the one received. It returns the region of the
+// credential scope.
+func (s *kmsAWSStandIn) checkSignature(r *http.Request, body []byte) string {
+	authorization := r.Header.Get("Authorization")
+	if !assert.True(s.t, strings.HasPrefix(authorization, "AWS4-HMAC-SHA256 Credential="+testAWSAccessKeyID+"/"), "authorization %q", authorization) {
+		return ""
+	}
+
+	// Credential={id}/{date}/{region}/{service}/aws4_request, SignedHeaders=...
+	fields := strings.Split(strings.TrimPrefix(authorization, "AWS4-HMAC-SHA256 "), ", ")
+	if !assert.Len(s.t, fields, 3) {
+		return ""
+	}
+	scope := strings.Split(strings.TrimPrefix(fields[0], "Credential="), "/")
+	if !assert.Len(s.t, scope, 5) {
+		return ""
+	}
+	assert.Equal(s.t, "kms", scope[3])
+
+	signTime, err := time.Parse("20060102T150405Z", r.Header.Get("X-Amz-Date"))
+	if !assert.NoError(s.t, err) {
+		return scope[2]
+	}
+
+	resigned, err := http.NewRequest(r.Method, "http://"+r.Host+r.URL.RequestURI(), nil)
+	if !assert.NoError(s.t, err) {
+		return scope[2]
+	}
+	for _, name := range strings.Split(strings.TrimPrefix(fields[1], "SignedHeaders="), ";") {
+		if name != "host" {
+			resigned.Header[http.CanonicalHeaderKey(name)] = r.Header.Values(name)
+		}
+	}
+
+	signer := v4.NewSigner(credentials.NewStaticCredentials(testAWSAccessKeyID, testAWSSecretAccessKey, ""))
+	_, err = signer.Sign(resigned, bytes.NewReader(body), scope[3], scope[2], signTime)
+	assert.NoError(s.t, err)
+	assert.Equal(s.t, resigned.Header.Get("Authorization"), authorization)
+
+	return scope[2]
+}
+
+func (s *kmsAWSStandIn) fail(exception string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.failure = exception
+}
+
+func (s *kmsAWSStandIn) reply(w http.ResponseWriter, status int, out interface{}) {
+	w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+	w.WriteHeader(status)
+	assert.NoError(s.t, json.NewEncoder(w).Encode(out))
+}
+
+func TestKMSAWSBoxer_SealOpen(t *testing.T) {
+	standIn, server := newKMSAWSStandIn(t)
+	boxer := NewKMSAWSBoxer("alias/slnc", server.URL)
+	assert.Equal(t, "kms-aws", boxer.WrapType())
+
+	sealed, err := boxer.Seal([]byte("vault content"))
+	require.NoError(t, err)
+	assert.NotContains(t, sealed, "vault content")
+
+	opened, err := boxer.Open(sealed)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("vault content"), opened)
+
+	assert.Equal(t, []string{"TrentService.Encrypt", "TrentService.Decrypt"}, standIn.targets)
+	assert.Equal(t, []string{"us-east-1", "us-east-1"}, standIn.regions)
+}
+
+func TestKMSAWSBoxer_RegionFromARN(t *testing.T) {
+	standIn, server := newKMSAWSStandIn(t)
+	boxer := NewKMSAWSBoxer("arn:aws:kms:eu-west-3:111122223333:key/1234abcd-12ab-34cd-56ef-1234567890ab", server.URL)
+
+	sealed, err := boxer.Seal([]byte("vault content"))
+	require.NoError(t, err)
+
+	_, err = NewKMSAWSBoxer("alias/other", server.URL).Open(sealed)
+	assert.Error(t, err, "a DEK wrapped by another key must not unwrap")
+
+	opened, err := boxer.Open(sealed)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("vault content"), opened)
+
+	assert.Equal(t, []string{"eu-west-3", "us-east-1", "eu-west-3"}, standIn.regions)
+}
+
+func TestKMSAWSManager_Errors(t *testing.T) {
+	tests := []struct {
+		failure string
+		wrap    bool
+	}{
+		{"NotFoundException", true},
+		{"DisabledException", true},
+		{"AccessDeniedException", false},
+		{"InvalidCiphertextException", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.failure, func(t *testing.T) {
+			standIn, server := newKMSAWSStandIn(t)
+			manager, err := NewKMSAWSManager("alias/slnc", server.URL)
+			require.NoError(t, err)
+
+			wrapped, err := manager.WrapDEK(make([]byte, 32))
+			require.NoError(t, err)
+
+			standIn.fail(test.failure)
+			if test.wrap {
+				_, err = manager.WrapDEK(make([]byte, 32))
+			} else {
+				_, err = manager.UnwrapDEK(wrapped)
+			}
+
+			var awsErr awserr.Error
+			require.True(t, errors.As(err, &awsErr), "error %v", err)
+			assert.Equal(t, test.failure, awsErr.Code())
+			assert.Equal(t, "stand-in "+test.failure, awsErr.Message())
+		})
+	}
+}
+
+func TestKMSAWSBoxer_Errors(t *testing.T) {
+	standIn, server := newKMSAWSStandIn(t)
+	boxer := NewKMSAWSBoxer("alias/slnc", server.URL)
+
+	sealed, err := boxer.Seal([]byte("vault content"))
+	require.NoError(t, err)
+
+	standIn.fail("AccessDeniedException")
+	_, err = boxer.Open(sealed)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "kms decryption")
+	assert.Contains(t, err.Error(), "AccessDeniedException")
+
+	_, err = boxer.Seal([]byte("vault content"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "kms encryption")
+
+	_, err = boxer.Open("not base64!")
+	assert.Error(t, err)
+}
@@ -16,15 +16,10 @@ package vault
 
 import (
 	"context"
-	"crypto/rand"
 	"encoding/base64"
-	"encoding/json"
 	"fmt"
-	"io"
-	"sync"
 
 	"golang.org/x/crypto/argon2"
-	"golang.org/x/crypto/nacl/secretbox"
 	"golang.org/x/oauth2/google"
 	"google.golang.org/api/cloudkms/v1"
 )
@@ -49,13 +44,7 @@ func (b *KMSGCPBoxer) Seal(in []byte) (string, error) {
 		return "", fmt.Errorf("new kms gcp manager, %s", err)
 	}
 
-	encrypted, err := mgr.Encrypt(in)
-	if err != nil {
-		return "", fmt.Errorf("kms encryption, %s", err)
-	}
-
-	return base64.RawStdEncoding.EncodeToString(encrypted), nil
-
+	return sealWithManager(mgr, in)
 }
 
 func (b *KMSGCPBoxer) Open(in string) ([]byte, error) {
@@ -63,15 +52,8 @@ func (b *KMSGCPBoxer) Open(in string) ([]byte, error) {
 	if err != nil {
 		return []byte{}, fmt.Errorf("new kms gcp manager, %s", err)
 	}
-	data, err := base64.RawStdEncoding.DecodeString(in)
-	if err != nil {
-		return []byte{}, fmt.Errorf("base 64 decode, %s", err)
-	}
-	out, err := mgr.Decrypt(data)
-	if err != nil {
-		return []byte{}, fmt.Errorf("base 64 decode, %s", err)
-	}
-	return out, nil
+
+	return openWithManager(mgr, in)
 }
 
 func (b *KMSGCPBoxer) WrapType() string {
@@ -112,132 +94,41 @@ func NewKMSGCPManager(keyPath string) (*KMSGCPManager, error) {
 		service: kmsService,
 		keyPath: keyPath,
 	}
+	manager.EnvelopeManager = NewEnvelopeManager(manager)
 
 	return manager, nil
 }
 
+// KMSGCPManager wraps the data encryption keys of an `EnvelopeManager`
+// with a Google Cloud KMS key.
 type KMSGCPManager struct {
-	dekCache        map[string][32]byte
-	dekCacheLock    sync.Mutex
-	localDEK        [32]byte
-	localWrappedDEK string
-	service         *cloudkms.Service
-	keyPath         string
-}
+	*EnvelopeManager
 
-func (k *KMSGCPManager) setupEncryption() error {
-	if k.dekCache != nil {
-		return nil
-	}
-
-	_, err := rand.Read(k.localDEK[:])
-	if err != nil {
-		return err
-	}
+	service *cloudkms.Service
+	keyPath string
+}
 
+func (k *KMSGCPManager) WrapDEK(plainDEK []byte) (string, error) {
 	req := &cloudkms.EncryptRequest{
-		Plaintext: base64.StdEncoding.EncodeToString(k.localDEK[:]),
+		Plaintext: base64.StdEncoding.EncodeToString(plainDEK),
 	}
 
 	resp, err := k.service.Projects.Locations.KeyRings.CryptoKeys.Encrypt(k.keyPath, req).Do()
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	k.localWrappedDEK = resp.Ciphertext
-	k.dekCache = map[string][32]byte{resp.Ciphertext: k.localDEK}
-
-	return nil
+	return resp.Ciphertext, nil
 }
 
-func (k *KMSGCPManager) fetchPlainDEK(wrappedDEK string) (out [32]byte, err error) {
-	k.dekCacheLock.Lock()
-	defer k.dekCacheLock.Unlock()
-
-	if cachedKey, found := k.dekCache[wrappedDEK]; found {
-		return cachedKey, nil
-	}
-
+func (k *KMSGCPManager) UnwrapDEK(wrappedDEK string) ([]byte, error) {
 	req := &cloudkms.DecryptRequest{
 		Ciphertext: wrappedDEK,
 	}
 	resp, err := k.service.Projects.Locations.KeyRings.CryptoKeys.Decrypt(k.keyPath, req).Do()
-	if err != nil {
-		return
-	}
-
-	plainKey, err := base64.StdEncoding.DecodeString(resp.Plaintext)
-	if err != nil {
-		return
-	}
-
-	copy(out[:], plainKey)
-
-	if k.dekCache == nil {
-		k.dekCache = map[string][32]byte{}
-	}
-	if k.localWrappedDEK == "" {
-		k.localWrappedDEK = wrappedDEK
-	}
-	k.dekCache[wrappedDEK] = out
-
-	return
-}
-
-type BlobV1 struct {
-	Version       int      `bson:"version"`
-	WrappedDEK    string   `bson:"wrapped_dek"`
-	Nonce         [24]byte `bson:"nonce"`
-	EncryptedData []byte   `bson:"data"`
-}
-
-func (k *KMSGCPManager) Encrypt(in []byte) ([]byte, error) {
-	if err := k.setupEncryption(); err != nil {
-		return nil, err
-	}
-
-	var nonce [24]byte
-	if _, err := io.ReadFull(rand.Reader, nonce[:]); err != nil {
-		return nil, err
-	}
-
-	var sealedMsg []byte
-	sealedMsg = secretbox.Seal(sealedMsg, in, &nonce, &k.localDEK)
-
-	blob := &BlobV1{
-		Version:       1,
-		WrappedDEK:    k.localWrappedDEK,
-		Nonce:         nonce,
-		EncryptedData: sealedMsg,
-	}
-
-	cereal, err := json.Marshal(blob)
-	if err != nil {
-		return nil, err
-	}
-
-	return cereal, nil
-}
-
-func (k *KMSGCPManager) Decrypt(in []byte) ([]byte, error) {
-	var blob BlobV1
-	err := json.Unmarshal(in, &blob)
 	if err != nil {
 		return nil, err
 	}
 
-	// No need to check `blob.Version` == 1, we did it already with
-	// the `magicFound` comparison.
-
-	plainDEK, err := k.fetchPlainDEK(blob.WrappedDEK)
-	if err != nil {
-		return nil, err
-	}
-
-	plainData, ok := secretbox.Open(nil, blob.EncryptedData, &blob.Nonce, &plainDEK)
-	if !ok {
-		return nil, fmt.Errorf("failed decrypting data, that's all we know")
-	}
-
-	return plainData, nil
+	return base64.StdEncoding.DecodeString(resp.Plaintext)
 }
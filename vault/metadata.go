@@ -0,0 +1,129 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vault
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/streamingfast/slnc/policy"
+	"github.com/streamingfast/solana-go"
+)
+
+// KeyMetadata describes a key of the vault, the creation time is unknown
+// for keys migrated from a version 1 vault.
+type KeyMetadata struct {
+	Label     string    `json:"label,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	Tags      []string  `json:"tags,omitempty"`
+	Notes     string    `json:"notes,omitempty"`
+
+	// DerivationPath is the path of the key derived from the vault seed,
+	// empty for random and imported keys.
+	DerivationPath string `json:"derivation_path,omitempty"`
+
+	// Policy restricts the transactions the key signs, sealed with it so
+	// loosening it requires opening the vault.
+	Policy *policy.KeyPolicy `json:"policy,omitempty"`
+}
+
+// keyEntry is a key of the sealed payload of a version 2 vault.
+type keyEntry struct {
+	PrivateKey solana.PrivateKey `json:"private_key"`
+	KeyMetadata
+}
+
+var labelRegexp = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// ValidateLabel checks that `label` can name a key, labels are referenced
+// as `@label` on the command line.
+func ValidateLabel(label string) error {
+	if !labelRegexp.MatchString(label) {
+		return fmt.Errorf("invalid label %q, a label starts with a letter or digit followed by letters, digits, '.', '_' or '-'", label)
+	}
+	return nil
+}
+
+// KeyMetadata returns the metadata of the key `pub`, nil when the vault
+// does not hold it.
+func (v *Vault) KeyMetadata(pub solana.PublicKey) *KeyMetadata {
+	if _, found := v.PrivateKey(pub); !found {
+		return nil
+	}
+
+	if v.Metadata == nil {
+		v.Metadata = map[string]*KeyMetadata{}
+	}
+
+	metadata := v.Metadata[pub.String()]
+	if metadata == nil {
+		metadata = &KeyMetadata{}
+		v.Metadata[pub.String()] = metadata
+	}
+
+	return metadata
+}
+
+// PrivateKey returns the private key of `pub` when the vault holds it.
+func (v *Vault) PrivateKey(pub solana.PublicKey) (solana.PrivateKey, bool) {
+	for _, key := range v.KeyBag {
+		if key.PublicKey().Equals(pub) {
+			return key, true
+		}
+	}
+	return nil, false
+}
+
+// FindByLabel returns the private key labeled `label`.
+func (v *Vault) FindByLabel(label string) (solana.PrivateKey, bool) {
+	for _, key := range v.KeyBag {
+		if metadata := v.Metadata[key.PublicKey().String()]; metadata != nil && metadata.Label == label {
+			return key, true
+		}
+	}
+	return nil, false
+}
+
+// SetLabel labels the key `pub`, an empty label removes the current one.
+// Labels are unique within the vault.
+func (v *Vault) SetLabel(pub solana.PublicKey, label string) error {
+	metadata := v.KeyMetadata(pub)
+	if metadata == nil {
+		return fmt.Errorf("key %s not found in vault", pub)
+	}
+
+	if label != "" {
+		if err := v.checkLabelAvailable(pub, label); err != nil {
+			return err
+		}
+	}
+
+	metadata.Label = label
+	return nil
+}
+
+// RemoveKey removes the key `pub` and its metadata from the vault, it
+// returns false when the vault does not hold it.
+func (v *Vault) RemoveKey(pub solana.PublicKey) bool {
+	for i, key := range v.KeyBag {
+		if key.PublicKey().Equals(pub) {
+			v.KeyBag = append(v.KeyBag[:i], v.KeyBag[i+1:]...)
+			delete(v.Metadata, pub.String())
+			return true
+		}
+	}
+	return false
+}
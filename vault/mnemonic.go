@@ -0,0 +1,159 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vault
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"github.com/streamingfast/solana-go"
+	"github.com/tyler-smith/go-bip39"
+)
+
+// NewMnemonic returns a new random BIP39 mnemonic of `words` words, either
+// 12 or 24.
+func NewMnemonic(words int) (string, error) {
+	var bitSize int
+	switch words {
+	case 12:
+		bitSize = 128
+	case 24:
+		bitSize = 256
+	default:
+		return "", fmt.Errorf("invalid mnemonic length %d, use 12 or 24 words", words)
+	}
+
+	entropy, err := bip39.NewEntropy(bitSize)
+	if err != nil {
+		return "", err
+	}
+
+	return bip39.NewMnemonic(entropy)
+}
+
+// NormalizeMnemonic collapses the whitespace and lowercases a mnemonic
+// typed by a user.
+func NormalizeMnemonic(mnemonic string) string {
+	return strings.ToLower(strings.Join(strings.Fields(mnemonic), " "))
+}
+
+// NewVaultFromMnemonic returns a new vault whose keys are derived from the
+// BIP39 `mnemonic` and optional `passphrase`, it holds no keys yet, see
+// `DeriveKey`.
+func NewVaultFromMnemonic(mnemonic, passphrase string) (*Vault, error) {
+	seed, err := bip39.NewSeedWithErrorChecking(NormalizeMnemonic(mnemonic), passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mnemonic: %w", err)
+	}
+
+	v := NewVault()
+	v.Seed = seed
+	return v, nil
+}
+
+// HasSeed returns whether the keys of the vault can be derived from a seed.
+func (v *Vault) HasSeed() bool {
+	return len(v.Seed) > 0
+}
+
+// DeriveKey derives the key at `index` of Solana's `m/44'/501'/index'/0'`
+// path from the vault seed and adds it to the vault.
+func (v *Vault) DeriveKey(index uint32) (solana.PublicKey, error) {
+	if !v.HasSeed() {
+		return solana.PublicKey{}, fmt.Errorf("vault has no seed, its keys were not derived from a mnemonic")
+	}
+
+	privateKey, err := DeriveSolanaKey(v.Seed, index)
+	if err != nil {
+		return solana.PublicKey{}, err
+	}
+
+	pub := privateKey.PublicKey()
+	if _, found := v.PrivateKey(pub); found {
+		return pub, fmt.Errorf("key %s at %s is already in the vault", pub, SolanaDerivationPath(index))
+	}
+
+	v.AddPrivateKey(privateKey)
+	v.KeyMetadata(pub).DerivationPath = SolanaDerivationPath(index)
+
+	return pub, nil
+}
+
+// NextDerivationIndex returns the index following the highest one of the
+// keys of the vault derived from its seed.
+func (v *Vault) NextDerivationIndex() uint32 {
+	var next uint32
+	for _, metadata := range v.Metadata {
+		var index uint32
+		if _, err := fmt.Sscanf(metadata.DerivationPath, "m/44'/501'/%d'/0'", &index); err == nil && index >= next {
+			next = index + 1
+		}
+	}
+	return next
+}
+
+// SolanaDerivationPath returns the BIP44 path of the Solana key `index`, the
+// one used by Phantom and `solana-keygen --derivation-path`.
+func SolanaDerivationPath(index uint32) string {
+	return fmt.Sprintf("m/44'/501'/%d'/0'", index)
+}
+
+// DeriveSolanaKey derives the Solana key `index` from a BIP39 `seed` along
+// the `m/44'/501'/index'/0'` path.
+func DeriveSolanaKey(seed []byte, index uint32) (solana.PrivateKey, error) {
+	if index >= hardenedOffset {
+		return nil, fmt.Errorf("invalid derivation index %d", index)
+	}
+
+	key, err := deriveSLIP10Ed25519(seed, []uint32{44, 501, index, 0})
+	if err != nil {
+		return nil, err
+	}
+
+	return solana.PrivateKey(ed25519.NewKeyFromSeed(key)), nil
+}
+
+const hardenedOffset = 0x80000000
+
+// deriveSLIP10Ed25519 derives the ed25519 private key seed at `path` per
+// SLIP-10, ed25519 only supports hardened derivation so every index of the
+// path is hardened.
+func deriveSLIP10Ed25519(seed []byte, path []uint32) ([]byte, error) {
+	if len(seed) < 16 || len(seed) > 64 {
+		return nil, fmt.Errorf("invalid seed length %d", len(seed))
+	}
+
+	mac := hmac.New(sha512.New, []byte("ed25519 seed"))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+	key, chainCode := sum[:32], sum[32:]
+
+	for _, index := range path {
+		data := make([]byte, 37)
+		copy(data[1:33], key)
+		binary.BigEndian.PutUint32(data[33:], index|hardenedOffset)
+
+		mac := hmac.New(sha512.New, chainCode)
+		mac.Write(data)
+		sum := mac.Sum(nil)
+		key, chainCode = sum[:32], sum[32:]
+	}
+
+	return key, nil
+}
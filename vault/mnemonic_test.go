@@ -0,0 +1,91 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vault
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustDecodeHex(t *testing.T, in string) []byte {
+	out, err := hex.DecodeString(in)
+	require.NoError(t, err)
+	return out
+}
+
+// The test vectors of SLIP-10 for ed25519, every index being hardened.
+func TestDeriveSLIP10Ed25519(t *testing.T) {
+	seed1 := "000102030405060708090a0b0c0d0e0f"
+	seed2 := "fffcf9f6f3f0edeae7e4e1dedbd8d5d2cfccc9c6c3c0bdbab7b4b1aeaba8a5a29f9c999693908d8a8784817e7b7875726f6c696663605d5a5754514e4b484542"
+
+	tests := []struct {
+		name     string
+		seed     string
+		path     []uint32
+		expected string
+	}{
+		{"vector 1 m", seed1, nil, "2b4be7f19ee27bbf30c667b642d5f4aa69fd169872f8fc3059c08ebae2eb19e7"},
+		{"vector 1 m/0'", seed1, []uint32{0}, "68e0fe46dfb67e368c75379acec591dad19df3cde26e63b93a8e704f1dade7a3"},
+		{"vector 1 m/0'/1'", seed1, []uint32{0, 1}, "b1d0bad404bf35da785a64ca1ac54b2617211d2777696fbffaf208f746ae84f2"},
+		{"vector 1 m/0'/1'/2'", seed1, []uint32{0, 1, 2}, "92a5b23c0b8a99e37d07df3fb9966917f5d06e02ddbd909c7e184371463e9fc9"},
+		{"vector 1 m/0'/1'/2'/2'", seed1, []uint32{0, 1, 2, 2}, "30d1dc7e5fc04c31219ab25a27ae00b50f6fd66622f6e9c913253d6511d1e662"},
+		{"vector 1 m/0'/1'/2'/2'/1000000000'", seed1, []uint32{0, 1, 2, 2, 1000000000}, "8f94d394a8e8fd6b1bc2f3f49f5c47e385281d5c17e65324b0f62483e37e8793"},
+
+		{"vector 2 m", seed2, nil, "171cb88b1b3c1db25add599712e36245d75bc65a1a5c9e18d76f9f2b1eab4012"},
+		{"vector 2 m/0'", seed2, []uint32{0}, "1559eb2bbec5790b0c65d8693e4d0875b1747f4970ae8b650486ed7470845635"},
+		{"vector 2 m/0'/2147483647'", seed2, []uint32{0, 2147483647}, "ea4f5bfe8694d8bb74b7b59404632fd5968b774ed545e810de9c32a4fb4192f4"},
+		{"vector 2 m/0'/2147483647'/1'", seed2, []uint32{0, 2147483647, 1}, "3757c7577170179c7868353ada796c839135b3d30554bbb74a4b1e4a5a58505c"},
+		{"vector 2 m/0'/2147483647'/1'/2147483646'", seed2, []uint32{0, 2147483647, 1, 2147483646}, "5837736c89570de861ebc173b1086da4f505d4adb387c6a1b1342d5e4ac9ec72"},
+		{"vector 2 m/0'/2147483647'/1'/2147483646'/2'", seed2, []uint32{0, 2147483647, 1, 2147483646, 2}, "551d333177df541ad876a60ea71f00447931c0a9da16f227c11ea080d7391b8d"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			key, err := deriveSLIP10Ed25519(mustDecodeHex(t, test.seed), test.path)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, hex.EncodeToString(key))
+		})
+	}
+}
+
+func TestDeriveSLIP10Ed25519_InvalidSeed(t *testing.T) {
+	_, err := deriveSLIP10Ed25519(make([]byte, 15), nil)
+	assert.EqualError(t, err, "invalid seed length 15")
+
+	_, err = deriveSLIP10Ed25519(make([]byte, 65), nil)
+	assert.EqualError(t, err, "invalid seed length 65")
+}
+
+// The first account of the mnemonic in the Solana CLI (`solana-keygen
+// recover 'prompt://?key=0/0'`) and Phantom.
+func TestNewVaultFromMnemonic_DeriveKey(t *testing.T) {
+	v, err := NewVaultFromMnemonic("  Abandon abandon abandon abandon abandon abandon\nabandon abandon abandon abandon abandon about ", "")
+	require.NoError(t, err)
+
+	pub, err := v.DeriveKey(0)
+	require.NoError(t, err)
+	assert.Equal(t, "HAgk14JpMQLgt6rVgv7cBQFJWFto5Dqxi472uT3DKpqk", pub.String())
+	assert.Equal(t, "m/44'/501'/0'/0'", v.KeyMetadata(pub).DerivationPath)
+	assert.Equal(t, uint32(1), v.NextDerivationIndex())
+
+	_, err = v.DeriveKey(0)
+	assert.Error(t, err, "already derived")
+
+	_, err = NewVaultFromMnemonic("abandon abandon abandon", "")
+	assert.Error(t, err)
+}
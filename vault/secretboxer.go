@@ -16,9 +16,9 @@ package vault
 
 import (
 	"fmt"
+	"io"
 	"os"
 
-	"github.com/pkg/errors"
 	"github.com/streamingfast/slnc/cli"
 )
 
@@ -28,13 +28,21 @@ type SecretBoxer interface {
 	WrapType() string
 }
 
-func SecretBoxerForType(boxerType string, keypath string) (SecretBoxer, error) {
+// KMSConfig is the key management service configuration of the KMS backed
+// boxers, `Key` identifies the key in the service and `Endpoint` optionally
+// overrides the address of the service.
+type KMSConfig struct {
+	Key      string
+	Endpoint string
+}
+
+func SecretBoxerForType(boxerType string, kms KMSConfig) (SecretBoxer, error) {
 	switch boxerType {
-	case "kms-gcp":
-		if keypath == "" {
-			return nil, errors.New("missing kms-gcp keypath")
+	case "kms-gcp", "kms-aws", "hashicorp-transit":
+		if kms.Key == "" {
+			return nil, fmt.Errorf("missing %s key", boxerType)
 		}
-		return NewKMSGCPBoxer(keypath), nil
+		return NewKMSBoxer(boxerType, kms), nil
 	case "passphrase":
 		var password string
 		var err error
@@ -48,7 +56,98 @@ func SecretBoxerForType(boxerType string, keypath string) (SecretBoxer, error) {
 		}
 
 		return NewPassphraseBoxer(password), nil
+	case "shamir":
+		return NewShamirOpener(PromptShamirShares(os.Stderr, cli.GetInput)), nil
 	default:
 		return nil, fmt.Errorf("unknown secret boxer: %s", boxerType)
 	}
 }
+
+// NewKMSBoxer returns the KMS backed boxer of type `boxerType`, nil when
+// the type is not a KMS one.
+func NewKMSBoxer(boxerType string, kms KMSConfig) SecretBoxer {
+	switch boxerType {
+	case "kms-gcp":
+		return NewKMSGCPBoxer(kms.Key)
+	case "kms-aws":
+		return NewKMSAWSBoxer(kms.Key, kms.Endpoint)
+	case "hashicorp-transit":
+		return NewHashicorpTransitBoxer(kms.Key, kms.Endpoint)
+	}
+	return nil
+}
+
+// PromptShamirShares returns a shares provider asking with `prompt` for the
+// files of the shares, the explanations being written to `out`. Protected
+// shares also get their passphrase asked.
+func PromptShamirShares(out io.Writer, prompt func(label string) (string, error)) func(threshold int) ([][]byte, error) {
+	return func(threshold int) ([][]byte, error) {
+		fmt.Fprintf(out, "The vault is split in shares, %d of them are required to open it.\n", threshold)
+
+		var shares [][]byte
+		for len(shares) < threshold {
+			filename, err := prompt(fmt.Sprintf("Enter the path of share file %d/%d: ", len(shares)+1, threshold))
+			if err != nil {
+				return nil, err
+			}
+
+			if filename == "" {
+				continue
+			}
+
+			share, err := readShamirShare(filename)
+			if err != nil {
+				return nil, err
+			}
+			shares = append(shares, share)
+		}
+
+		return shares, nil
+	}
+}
+
+// ShamirSharesFromFiles returns a shares provider reading the given share
+// files, protected shares get their passphrase asked.
+func ShamirSharesFromFiles(filenames []string) func(threshold int) ([][]byte, error) {
+	return func(threshold int) ([][]byte, error) {
+		if len(filenames) < threshold {
+			return nil, fmt.Errorf("%d share files are required to open the vault, got %d", threshold, len(filenames))
+		}
+
+		var shares [][]byte
+		for _, filename := range filenames {
+			share, err := readShamirShare(filename)
+			if err != nil {
+				return nil, err
+			}
+			shares = append(shares, share)
+		}
+		return shares, nil
+	}
+}
+
+func readShamirShare(filename string) ([]byte, error) {
+	f, err := ReadShamirShareFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("reading share file %q: %w", filename, err)
+	}
+
+	var boxer SecretBoxer
+	if f.IsProtected() {
+		if f.SecretBoxWrap != "passphrase" {
+			return nil, fmt.Errorf("share file %q: unsupported protection %q", filename, f.SecretBoxWrap)
+		}
+
+		password, err := cli.GetPassword(fmt.Sprintf("Enter passphrase of share #%d: ", f.Index))
+		if err != nil {
+			return nil, err
+		}
+		boxer = NewPassphraseBoxer(password)
+	}
+
+	share, err := f.OpenShare(boxer)
+	if err != nil {
+		return nil, fmt.Errorf("opening share file %q: %w", filename, err)
+	}
+	return share, nil
+}
@@ -0,0 +1,396 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vault
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+///
+/// Boxer implementation.
+///
+
+// ShamirBoxer encrypts the vault with a random key which is split in
+// `parts` shares, any `threshold` of them being required to recover it.
+type ShamirBoxer struct {
+	parts     int
+	threshold int
+
+	// shareProvider is called when opening the vault to get `threshold`
+	// shares of the key.
+	shareProvider func(threshold int) ([][]byte, error)
+
+	key    *[keyLength]byte
+	shares [][]byte
+}
+
+// NewShamirBoxer returns a boxer that seals the vault with a new key split
+// in `parts` shares with the given threshold, see `Shares`.
+func NewShamirBoxer(parts, threshold int) *ShamirBoxer {
+	return &ShamirBoxer{
+		parts:     parts,
+		threshold: threshold,
+	}
+}
+
+// NewShamirOpener returns a boxer that opens the vault with the shares
+// returned by `shareProvider`. Sealing again with the same boxer re-uses
+// the recovered key so the existing shares stay valid.
+func NewShamirOpener(shareProvider func(threshold int) ([][]byte, error)) *ShamirBoxer {
+	return &ShamirBoxer{
+		shareProvider: shareProvider,
+	}
+}
+
+func (b *ShamirBoxer) WrapType() string {
+	return "shamir"
+}
+
+// Parts returns the number of shares the key is split in.
+func (b *ShamirBoxer) Parts() int {
+	return b.parts
+}
+
+// Threshold returns the number of shares required to recover the key.
+func (b *ShamirBoxer) Threshold() int {
+	return b.threshold
+}
+
+// Shares returns the shares of the key generated by the last `Seal`, it's
+// empty when the vault was sealed again with a recovered key.
+func (b *ShamirBoxer) Shares() [][]byte {
+	return b.shares
+}
+
+type shamirBlob struct {
+	Version       int      `json:"version"`
+	Parts         int      `json:"parts"`
+	Threshold     int      `json:"threshold"`
+	Nonce         [24]byte `json:"nonce"`
+	EncryptedData []byte   `json:"data"`
+}
+
+func (b *ShamirBoxer) Seal(in []byte) (string, error) {
+	if b.key == nil {
+		var key [keyLength]byte
+		if _, err := io.ReadFull(rand.Reader, key[:shamirSecretLength]); err != nil {
+			return "", err
+		}
+
+		shares, err := SplitSecret(key[:shamirSecretLength], b.parts, b.threshold)
+		if err != nil {
+			return "", fmt.Errorf("splitting key: %w", err)
+		}
+
+		b.key = &key
+		b.shares = shares
+	}
+
+	var nonce [nonceLength]byte
+	if _, err := io.ReadFull(rand.Reader, nonce[:]); err != nil {
+		return "", err
+	}
+
+	cereal, err := json.Marshal(&shamirBlob{
+		Version:       1,
+		Parts:         b.parts,
+		Threshold:     b.threshold,
+		Nonce:         nonce,
+		EncryptedData: secretbox.Seal(nil, in, &nonce, b.key),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawStdEncoding.EncodeToString(cereal), nil
+}
+
+func (b *ShamirBoxer) Open(in string) ([]byte, error) {
+	data, err := base64.RawStdEncoding.DecodeString(in)
+	if err != nil {
+		return []byte{}, fmt.Errorf("base 64 decode, %s", err)
+	}
+
+	var blob shamirBlob
+	if err := json.Unmarshal(data, &blob); err != nil {
+		return []byte{}, fmt.Errorf("decoding shamir blob, %s", err)
+	}
+
+	if b.shareProvider == nil {
+		return []byte{}, fmt.Errorf("no shares provider")
+	}
+
+	shares, err := b.shareProvider(blob.Threshold)
+	if err != nil {
+		return []byte{}, fmt.Errorf("reading shares: %w", err)
+	}
+
+	if len(shares) < blob.Threshold {
+		return []byte{}, fmt.Errorf("%d shares are required to open the vault, got %d", blob.Threshold, len(shares))
+	}
+
+	secret, err := CombineShares(shares)
+	if err != nil {
+		return []byte{}, fmt.Errorf("combining shares: %w", err)
+	}
+
+	var key [keyLength]byte
+	copy(key[:], secret)
+
+	out, ok := secretbox.Open(nil, blob.EncryptedData, &blob.Nonce, &key)
+	if !ok {
+		return []byte{}, fmt.Errorf("failed to decrypt, the shares are invalid or do not belong to this vault")
+	}
+
+	b.parts = blob.Parts
+	b.threshold = blob.Threshold
+	b.key = &key
+	return out, nil
+}
+
+///
+/// Share files
+///
+
+// ShamirShareFile is the document holding one share of a vault key, handed
+// to a single person. The share can itself be passphrase protected.
+type ShamirShareFile struct {
+	Kind      string `json:"kind"`
+	Version   int    `json:"version"`
+	Index     int    `json:"index"`
+	Parts     int    `json:"parts"`
+	Threshold int    `json:"threshold"`
+
+	SecretBoxWrap       string `json:"secretbox_wrap,omitempty"`
+	SecretBoxCiphertext string `json:"secretbox_ciphertext,omitempty"`
+	Share               string `json:"share,omitempty"`
+}
+
+func NewShamirShareFile(index, parts, threshold int, share []byte, boxer SecretBoxer) (*ShamirShareFile, error) {
+	f := &ShamirShareFile{
+		Kind:      "solana-vault-shamir-share",
+		Version:   1,
+		Index:     index,
+		Parts:     parts,
+		Threshold: threshold,
+	}
+
+	if boxer == nil {
+		f.Share = base64.RawStdEncoding.EncodeToString(share)
+		return f, nil
+	}
+
+	cipherText, err := boxer.Seal(share)
+	if err != nil {
+		return nil, err
+	}
+
+	f.SecretBoxWrap = boxer.WrapType()
+	f.SecretBoxCiphertext = cipherText
+	return f, nil
+}
+
+func ReadShamirShareFile(filename string) (*ShamirShareFile, error) {
+	cnt, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &ShamirShareFile{}
+	if err := json.Unmarshal(cnt, f); err != nil {
+		return nil, err
+	}
+
+	if f.Kind != "solana-vault-shamir-share" {
+		return nil, fmt.Errorf("not a vault share file")
+	}
+	return f, nil
+}
+
+// IsProtected returns true when the share needs a boxer to be opened.
+func (f *ShamirShareFile) IsProtected() bool {
+	return f.SecretBoxWrap != ""
+}
+
+// OpenShare returns the share, `boxer` is used only when it is protected.
+func (f *ShamirShareFile) OpenShare(boxer SecretBoxer) ([]byte, error) {
+	if !f.IsProtected() {
+		return base64.RawStdEncoding.DecodeString(f.Share)
+	}
+
+	if boxer == nil {
+		return nil, fmt.Errorf("share is protected by %q", f.SecretBoxWrap)
+	}
+	return boxer.Open(f.SecretBoxCiphertext)
+}
+
+// WriteToFile writes the share file, it refuses to overwrite an existing
+// one.
+func (f *ShamirShareFile) WriteToFile(filename string) error {
+	cnt, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	fl, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+
+	if _, err := fl.Write(cnt); err != nil {
+		fl.Close()
+		return err
+	}
+
+	return fl.Close()
+}
+
+///
+/// Secret sharing, over GF(2^8)
+///
+
+// SplitSecret splits `secret` in `parts` shares, any `threshold` of them
+// being enough to recover it with `CombineShares`. Each share is as long
+// as the secret plus one byte, its x coordinate.
+func SplitSecret(secret []byte, parts, threshold int) ([][]byte, error) {
+	if len(secret) == 0 {
+		return nil, fmt.Errorf("cannot split an empty secret")
+	}
+	if threshold < 2 {
+		return nil, fmt.Errorf("threshold must be at least 2, got %d", threshold)
+	}
+	if parts < threshold {
+		return nil, fmt.Errorf("parts (%d) cannot be less than the threshold (%d)", parts, threshold)
+	}
+	if parts > 255 {
+		return nil, fmt.Errorf("parts cannot exceed 255, got %d", parts)
+	}
+
+	shares := make([][]byte, parts)
+	for i := range shares {
+		shares[i] = make([]byte, len(secret)+1)
+		shares[i][len(secret)] = byte(i + 1)
+	}
+
+	coefficients := make([]byte, threshold)
+	for idx, value := range secret {
+		coefficients[0] = value
+		if _, err := io.ReadFull(rand.Reader, coefficients[1:]); err != nil {
+			return nil, err
+		}
+
+		for i := range shares {
+			shares[i][idx] = gfEvaluate(coefficients, byte(i+1))
+		}
+	}
+
+	for i := range coefficients {
+		coefficients[i] = 0
+	}
+
+	return shares, nil
+}
+
+// CombineShares recovers the secret from shares produced by `SplitSecret`,
+// passing less shares than the threshold yields a wrong secret.
+func CombineShares(shares [][]byte) ([]byte, error) {
+	if len(shares) < 2 {
+		return nil, fmt.Errorf("at least 2 shares are required")
+	}
+
+	length := len(shares[0])
+	if length < 2 {
+		return nil, fmt.Errorf("shares are too short")
+	}
+
+	xs := make([]byte, len(shares))
+	seen := map[byte]bool{}
+	for i, share := range shares {
+		if len(share) != length {
+			return nil, fmt.Errorf("shares have different lengths")
+		}
+
+		x := share[length-1]
+		if x == 0 || seen[x] {
+			return nil, fmt.Errorf("share %d is invalid or duplicated", i+1)
+		}
+		seen[x] = true
+		xs[i] = x
+	}
+
+	secret := make([]byte, length-1)
+	for idx := range secret {
+		// Lagrange interpolation at x = 0
+		var value byte
+		for i, share := range shares {
+			basis := byte(1)
+			for j := range shares {
+				if i == j {
+					continue
+				}
+				basis = gfMul(basis, gfDiv(xs[j], xs[j]^xs[i]))
+			}
+			value ^= gfMul(share[idx], basis)
+		}
+		secret[idx] = value
+	}
+
+	return secret, nil
+}
+
+func gfEvaluate(coefficients []byte, x byte) byte {
+	// Horner's method, from the highest degree
+	var out byte
+	for i := len(coefficients) - 1; i >= 0; i-- {
+		out = gfMul(out, x) ^ coefficients[i]
+	}
+	return out
+}
+
+// gfMul multiplies in GF(2^8) modulo x^8 + x^4 + x^3 + x + 1, without
+// branching on the operands.
+func gfMul(a, b byte) byte {
+	var out byte
+	for i := 0; i < 8; i++ {
+		out ^= -(b & 1) & a
+		carry := a >> 7
+		a <<= 1
+		a ^= -carry & 0x1b
+		b >>= 1
+	}
+	return out
+}
+
+func gfInverse(a byte) byte {
+	// a^254 is the inverse of a, as a^255 = 1
+	out := byte(1)
+	for i := 0; i < 7; i++ {
+		a = gfMul(a, a)
+		out = gfMul(out, a)
+	}
+	return out
+}
+
+func gfDiv(a, b byte) byte {
+	return gfMul(a, gfInverse(b))
+}
@@ -0,0 +1,287 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vault
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitSecret_CombineEverySubset(t *testing.T) {
+	tests := []struct {
+		parts     int
+		threshold int
+	}{
+		{2, 2},
+		{3, 2},
+		{5, 3},
+		{6, 4},
+		{7, 7},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%d of %d", test.threshold, test.parts), func(t *testing.T) {
+			secret := make([]byte, shamirSecretLength)
+			_, err := rand.Read(secret)
+			require.NoError(t, err)
+
+			shares, err := SplitSecret(secret, test.parts, test.threshold)
+			require.NoError(t, err)
+			require.Len(t, shares, test.parts)
+			for i, share := range shares {
+				assert.Len(t, share, len(secret)+1)
+				assert.Equal(t, byte(i+1), share[len(secret)])
+			}
+
+			for subset := 1; subset < 1<<test.parts; subset++ {
+				var picked [][]byte
+				for i := test.parts - 1; i >= 0; i-- {
+					if subset&(1<<i) != 0 {
+						picked = append(picked, shares[i])
+					}
+				}
+
+				if len(picked) < 2 {
+					continue
+				}
+
+				recovered, err := CombineShares(picked)
+				require.NoError(t, err)
+				if len(picked) >= test.threshold {
+					assert.Equal(t, secret, recovered, "subset %b", subset)
+				} else {
+					assert.NotEqual(t, secret, recovered, "subset %b is below the threshold", subset)
+				}
+			}
+		})
+	}
+}
+
+func TestSplitSecret_Errors(t *testing.T) {
+	_, err := SplitSecret(nil, 3, 2)
+	assert.EqualError(t, err, "cannot split an empty secret")
+
+	_, err = SplitSecret([]byte("secret"), 3, 1)
+	assert.EqualError(t, err, "threshold must be at least 2, got 1")
+
+	_, err = SplitSecret([]byte("secret"), 2, 3)
+	assert.EqualError(t, err, "parts (2) cannot be less than the threshold (3)")
+
+	_, err = SplitSecret([]byte("secret"), 256, 3)
+	assert.EqualError(t, err, "parts cannot exceed 255, got 256")
+}
+
+func TestCombineShares_Rejects(t *testing.T) {
+	shares, err := SplitSecret([]byte("secret"), 3, 2)
+	require.NoError(t, err)
+
+	_, err = CombineShares(nil)
+	assert.EqualError(t, err, "at least 2 shares are required")
+
+	_, err = CombineShares(shares[:1])
+	assert.EqualError(t, err, "at least 2 shares are required")
+
+	_, err = CombineShares([][]byte{shares[0], shares[1], shares[0]})
+	assert.EqualError(t, err, "share 3 is invalid or duplicated")
+
+	zeroX := append([]byte{}, shares[1]...)
+	zeroX[len(zeroX)-1] = 0
+	_, err = CombineShares([][]byte{shares[0], zeroX})
+	assert.EqualError(t, err, "share 2 is invalid or duplicated")
+
+	_, err = CombineShares([][]byte{shares[0], shares[1][1:]})
+	assert.EqualError(t, err, "shares have different lengths")
+
+	_, err = CombineShares([][]byte{{1}, {2}})
+	assert.EqualError(t, err, "shares are too short")
+}
+
+// gfTables returns the exponential and logarithm tables of GF(2^8) with
+// the generator 3, computed independently of `gfMul`.
+func gfTables() (exp [255]byte, log [256]byte) {
+	x := byte(1)
+	for i := 0; i < 255; i++ {
+		exp[i] = x
+		log[x] = byte(i)
+
+		// x * 3 = x * 2 + x, reduced by x^8 + x^4 + x^3 + x + 1
+		double := x << 1
+		if x&0x80 != 0 {
+			double ^= 0x1b
+		}
+		x ^= double
+	}
+	return
+}
+
+func TestGF_Tables(t *testing.T) {
+	exp, log := gfTables()
+
+	seen := map[byte]bool{}
+	for _, value := range exp {
+		seen[value] = true
+	}
+	require.Len(t, seen, 255, "3 generates the multiplicative group")
+
+	for a := 0; a < 256; a++ {
+		for b := 0; b < 256; b++ {
+			expected := byte(0)
+			if a != 0 && b != 0 {
+				expected = exp[(int(log[a])+int(log[b]))%255]
+			}
+			require.Equal(t, expected, gfMul(byte(a), byte(b)), "%#x * %#x", a, b)
+		}
+	}
+}
+
+func TestGF_Field(t *testing.T) {
+	// FIPS-197, section 4.2
+	assert.Equal(t, byte(0xc1), gfMul(0x57, 0x83))
+	assert.Equal(t, byte(0xfe), gfMul(0x57, 0x13))
+
+	for a := 0; a < 256; a++ {
+		assert.Equal(t, byte(0), gfMul(byte(a), 0))
+		assert.Equal(t, byte(a), gfMul(byte(a), 1))
+		if a == 0 {
+			continue
+		}
+
+		inverse := gfInverse(byte(a))
+		assert.Equal(t, byte(1), gfMul(byte(a), inverse), "inverse of %#x", a)
+
+		for b := 1; b < 256; b++ {
+			assert.Equal(t, byte(a), gfDiv(gfMul(byte(a), byte(b)), byte(b)))
+			for c := 0; c < 256; c += 17 {
+				assert.Equal(t, gfMul(byte(a), byte(b)^byte(c)), gfMul(byte(a), byte(b))^gfMul(byte(a), byte(c)))
+			}
+		}
+	}
+}
+
+func TestGF_Evaluate(t *testing.T) {
+	coefficients := []byte{0x2a, 0x03, 0xf1, 0x80}
+
+	assert.Equal(t, coefficients[0], gfEvaluate(coefficients, 0))
+	for x := 1; x < 256; x++ {
+		var expected byte
+		power := byte(1)
+		for _, coefficient := range coefficients {
+			expected ^= gfMul(coefficient, power)
+			power = gfMul(power, byte(x))
+		}
+		assert.Equal(t, expected, gfEvaluate(coefficients, byte(x)), "x = %#x", x)
+	}
+}
+
+func TestShamirBoxer_SealOpen(t *testing.T) {
+	boxer := NewShamirBoxer(5, 3)
+	sealed, err := boxer.Seal([]byte("vault content"))
+	require.NoError(t, err)
+
+	shares := boxer.Shares()
+	require.Len(t, shares, 5)
+
+	opener := NewShamirOpener(func(threshold int) ([][]byte, error) {
+		assert.Equal(t, 3, threshold)
+		return [][]byte{shares[4], shares[1], shares[2]}, nil
+	})
+	opened, err := opener.Open(sealed)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("vault content"), opened)
+	assert.Equal(t, 5, opener.Parts())
+	assert.Equal(t, 3, opener.Threshold())
+
+	resealed, err := opener.Seal([]byte("new content"))
+	require.NoError(t, err)
+	assert.Empty(t, opener.Shares(), "the recovered key is re-used")
+
+	opened, err = NewShamirOpener(func(int) ([][]byte, error) { return shares[:3], nil }).Open(resealed)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("new content"), opened)
+}
+
+func TestShamirBoxer_OpenRejects(t *testing.T) {
+	boxer := NewShamirBoxer(5, 3)
+	sealed, err := boxer.Seal([]byte("vault content"))
+	require.NoError(t, err)
+	shares := boxer.Shares()
+
+	other := NewShamirBoxer(5, 3)
+	_, err = other.Seal([]byte("other content"))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		shares [][]byte
+		expect string
+	}{
+		{"too few", shares[:2], "3 shares are required to open the vault, got 2"},
+		{"duplicated", [][]byte{shares[0], shares[1], shares[0]}, "combining shares: share 3 is invalid or duplicated"},
+		{"other vault", other.Shares()[:3], "failed to decrypt, the shares are invalid or do not belong to this vault"},
+		{"mixed vaults", [][]byte{shares[0], shares[1], other.Shares()[2]}, "failed to decrypt, the shares are invalid or do not belong to this vault"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := NewShamirOpener(func(int) ([][]byte, error) { return test.shares, nil }).Open(sealed)
+			assert.EqualError(t, err, test.expect)
+		})
+	}
+}
+
+func TestPromptShamirShares(t *testing.T) {
+	shares, err := SplitSecret([]byte("secret"), 3, 2)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	var filenames []string
+	for i, share := range shares {
+		file, err := NewShamirShareFile(i+1, 3, 2, share, nil)
+		require.NoError(t, err)
+
+		filename := filepath.Join(dir, fmt.Sprintf("share-%d.json", i+1))
+		require.NoError(t, file.WriteToFile(filename))
+		filenames = append(filenames, filename)
+	}
+
+	answers := []string{"", filenames[2], filenames[0]}
+	var labels []string
+	prompt := func(label string) (string, error) {
+		labels = append(labels, label)
+		answer := answers[0]
+		answers = answers[1:]
+		return answer, nil
+	}
+
+	out := &bytes.Buffer{}
+	read, err := PromptShamirShares(out, prompt)(2)
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{shares[2], shares[0]}, read)
+	assert.Equal(t, "The vault is split in shares, 2 of them are required to open it.\n", out.String())
+	assert.Equal(t, []string{
+		"Enter the path of share file 1/2: ",
+		"Enter the path of share file 1/2: ",
+		"Enter the path of share file 2/2: ",
+	}, labels)
+
+	_, err = PromptShamirShares(out, func(string) (string, error) { return filepath.Join(dir, "missing.json"), nil })(2)
+	assert.Error(t, err)
+}
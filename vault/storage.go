@@ -0,0 +1,346 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vault
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/streamingfast/dstore"
+	"google.golang.org/api/googleapi"
+)
+
+// ErrConcurrentUpdate is returned when writing a remote vault file that
+// changed since it was read, or that exists when it's created.
+var ErrConcurrentUpdate = errors.New("vault file was modified by someone else since it was read")
+
+// LocalPath returns the path of the vault file `location` when it's on the
+// local file system, a plain path or a `file://` URL. Other locations are
+// object storage URLs (gs://, s3:// or az://) handled through `dstore`.
+func LocalPath(location string) (path string, local bool) {
+	u, err := url.Parse(location)
+	if err != nil || u.Scheme == "" || len(u.Scheme) == 1 {
+		// A one letter scheme is a Windows drive
+		return location, true
+	}
+
+	if u.Scheme == "file" {
+		return u.Host + u.Path, true
+	}
+
+	return "", false
+}
+
+// LoadVault reads the vault file at `location`, a local path or an object
+// storage URL. The version of a remote file is kept so that `Save` and
+// `Replace` fail with `ErrConcurrentUpdate` when it changes meanwhile.
+func LoadVault(ctx context.Context, location string) (*Vault, error) {
+	if path, local := LocalPath(location); local {
+		return NewVaultFromWalletFile(path)
+	}
+
+	file, err := openRemoteFile(location)
+	if err != nil {
+		return nil, err
+	}
+
+	cnt, version, err := file.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	v := NewVault()
+	if err := json.Unmarshal(cnt, v); err != nil {
+		return nil, err
+	}
+
+	v.loadedFrom, v.loadedVersion = location, version
+	return v, nil
+}
+
+// VaultExists returns whether a vault file exists at `location`.
+func VaultExists(ctx context.Context, location string) (bool, error) {
+	if path, local := LocalPath(location); local {
+		_, err := os.Stat(path)
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return err == nil, err
+	}
+
+	file, err := openRemoteFile(location)
+	if err != nil {
+		return false, err
+	}
+
+	_, _, err = file.read(ctx)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Save atomically writes the vault to `location`. A remote vault file is
+// only replaced when it's the one the vault was loaded from and it did not
+// change since, and only created when it does not exist.
+func (v *Vault) Save(ctx context.Context, location string) error {
+	cnt, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if path, local := LocalPath(location); local {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return writeNewFile(path, cnt)
+		}
+		return writeFileAtomic(path, cnt)
+	}
+
+	return v.saveRemote(ctx, location, cnt)
+}
+
+// Replace atomically replaces the existing vault file `location`, a copy
+// of the previous one is kept next to it and its location is returned.
+func (v *Vault) Replace(ctx context.Context, location string) (backupLocation string, err error) {
+	if path, local := LocalPath(location); local {
+		return v.ReplaceFile(path)
+	}
+
+	cnt, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	file, err := openRemoteFile(location)
+	if err != nil {
+		return "", err
+	}
+
+	previous, version, err := file.read(ctx)
+	if err != nil {
+		return "", fmt.Errorf("read current vault: %w", err)
+	}
+
+	if v.loadedFrom != location || v.loadedVersion != version {
+		return "", ErrConcurrentUpdate
+	}
+
+	backupLocation = fmt.Sprintf("%s.bak-%s", location, time.Now().UTC().Format("20060102T150405Z"))
+	backup, err := openRemoteFile(backupLocation)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := backup.write(ctx, previous, ""); err != nil {
+		return "", fmt.Errorf("write backup: %w", err)
+	}
+
+	if err := v.saveRemote(ctx, location, cnt); err != nil {
+		return backupLocation, err
+	}
+	return backupLocation, nil
+}
+
+func (v *Vault) saveRemote(ctx context.Context, location string, cnt []byte) error {
+	file, err := openRemoteFile(location)
+	if err != nil {
+		return err
+	}
+
+	version := ""
+	if v.loadedFrom == location {
+		version = v.loadedVersion
+	}
+
+	newVersion, err := file.write(ctx, cnt, version)
+	if err != nil {
+		return err
+	}
+
+	v.loadedFrom, v.loadedVersion = location, newVersion
+	return nil
+}
+
+// remoteFile is a vault file in object storage, `version` identifies its
+// content: the object generation on Google Cloud Storage and the hash of
+// the content on the other backends.
+type remoteFile interface {
+	// read returns the content and version of the file, an error wrapping
+	// `os.ErrNotExist` when it does not exist.
+	read(ctx context.Context) (cnt []byte, version string, err error)
+	// write replaces the file when its current version is `version`, or
+	// creates it when `version` is empty and it does not exist, and
+	// returns the version of the written file.
+	write(ctx context.Context, cnt []byte, version string) (newVersion string, err error)
+}
+
+func openRemoteFile(location string) (remoteFile, error) {
+	u, err := url.Parse(location)
+	if err != nil {
+		return nil, fmt.Errorf("invalid vault location %q: %w", location, err)
+	}
+
+	switch u.Scheme {
+	case "gs":
+		return &gsFile{bucket: u.Host, object: strings.TrimPrefix(u.Path, "/")}, nil
+	case "s3", "az":
+		store, filename, err := dstore.NewStoreFromURL(location, dstore.AllowOverwrite())
+		if err != nil {
+			return nil, fmt.Errorf("invalid vault location %q: %w", location, err)
+		}
+		return &dstoreFile{store: store, filename: filename}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported vault location %q, expected a local path or a gs://, s3://, az:// or file:// URL", location)
+}
+
+// gsFile writes with generation preconditions, a concurrent update of the
+// object is always detected.
+type gsFile struct {
+	bucket string
+	object string
+}
+
+func (f *gsFile) handle(ctx context.Context) (*storage.ObjectHandle, func(), error) {
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("google storage client: %w", err)
+	}
+	return client.Bucket(f.bucket).Object(f.object), func() { client.Close() }, nil
+}
+
+func (f *gsFile) read(ctx context.Context) ([]byte, string, error) {
+	object, closeClient, err := f.handle(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	defer closeClient()
+
+	attrs, err := object.Attrs(ctx)
+	if err == storage.ErrObjectNotExist {
+		return nil, "", fmt.Errorf("gs://%s/%s: %w", f.bucket, f.object, os.ErrNotExist)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	reader, err := object.Generation(attrs.Generation).NewReader(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	defer reader.Close()
+
+	cnt, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return cnt, strconv.FormatInt(attrs.Generation, 10), nil
+}
+
+func (f *gsFile) write(ctx context.Context, cnt []byte, version string) (string, error) {
+	object, closeClient, err := f.handle(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer closeClient()
+
+	conditions := storage.Conditions{DoesNotExist: true}
+	if version != "" {
+		generation, err := strconv.ParseInt(version, 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("invalid generation %q: %w", version, err)
+		}
+		conditions = storage.Conditions{GenerationMatch: generation}
+	}
+
+	writer := object.If(conditions).NewWriter(ctx)
+	writer.ContentType = "application/json"
+	if _, err := writer.Write(cnt); err != nil {
+		writer.Close()
+		return "", err
+	}
+
+	if err := writer.Close(); err != nil {
+		if apiErr, ok := err.(*googleapi.Error); ok && apiErr.Code == http.StatusPreconditionFailed {
+			return "", ErrConcurrentUpdate
+		}
+		return "", err
+	}
+
+	// The generation of the object as written, an update landing right
+	// after is caught by the next write.
+	return strconv.FormatInt(writer.Attrs().Generation, 10), nil
+}
+
+// dstoreFile compares the content of the object right before replacing
+// it, S3 and Azure uploads replace an object atomically but a concurrent
+// update landing between the comparison and the upload goes unnoticed.
+type dstoreFile struct {
+	store    dstore.Store
+	filename string
+}
+
+func (f *dstoreFile) read(ctx context.Context) ([]byte, string, error) {
+	reader, err := f.store.OpenObject(ctx, f.filename)
+	if err == dstore.ErrNotFound {
+		return nil, "", fmt.Errorf("%s: %w", f.store.ObjectURL(f.filename), os.ErrNotExist)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	defer reader.Close()
+
+	cnt, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return cnt, contentVersion(cnt), nil
+}
+
+func (f *dstoreFile) write(ctx context.Context, cnt []byte, version string) (string, error) {
+	_, current, err := f.read(ctx)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+
+	if current != version {
+		return "", ErrConcurrentUpdate
+	}
+
+	if err := f.store.WriteObject(ctx, f.filename, bytes.NewReader(cnt)); err != nil {
+		return "", err
+	}
+	return contentVersion(cnt), nil
+}
+
+func contentVersion(cnt []byte) string {
+	sum := sha256.Sum256(cnt)
+	return hex.EncodeToString(sum[:])
+}
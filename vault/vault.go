@@ -17,7 +17,10 @@ package vault
 import (
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/streamingfast/solana-go"
 )
@@ -30,10 +33,47 @@ type Vault struct {
 	Version int    `json:"version"`
 	Comment string `json:"comment"`
 
+	// PublicKeys is the plaintext index of the keys held by the vault,
+	// written by `Seal`, see `PublicKeyIndexEntry`.
+	PublicKeys []*PublicKeyIndexEntry `json:"public_keys"`
+
+	// WatchOnly lists the addresses tracked without their private key, see
+	// `WatchOnlyAddress`. Like `PublicKeys` it's in plaintext, so they're
+	// listed without opening the vault.
+	WatchOnly []*WatchOnlyAddress `json:"watch_only,omitempty"`
+
 	SecretBoxWrap       string `json:"secretbox_wrap"`
 	SecretBoxCiphertext string `json:"secretbox_ciphertext"`
 
 	KeyBag []solana.PrivateKey `json:"-"`
+
+	// Metadata holds the label, creation time, tags and notes of the keys
+	// of the `KeyBag`, by public key. It's sealed along the private keys
+	// since version 2 of the vault format.
+	Metadata map[string]*KeyMetadata `json:"-"`
+
+	// Seed is the BIP39 seed the keys are derived from, see `DeriveKey`.
+	// It's sealed along the private keys since version 3 of the vault
+	// format, vaults of random keys have none.
+	Seed []byte `json:"-"`
+
+	// loadedFrom and loadedVersion identify the remote file the vault was
+	// loaded from, see `LoadVault`.
+	loadedFrom    string
+	loadedVersion string
+}
+
+// CurrentVersion is the version of the vault format written by `Seal`,
+// older vaults are upgraded when sealed. Version 1 vaults only hold private
+// keys, version 2 adds their metadata, version 3 the BIP39 seed and version
+// 4 the watch-only addresses, in plaintext next to the public key index
+// which covers them and the ciphertext.
+const CurrentVersion = 4
+
+// NeedsUpgrade returns whether the vault file predates the current format,
+// either an older version or one written without its public key index.
+func (v *Vault) NeedsUpgrade() bool {
+	return v.Version < CurrentVersion || !v.HasPublicKeyIndex()
 }
 
 // NewVaultFromWalletFile returns a new Vault instance from the
@@ -80,8 +120,9 @@ func NewVaultFromSingleKey(privKey string) (*Vault, error) {
 // NewVault returns an empty vault, unsaved and with no keys.
 func NewVault() *Vault {
 	return &Vault{
-		Kind:    "solana-vault-wallet",
-		Version: 1,
+		Kind:     "solana-vault-wallet",
+		Version:  CurrentVersion,
+		Metadata: map[string]*KeyMetadata{},
 	}
 }
 
@@ -94,15 +135,32 @@ func (v *Vault) NewKeyPair() (pub solana.PublicKey, err error) {
 		return
 	}
 
-	v.KeyBag = append(v.KeyBag, privKey)
+	v.AddPrivateKey(privKey)
 
 	return
 }
 
-// AddPrivateKey appends the provided private key into the Vault's KeyBag
+// AddPrivateKey appends the provided private key into the Vault's KeyBag,
+// recording its creation time. A watch-only entry for the key is replaced
+// by it, keeping its label and notes.
 func (v *Vault) AddPrivateKey(privateKey solana.PrivateKey) solana.PublicKey {
 	v.KeyBag = append(v.KeyBag, privateKey)
-	return privateKey.PublicKey()
+
+	pub := privateKey.PublicKey()
+	if v.Metadata == nil {
+		v.Metadata = map[string]*KeyMetadata{}
+	}
+	if _, found := v.Metadata[pub.String()]; !found {
+		v.Metadata[pub.String()] = &KeyMetadata{CreatedAt: time.Now().UTC().Truncate(time.Second)}
+	}
+
+	if watched := v.WatchOnlyAddress(pub); watched != nil {
+		v.RemoveWatchOnly(pub)
+		v.Metadata[pub.String()].Label = watched.Label
+		v.Metadata[pub.String()].Notes = watched.Notes
+	}
+
+	return pub
 }
 
 // PrintPublicKeys prints a PublicKey corresponding to each PrivateKey in the Vault's
@@ -143,32 +201,155 @@ func (v *Vault) WriteToFile(filename string) error {
 	return fl.Close()
 }
 
+// ReplaceFile atomically replaces the existing vault file `filename`, a
+// copy of the previous one is kept next to it and its name is returned.
+func (v *Vault) ReplaceFile(filename string) (backupFilename string, err error) {
+	cnt, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	previous, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return "", fmt.Errorf("read current vault: %w", err)
+	}
+
+	backupFilename = fmt.Sprintf("%s.bak-%s", filename, time.Now().UTC().Format("20060102T150405Z"))
+	if err := writeNewFile(backupFilename, previous); err != nil {
+		return "", fmt.Errorf("write backup: %w", err)
+	}
+
+	if err := writeFileAtomic(filename, cnt); err != nil {
+		return backupFilename, err
+	}
+
+	return backupFilename, nil
+}
+
+func writeNewFile(filename string, cnt []byte) error {
+	fl, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+
+	if _, err := fl.Write(cnt); err != nil {
+		fl.Close()
+		return err
+	}
+
+	return fl.Close()
+}
+
+// writeFileAtomic writes `cnt` to a temporary file in the directory of
+// `filename` then renames it, a reader never sees a partially written file.
+func writeFileAtomic(filename string, cnt []byte) error {
+	fl, err := ioutil.TempFile(filepath.Dir(filename), "."+filepath.Base(filename)+".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(fl.Name())
+
+	if err := fl.Chmod(0600); err != nil {
+		fl.Close()
+		return err
+	}
+
+	if _, err := fl.Write(cnt); err != nil {
+		fl.Close()
+		return err
+	}
+
+	if err := fl.Sync(); err != nil {
+		fl.Close()
+		return err
+	}
+
+	if err := fl.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(fl.Name(), filename)
+}
+
 func (v *Vault) Open(boxer SecretBoxer) error {
 	data, err := boxer.Open(v.SecretBoxCiphertext)
 	if err != nil {
 		return fmt.Errorf("opening boxer: %w", err)
 	}
 
-	err = json.Unmarshal(data, &v.KeyBag)
-	if err != nil {
-		return fmt.Errorf("unmarshal: %w", err)
+	v.Metadata = map[string]*KeyMetadata{}
+
+	switch v.Version {
+	case 1:
+		err = json.Unmarshal(data, &v.KeyBag)
+		if err != nil {
+			return fmt.Errorf("unmarshal: %w", err)
+		}
+
+	case 2:
+		var entries []*keyEntry
+		err = json.Unmarshal(data, &entries)
+		if err != nil {
+			return fmt.Errorf("unmarshal: %w", err)
+		}
+
+		v.setKeyEntries(entries)
+
+	case 3, 4:
+		var payload payloadV3
+		err = json.Unmarshal(data, &payload)
+		if err != nil {
+			return fmt.Errorf("unmarshal: %w", err)
+		}
+
+		v.Seed = payload.Seed
+		v.setKeyEntries(payload.Keys)
+
+	default:
+		return fmt.Errorf("unsupported vault version %d, at most version %d is supported", v.Version, CurrentVersion)
 	}
 
 	return nil
 }
 
+func (v *Vault) setKeyEntries(entries []*keyEntry) {
+	v.KeyBag = nil
+	for _, entry := range entries {
+		v.KeyBag = append(v.KeyBag, entry.PrivateKey)
+		v.Metadata[entry.PrivateKey.PublicKey().String()] = &entry.KeyMetadata
+	}
+}
+
+// payloadV3 is the sealed payload of a version 3 vault, unchanged in
+// version 4.
+type payloadV3 struct {
+	Seed []byte      `json:"seed,omitempty"`
+	Keys []*keyEntry `json:"keys"`
+}
+
+// Seal encrypts the keys and their metadata with `boxer`, the vault is
+// written in the current version of the format.
 func (v *Vault) Seal(boxer SecretBoxer) error {
-	payload, err := json.Marshal(v.KeyBag)
+	entries := make([]*keyEntry, len(v.KeyBag))
+	for i, key := range v.KeyBag {
+		entries[i] = &keyEntry{PrivateKey: key}
+		if metadata := v.Metadata[key.PublicKey().String()]; metadata != nil {
+			entries[i].KeyMetadata = *metadata
+		}
+	}
+
+	payload, err := json.Marshal(&payloadV3{Seed: v.Seed, Keys: entries})
 	if err != nil {
 		return err
 	}
 
-	v.SecretBoxWrap = boxer.WrapType()
 	cipherText, err := boxer.Seal(payload)
 	if err != nil {
 		return err
 	}
 
+	v.Version = CurrentVersion
+	v.SecretBoxWrap = boxer.WrapType()
 	v.SecretBoxCiphertext = cipherText
-	return nil
+	return v.buildPublicKeyIndex()
 }
@@ -0,0 +1,129 @@
+// Copyright 2020 dfuse Platform Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vault
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/streamingfast/solana-go"
+)
+
+// WatchOnlyAddress is an address tracked by the vault without its private
+// key, for balances reporting and `@label` references. Watch-only
+// addresses are in plaintext next to the public key index since version 4
+// of the vault format.
+type WatchOnlyAddress struct {
+	PublicKey solana.PublicKey `json:"public_key"`
+	Label     string           `json:"label,omitempty"`
+	CreatedAt time.Time        `json:"created_at"`
+	Notes     string           `json:"notes,omitempty"`
+}
+
+// WatchOnlyAddress returns the watch-only entry of `pub`, nil when the
+// vault does not track it.
+func (v *Vault) WatchOnlyAddress(pub solana.PublicKey) *WatchOnlyAddress {
+	for _, address := range v.WatchOnly {
+		if address.PublicKey.Equals(pub) {
+			return address
+		}
+	}
+	return nil
+}
+
+// FindWatchOnlyByLabel returns the watch-only address labeled `label`.
+func (v *Vault) FindWatchOnlyByLabel(label string) (*WatchOnlyAddress, bool) {
+	for _, address := range v.WatchOnly {
+		if address.Label == label {
+			return address, true
+		}
+	}
+	return nil, false
+}
+
+// AddWatchOnly starts tracking `pub` without its private key, it fails
+// when the vault already holds the key of `pub` or tracks it, or holds no
+// key to sign the watch-only addresses, see `PublicKeyIndexEntry`.
+func (v *Vault) AddWatchOnly(pub solana.PublicKey, label string) (*WatchOnlyAddress, error) {
+	if len(v.KeyBag) == 0 {
+		return nil, fmt.Errorf("vault holds no key to vouch for watch-only addresses, add one first")
+	}
+
+	if _, found := v.PrivateKey(pub); found {
+		return nil, fmt.Errorf("vault already holds the key of %s", pub)
+	}
+
+	if v.WatchOnlyAddress(pub) != nil {
+		return nil, fmt.Errorf("address %s is already watched", pub)
+	}
+
+	address := &WatchOnlyAddress{PublicKey: pub, CreatedAt: time.Now().UTC().Truncate(time.Second)}
+	v.WatchOnly = append(v.WatchOnly, address)
+
+	if err := v.SetWatchOnlyLabel(pub, label); err != nil {
+		v.RemoveWatchOnly(pub)
+		return nil, err
+	}
+
+	return address, nil
+}
+
+// SetWatchOnlyLabel labels the watch-only address `pub`, an empty label
+// removes the current one. Labels are shared with the keys of the vault.
+func (v *Vault) SetWatchOnlyLabel(pub solana.PublicKey, label string) error {
+	address := v.WatchOnlyAddress(pub)
+	if address == nil {
+		return fmt.Errorf("address %s is not watched by the vault", pub)
+	}
+
+	if label != "" {
+		if err := v.checkLabelAvailable(pub, label); err != nil {
+			return err
+		}
+	}
+
+	address.Label = label
+	return nil
+}
+
+// RemoveWatchOnly stops tracking `pub`, it returns false when the vault
+// does not track it.
+func (v *Vault) RemoveWatchOnly(pub solana.PublicKey) bool {
+	for i, address := range v.WatchOnly {
+		if address.PublicKey.Equals(pub) {
+			v.WatchOnly = append(v.WatchOnly[:i], v.WatchOnly[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// checkLabelAvailable ensures `label` is valid and not used by a key or a
+// watch-only address other than `pub`.
+func (v *Vault) checkLabelAvailable(pub solana.PublicKey, label string) error {
+	if err := ValidateLabel(label); err != nil {
+		return err
+	}
+
+	if other, found := v.FindByLabel(label); found && !other.PublicKey().Equals(pub) {
+		return fmt.Errorf("label %q is already used by key %s", label, other.PublicKey())
+	}
+
+	if other, found := v.FindWatchOnlyByLabel(label); found && !other.PublicKey.Equals(pub) {
+		return fmt.Errorf("label %q is already used by watch-only address %s", label, other.PublicKey)
+	}
+
+	return nil
+}